@@ -5,47 +5,83 @@ package app
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/sjmudd/anonymiser"
+	"github.com/sjmudd/ps-top/baseobject"
+	"github.com/sjmudd/ps-top/capabilities"
+	"github.com/sjmudd/ps-top/collector"
 	"github.com/sjmudd/ps-top/connector"
 	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
 	"github.com/sjmudd/ps-top/display"
 	"github.com/sjmudd/ps-top/event"
+	"github.com/sjmudd/ps-top/exporter"
 	"github.com/sjmudd/ps-top/global"
 	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/logger"
+	"github.com/sjmudd/ps-top/model/custom"
 	"github.com/sjmudd/ps-top/model/filter"
 	"github.com/sjmudd/ps-top/ps_table"
 	"github.com/sjmudd/ps-top/setup_instruments"
 	"github.com/sjmudd/ps-top/view"
 	"github.com/sjmudd/ps-top/wait_info"
+	"github.com/sjmudd/ps-top/window"
+	"github.com/sjmudd/ps-top/wrapper/blocking"
+	custom_wrapper "github.com/sjmudd/ps-top/wrapper/custom"
+	"github.com/sjmudd/ps-top/wrapper/digest_latency"
 	"github.com/sjmudd/ps-top/wrapper/file_io_latency"
+	"github.com/sjmudd/ps-top/wrapper/global_status"
+	"github.com/sjmudd/ps-top/wrapper/index_usage"
 	"github.com/sjmudd/ps-top/wrapper/memory_usage"
 	"github.com/sjmudd/ps-top/wrapper/mutex_latency"
+	"github.com/sjmudd/ps-top/wrapper/replication"
 	"github.com/sjmudd/ps-top/wrapper/stages_latency"
+	"github.com/sjmudd/ps-top/wrapper/table_detail"
 	"github.com/sjmudd/ps-top/wrapper/table_io_latency"
 	"github.com/sjmudd/ps-top/wrapper/table_io_ops"
 	"github.com/sjmudd/ps-top/wrapper/table_lock_latency"
+	"github.com/sjmudd/ps-top/wrapper/threads"
 	"github.com/sjmudd/ps-top/wrapper/user_latency"
+	"github.com/sjmudd/ps-top/wrapper/wait_latency"
 )
 
+// minQueryTimeout is the shortest time a collection may take before
+// its queries are cancelled
+const minQueryTimeout = 5 * time.Second
+
 // Flags for initialising the app
 type Settings struct {
-	Anonymise  bool                   // Do we want to anonymise data shown?
-	ConnFlags  connector.Flags        // database connection flags
-	Count      int                    // number of collections to take (ps-stats)
-	Filter     *filter.DatabaseFilter // optional names of databases to filter on
-	Interval   int                    // default interval to poll information
-	Limit      int                    // limit the number of lines of output shown?
-	OnlyTotals bool                   // show only totals?
-	Stdout     bool                   // output to stdout?
-	View       string                 // which view to start with
+	AllowKill      bool                     // allow killing queries and connections from the threads view?
+	Anonymise      bool                     // Do we want to anonymise data shown?
+	ConnFlags      connector.Flags          // database connection flags
+	Count          int                      // number of collections to take (ps-stats)
+	Filter         *filter.DatabaseFilter   // optional names of databases to filter on
+	Format         display.Format           // format of the output when sent to stdout
+	HiddenColumns  map[string][]string      // columns to hide in each view, by view name
+	Interval       int                      // default interval to poll information
+	Listen         string                   // address to serve Prometheus metrics on (ps-stats)
+	Limit          int                      // limit the number of lines of output shown?
+	Record         string                   // file to record the collected data to
+	Replay         string                   // file to replay the data from rather than collecting it from MySQL
+	OnlyTotals     bool                     // show only totals?
+	Sort           string                   // column to sort the initial view by
+	SortColumns    map[string]string        // column to sort each view by, by view name
+	StatusPrefixes []string                 // prefixes of the variables to show in the global_status view
+	Stdout         bool                     // output to stdout?
+	View           string                   // which view to start with
+	ViewIntervals  map[string]time.Duration // intervals to collect views in the background at if not Interval
+	Views          []custom.Definition      // views defined by the user in ~/.pstoprc
+	WantRates      bool                     // show values per second?
+	WaitClasses    []string                 // wait classes to collect for the wait_latency view
+	WantWindow     bool                     // start showing statistics over the sliding window?
+	Window         window.Window            // size of the sliding window
 }
 
 // App holds the data needed by an application
@@ -58,31 +94,56 @@ type App struct {
 	wi                 wait_info.WaitInfo
 	Finished           bool // has the app finished?
 	stdout             bool
+	connector          *connector.Connector // set if collecting from MySQL
 	db                 *sql.DB
-	Help               bool // do we want help?
+	restarts           int // number of MySQL restarts we have reset the statistics for
+	source             datasource.Source
+	replayer           *datasource.Replayer // set if replaying a recording
+	Help               bool                 // do we want help?
+	listen             string               // address to serve Prometheus metrics on
 	file_io_latency    ps_table.Tabler
 	table_io_latency   ps_table.Tabler
 	table_io_ops       ps_table.Tabler
 	table_lock_latency ps_table.Tabler
+	index_usage        ps_table.Tabler
+	blocking           ps_table.Tabler
 	mutex_latency      ps_table.Tabler
+	wait_latency       *wait_latency.Wrapper
+	waitInstruments    []string // setup_instruments names to enable for wait_latency, if any
 	stages_latency     ps_table.Tabler
 	memory             ps_table.Tabler
-	users              ps_table.Tabler
+	digest_latency     ps_table.Tabler
+	replication        ps_table.Tabler
+	global_status      ps_table.Tabler
+	custom             map[view.Code]*custom_wrapper.Wrapper // views defined by the user
+	users              *user_latency.Wrapper
+	threads            *threads.Wrapper
 	currentView        view.View
+	tableDetail        *table_detail.Wrapper // details of the table chosen in a table view
+	showTableDetail    bool                  // are the table details being shown?
+	listCursor         int                   // cursor position in the list to return to
+	allowKill          bool                  // may queries and connections be killed?
+	pendingKill        *kill                 // kill waiting to be confirmed by the user
+	action             string                // result of the last action shown in the status line
 	setupInstruments   setup_instruments.SetupInstruments
+	collector          *collector.Collector // set if the views are collected in the background
 }
 
 // ensure performance_schema is enabled
 // - if not will not return and will exit
-func ensurePerformanceSchemaEnabled(variables *global.Variables) {
+func ensurePerformanceSchemaEnabled(variables *global.Variables, caps capabilities.Capabilities) {
 	if variables == nil {
 		log.Fatal("ensurePerformanceSchemaEnabled() variables is nil")
 	}
 
 	// check that performance_schema = ON
 	if value := variables.Get("performance_schema"); value != "ON" {
-		log.Fatal(fmt.Sprintf("ensurePerformanceSchemaEnabled(): performance_schema = '%s'. Please configure performance_schema = 1 in /etc/my.cnf (or equivalent) and restart mysqld to use %s.",
-			value, lib.MyName()))
+		reason := ""
+		if !caps.Has(capabilities.PerformanceSchemaDefault) {
+			reason = fmt.Sprintf(" %s %s does not enable it by default.", caps.Flavour(), caps.Version())
+		}
+		log.Fatal(fmt.Sprintf("ensurePerformanceSchemaEnabled(): performance_schema = '%s'.%s Please configure performance_schema = 1 in /etc/my.cnf (or equivalent) and restart mysqld to use %s.",
+			value, reason, lib.MyName()))
 	} else {
 		logger.Println("performance_schema = ON check succeeds")
 	}
@@ -93,114 +154,349 @@ func NewApp(settings Settings) *App {
 	logger.Println("app.NewApp()")
 	app := new(App)
 
-	anonymiser.Enable(settings.Anonymise)
-	app.db = connector.NewConnector(settings.ConnFlags).Handle()
+	lib.EnableAnonymise(settings.Anonymise)
+	app.source = app.newSource(settings)
 
-	status := global.NewStatus(app.db)
-	variables := global.NewVariables(app.db)
+	caps, err := capabilities.Collect(app.source, app.db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var status *global.Status
+	if app.db != nil {
+		status = global.NewStatus(app.db, caps)
+	}
+	variables, err := global.NewVariables(app.source, app.db, caps)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !caps.Known() {
+		// recorded before the capabilities were, so use the recorded variables
+		caps = capabilities.New(variables.Get("version"), variables.Get("version_comment"))
+	}
+	logger.Println("app.NewApp() server:", caps)
 	// Prior to setting up screen check that performance_schema is enabled.
 	// On MariaDB this is not the default setting so it will confuse people.
-	ensurePerformanceSchemaEnabled(variables)
+	ensurePerformanceSchemaEnabled(variables, caps)
 
-	app.ctx = context.NewContext(status, variables, settings.Filter)
+	app.ctx = context.NewContext(app.source, status, variables, settings.Filter)
+	app.ctx.SetCapabilities(caps)
 	app.ctx.SetWantRelativeStats(true)
+	app.ctx.SetWantRates(settings.WantRates)
+	app.ctx.SetWindow(settings.Window)
+	app.ctx.SetWantWindowStats(settings.WantWindow)
+	app.listen = settings.Listen
+	if app.listen != "" {
+		// Prometheus expects counters to be the values collected from MySQL
+		app.ctx.SetWantRelativeStats(false)
+		app.ctx.SetWantRates(false)
+		app.ctx.SetWantWindowStats(false)
+	}
 	app.count = settings.Count
+	app.allowKill = settings.AllowKill
 	app.Finished = false
 
+	app.custom = make(map[view.Code]*custom_wrapper.Wrapper)
+	for _, d := range settings.Views {
+		code, err := view.Register(d.Name, d.Check)
+		if err != nil {
+			log.Fatal(err)
+		}
+		app.custom[code] = custom_wrapper.NewCustom(app.ctx, app.db, d)
+	}
+	if err := view.ValidateViews(app.source, app.db, app.ctx.Capabilities()); err != nil {
+		log.Fatal(err)
+	}
+
+	logger.Println("app.Setup() Setting the default view to:", settings.View)
+	app.currentView.SetByName(settings.View) // if empty will use the default
+
+	app.setupModels(settings)
+
+	for name, column := range settings.SortColumns {
+		v, ok := view.Lookup(name)
+		if !ok {
+			log.Fatalf("Unable to sort view %q by %q: no such view", name, column)
+		}
+		if err := app.tabler(v).SetSortColumn(column); err != nil {
+			log.Fatalf("Unable to sort view %q: %v", name, err)
+		}
+	}
+	for name, columns := range settings.HiddenColumns {
+		v, ok := view.Lookup(name)
+		if !ok {
+			log.Fatalf("Unable to hide %v in view %q: no such view", columns, name)
+		}
+		if err := app.tabler(v).HideColumns(columns); err != nil {
+			log.Fatalf("Unable to hide columns in view %q: %v", name, err)
+		}
+	}
+	if settings.Sort != "" {
+		if err := app.tabler(app.currentView.Get()).SetSortColumn(settings.Sort); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// From here on errors must not be fatal as setup_instruments must
+	// be restored by Cleanup() and the screen may be in raw mode.
+	if app.db != nil {
+		app.setupInstruments = setup_instruments.NewSetupInstruments(app.db)
+		if err := app.setupInstruments.EnableMonitoring(app.waitInstruments); err != nil {
+			log.Printf("Unable to configure setup_instruments, mutex and stage data may be incomplete: %v", err)
+		}
+	}
+
 	app.stdout = settings.Stdout
-	app.display = display.NewScreenDisplay(settings.Limit, settings.OnlyTotals)
+	if app.stdout {
+		app.display = display.NewStdoutDisplay(settings.Limit, settings.OnlyTotals, settings.Format)
+	} else {
+		app.display = display.NewScreenDisplay(settings.Limit, settings.OnlyTotals)
+	}
 
 	app.display.SetContext(app.ctx)
 	app.SetHelp(false)
 
-	if err := view.ValidateViews(app.db); err != nil {
-		log.Fatal(err)
-	}
+	app.setWaitInterval(time.Second * time.Duration(settings.Interval))
 
-	logger.Println("app.Setup() Setting the default view to:", settings.View)
-	app.currentView.SetByName(settings.View) // if empty will use the default
+	logger.Println("app.NewApp() resetDBStatistics()")
+	app.resetDBStatistics()
 
-	app.setupInstruments = setup_instruments.NewSetupInstruments(app.db)
-	app.setupInstruments.EnableMonitoring()
+	// ps-top collects the data of all views in the background so
+	// they are up to date when shown
+	if !app.stdout && app.replayer == nil {
+		if err := app.startCollector(settings.ViewIntervals); err != nil {
+			app.Cleanup()
+			log.Fatal(err)
+		}
+	}
 
-	app.wi.SetWaitInterval(time.Second * time.Duration(settings.Interval))
+	logger.Println("app.NewApp() finishes")
+	return app
+}
 
-	// setup to their initial types/values
-	logger.Println("app.NewApp() Setup models")
-	app.file_io_latency = file_io_latency.NewFileSummaryByInstance(app.ctx, app.db)
+// setupModels sets up the built-in views to their initial types/values
+func (app *App) setupModels(settings Settings) {
+	logger.Println("app.setupModels() Setup models")
+	temp_file_io_latency := file_io_latency.NewFileSummaryByInstance(app.ctx, app.db)
+	app.file_io_latency = temp_file_io_latency
 
 	temp_table_io_latency := table_io_latency.NewTableIoLatency(app.ctx, app.db) // shared backend/metrics
 	app.table_io_latency = temp_table_io_latency
 	app.table_io_ops = table_io_ops.NewTableIoOps(temp_table_io_latency)
-	app.table_lock_latency = table_lock_latency.NewTableLockLatency(app.ctx, app.db)
+	temp_table_lock_latency := table_lock_latency.NewTableLockLatency(app.ctx, app.db)
+	app.table_lock_latency = temp_table_lock_latency
+	temp_index_usage := index_usage.NewIndexUsage(app.ctx, app.db)
+	app.index_usage = temp_index_usage
+	app.blocking = blocking.NewBlocking(app.ctx, app.db)
+	app.tableDetail = table_detail.NewTableDetail(temp_index_usage, temp_table_lock_latency, temp_file_io_latency)
 	app.mutex_latency = mutex_latency.NewMutexLatency(app.ctx, app.db)
+	app.wait_latency = wait_latency.NewWaitLatency(app.ctx, app.db, settings.WaitClasses)
+	if len(settings.WaitClasses) > 0 {
+		// only enable the instruments of the classes asked for
+		app.waitInstruments = wait_latency.Instruments(settings.WaitClasses)
+	}
 	app.stages_latency = stages_latency.NewStagesLatency(app.ctx, app.db)
 	app.memory = memory_usage.NewMemoryUsage(app.ctx, app.db)
+	app.digest_latency = digest_latency.NewDigestLatency(app.ctx, app.db)
+	app.replication = replication.NewReplication(app.ctx, app.db)
+	app.global_status = global_status.NewGlobalStatus(app.ctx, app.db, settings.StatusPrefixes)
 	app.users = user_latency.NewUserLatency(app.ctx, app.db)
-	logger.Println("app.NewApp() Finished initialising models")
+	app.threads = threads.NewThreads(app.ctx, app.db)
+	logger.Println("app.setupModels() Finished initialising models")
+}
 
-	logger.Println("app.NewApp() resetDBStatistics()")
-	app.resetDBStatistics()
+// newSource returns the source of the data: either a recording to
+// replay or MySQL, in which case the data may be recorded as it is collected.
+func (app *App) newSource(settings Settings) datasource.Source {
+	if settings.Replay != "" {
+		replayer, err := datasource.NewReplayer(settings.Replay)
+		if err != nil {
+			log.Fatal(err)
+		}
+		app.replayer = replayer
+		return replayer
+	}
 
-	logger.Println("app.NewApp() finishes")
-	return app
+	app.connector = connector.NewConnector(settings.ConnFlags)
+	app.db = app.connector.Handle()
+	if settings.Record != "" {
+		recorder, err := datasource.NewRecorder(settings.Record, datasource.NewMySQL())
+		if err != nil {
+			log.Fatal(err)
+		}
+		return recorder
+	}
+	return datasource.NewMySQL()
 }
 
-// CollectAll collects all the stats together in one go
-func (app *App) collectAll() {
+// CollectAll collects all the stats together in one go.
+// Collection continues if there are errors and the first one is returned.
+func (app *App) collectAll() error {
 	logger.Println("app.collectAll() start")
-	app.file_io_latency.Collect()
-	app.table_lock_latency.Collect()
-	app.table_io_latency.Collect()
-	app.users.Collect()
-	app.stages_latency.Collect()
-	app.mutex_latency.Collect()
-	app.memory.Collect()
+	var firstErr error
+	for _, v := range viewsToCollect() {
+		// the server may not have the tables or we may not be able to read them
+		if !v.Selectable() {
+			continue
+		}
+		if err := app.tabler(v).Collect(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
 	logger.Println("app.collectAll() finished")
+
+	return firstErr
 }
 
 // do a fresh collection of data and then update the initial values based on that.
+// When collecting in the background the data collected last is used rather
+// than wait for the views to be collected again.
 func (app *App) resetDBStatistics() {
 	logger.Println("app.resetDBStatistcs()")
-	app.collectAll()
+	if app.collector != nil {
+		app.setFirstFromLast()
+		app.collector.CollectAllNow()
+		return
+	}
+	app.collected(app.collectAll())
 	app.setFirstFromLast()
 }
 
 func (app *App) setFirstFromLast() {
 	start := time.Now()
+	if app.collector != nil {
+		defer app.collector.LockAll()()
+	}
 	app.file_io_latency.SetFirstFromLast()
 	app.table_lock_latency.SetFirstFromLast()
 	app.table_io_latency.SetFirstFromLast()
+	app.index_usage.SetFirstFromLast()
+	app.blocking.SetFirstFromLast()
 	app.users.SetFirstFromLast()
+	app.threads.SetFirstFromLast()
 	app.stages_latency.SetFirstFromLast()
 	app.mutex_latency.SetFirstFromLast()
+	app.wait_latency.SetFirstFromLast()
 	app.memory.SetFirstFromLast()
+	app.digest_latency.SetFirstFromLast()
+	app.replication.SetFirstFromLast()
+	app.global_status.SetFirstFromLast()
+	for _, c := range app.custom {
+		c.SetFirstFromLast()
+	}
 	logger.Println("app.setFirstFromLast() took", time.Duration(time.Since(start)).String())
 }
 
-// Collect the data we are looking at.
-func (app *App) Collect() {
+// Collect the data we are looking at. If this fails the last data
+// collected is kept and the error is shown to the user.
+func (app *App) Collect() error {
 	logger.Println("app.Collect()")
 	start := time.Now()
 
-	switch app.currentView.Get() {
-	case view.ViewLatency, view.ViewOps:
-		app.table_io_latency.Collect()
-	case view.ViewIO:
-		app.file_io_latency.Collect()
-	case view.ViewLocks:
-		app.table_lock_latency.Collect()
-	case view.ViewUsers:
-		app.users.Collect()
-	case view.ViewMutex:
-		app.mutex_latency.Collect()
-	case view.ViewStages:
-		app.stages_latency.Collect()
-	case view.ViewMemory:
-		app.memory.Collect()
+	if app.collector != nil {
+		// the views are collected, and the connection checked, in the background
+		app.checkServerRestart()
+		app.wi.CollectedNow()
+		return nil
 	}
-	app.wi.CollectedNow()
+
+	if app.ctx.Disconnected() {
+		if err := app.reconnect(); err != nil {
+			app.collected(err)
+			return err
+		}
+		app.currentView.Set(app.currentView.Get()) // move on if the view is no longer selectable
+	}
+
+	app.checkServerRestart()
+
+	var err error
+	if app.showTableDetail {
+		err = app.tableDetail.Collect()
+	} else if t := app.tabler(app.currentView.Get()); t != nil {
+		err = t.Collect()
+	}
+	if err != nil {
+		app.checkConnection()
+	}
+	app.collected(err)
 	logger.Println("app.Collect() took", time.Duration(time.Since(start)).String())
+
+	return err
+}
+
+// checkConnection checks if the connection to MySQL has been lost
+// so we can reconnect.
+func (app *App) checkConnection() {
+	if app.connector == nil {
+		return
+	}
+	if err := app.connector.Ping(); err != nil {
+		logger.Println("app.checkConnection() lost the connection to MySQL:", err)
+		app.ctx.SetDisconnected(true)
+	}
+}
+
+// reconnect connects to MySQL again after the connection was lost.
+// The server may have restarted or failed over so we check again which
+// views can be used and configure setup_instruments again.  The current
+// view should then be set again in case it can no longer be used.
+func (app *App) reconnect() error {
+	if err := app.connector.Reconnect(); err != nil {
+		return err
+	}
+	if err := view.ValidateViews(app.source, app.db, app.ctx.Capabilities()); err != nil {
+		return err
+	}
+	if err := app.setupInstruments.EnableMonitoring(app.waitInstruments); err != nil {
+		logger.Println("app.reconnect() unable to configure setup_instruments:", err)
+	}
+	app.ctx.SetDisconnected(false)
+	app.ctx.AddReconnect()
+	logger.Println("app.reconnect() reconnected to MySQL,", app.ctx.Reconnects(), "reconnect(s) so far")
+
+	return nil
+}
+
+// checkServerRestart resets the statistics if MySQL has restarted, which
+// we see as Uptime going backwards, as the values collected before and
+// after the restart can not be compared.
+func (app *App) checkServerRestart() {
+	if app.collector == nil {
+		app.ctx.Uptime() // otherwise collected in the background
+	}
+	if restarts := app.ctx.ServerRestarts(); restarts != app.restarts {
+		logger.Println("app.checkServerRestart() MySQL has restarted, resetting the statistics")
+		app.restarts = restarts
+		app.resetDBStatistics()
+	}
+}
+
+// collected records the result of a collection, delaying the next
+// one if it failed and updating the status shown to the user.
+func (app *App) collected(err error) {
+	if err != nil {
+		logger.Println("app.collected() collection failed:", err)
+		app.wi.CollectFailed()
+		app.setStatus(fmt.Sprintf("%s (retrying in %v)", collectError(err), app.wi.WaitInterval()+app.wi.Backoff()))
+		return
+	}
+	app.wi.CollectedNow()
+	app.setStatus(app.action)
+}
+
+// collectError returns the message shown when data could not be
+// collected, saying so if the collection timed out.
+func collectError(err error) string {
+	var timeout baseobject.TimeoutError
+	if errors.As(err, &timeout) {
+		return fmt.Sprintf("TIMED OUT: %v, showing the data collected before", timeout)
+	}
+	return fmt.Sprintf("Unable to collect data: %v", err)
+}
+
+// setStatus sets the status message shown to the user
+func (app *App) setStatus(message string) {
+	app.display.SetStatus(message)
 }
 
 // SetHelp determines if we need to display help
@@ -210,34 +506,136 @@ func (app *App) SetHelp(help bool) {
 	app.display.ClearScreen()
 }
 
+// tabler returns the Tabler which provides the data for the given view
+func (app *App) tabler(v view.Code) ps_table.Tabler {
+	switch v {
+	case view.ViewLatency:
+		return app.table_io_latency
+	case view.ViewOps:
+		return app.table_io_ops
+	case view.ViewIndexes:
+		return app.index_usage
+	case view.ViewIO:
+		return app.file_io_latency
+	case view.ViewLocks:
+		return app.table_lock_latency
+	case view.ViewBlocking:
+		return app.blocking
+	case view.ViewUsers:
+		return app.users
+	case view.ViewThreads:
+		return app.threads
+	case view.ViewMutex:
+		return app.mutex_latency
+	case view.ViewWaits:
+		return app.wait_latency
+	case view.ViewStages:
+		return app.stages_latency
+	case view.ViewMemory:
+		return app.memory
+	case view.ViewDigest:
+		return app.digest_latency
+	case view.ViewReplication:
+		return app.replication
+	case view.ViewStatus:
+		return app.global_status
+	}
+	if t, ok := app.custom[v]; ok {
+		return t
+	}
+	return nil
+}
+
 // Display shows the output appropriate to the corresponding view and device
 func (app *App) Display() {
+	defer app.lockViews(app.shownViews()...)()
+
 	if app.Help {
 		app.display.DisplayHelp() // shouldn't get here if in --stdout mode
+	} else if app.showTableDetail {
+		app.display.Display(app.tableDetail)
+	} else if t := app.tabler(app.currentView.Get()); t != nil {
+		app.display.SetView(app.currentView.Name())
+		app.display.Display(t)
+	}
+}
+
+// queryTimeout returns how long a collection may take when collecting
+// every interval: twice the interval but at least minQueryTimeout
+func queryTimeout(interval time.Duration) time.Duration {
+	if timeout := 2 * interval; timeout > minQueryTimeout {
+		return timeout
+	}
+	return minQueryTimeout
+}
+
+// setWaitInterval sets the interval between collections
+func (app *App) setWaitInterval(interval time.Duration) {
+	app.wi.SetWaitInterval(interval)
+	app.display.SetInterval(interval)
+	app.ctx.SetQueryTimeout(queryTimeout(interval))
+	if app.collector != nil {
+		app.collector.SetInterval(interval)
+	}
+}
+
+// hasTableRows returns true if the rows of the view are tables whose
+// details can be shown
+func hasTableRows(v view.Code) bool {
+	return v == view.ViewLatency || v == view.ViewOps || v == view.ViewLocks
+}
+
+// selectRow shows the details of the table under the cursor if
+// the current view shows tables.
+func (app *App) selectRow() {
+	cursor := app.display.Cursor()
+	if !hasTableRows(app.currentView.Get()) || cursor < 0 {
+		return
+	}
+	unlock := app.lockViews(app.currentView.Get())
+	records := app.tabler(app.currentView.Get()).Records()
+	unlock()
+	if cursor >= len(records) {
+		return
+	}
+	name, _ := records[cursor].Value("name")
+
+	app.showTableDetail = true
+	app.listCursor = cursor
+	app.display.SetCursor(-1)
+	app.display.ClearScreen()
+	app.tableDetail.SetTable(fmt.Sprint(name))
+	if app.collector != nil {
+		// show the data collected last until the views are collected again
+		app.collectViewsNow(app.shownViews()...)
 	} else {
-		switch app.currentView.Get() {
-		case view.ViewLatency:
-			app.display.Display(app.table_io_latency)
-		case view.ViewOps:
-			app.display.Display(app.table_io_ops)
-		case view.ViewIO:
-			app.display.Display(app.file_io_latency)
-		case view.ViewLocks:
-			app.display.Display(app.table_lock_latency)
-		case view.ViewUsers:
-			app.display.Display(app.users)
-		case view.ViewMutex:
-			app.display.Display(app.mutex_latency)
-		case view.ViewStages:
-			app.display.Display(app.stages_latency)
-		case view.ViewMemory:
-			app.display.Display(app.memory)
-		}
+		app.collected(app.tableDetail.Collect())
+	}
+	app.Display()
+}
+
+// back returns from the table details to the list
+func (app *App) back() {
+	app.showTableDetail = false
+	app.display.SetCursor(app.listCursor)
+	app.display.ClearScreen()
+	app.Display()
+}
+
+// moveCursor moves the cursor in the list by the given number of rows
+func (app *App) moveCursor(rows int) {
+	if app.showTableDetail {
+		return
 	}
+	app.display.MoveCursor(rows)
+	app.Display()
 }
 
 // change to the previous display mode
 func (app *App) displayPrevious() {
+	app.showTableDetail = false
+	app.action = ""
+	app.display.SetCursor(-1)
 	app.currentView.SetPrev()
 	app.display.ClearScreen()
 	app.Display()
@@ -245,6 +643,9 @@ func (app *App) displayPrevious() {
 
 // change to the next display mode
 func (app *App) displayNext() {
+	app.showTableDetail = false
+	app.action = ""
+	app.display.SetCursor(-1)
 	app.currentView.SetNext()
 	app.display.ClearScreen()
 	app.Display()
@@ -252,14 +653,77 @@ func (app *App) displayNext() {
 
 // Cleanup prepares  the application prior to shutting down
 func (app *App) Cleanup() {
+	if app.collector != nil {
+		app.collector.Stop()
+	}
 	app.display.Close()
 	if app.db != nil {
-		app.setupInstruments.RestoreConfiguration()
+		if err := app.setupInstruments.RestoreConfiguration(); err != nil {
+			log.Printf("Problem restoring setup_instruments: %v", err)
+		}
 		_ = app.db.Close()
 	}
+	if err := app.source.Close(); err != nil {
+		log.Printf("Problem closing the data source: %v", err)
+	}
 	logger.Println("App.Cleanup completed")
 }
 
+// exporterViews returns the views which are published as Prometheus metrics
+func (app *App) exporterViews() []exporter.View {
+	var views []exporter.View
+
+	add := func(v view.Code, ev exporter.View) {
+		if v.Selectable() {
+			views = append(views, ev)
+		} else {
+			logger.Println("app.exporterViews() skipping view", v.String(), "as it is not SELECTable")
+		}
+	}
+	add(view.ViewLatency, exporter.View{Subsystem: "table_io", Label: "table", Tabler: app.table_io_latency})
+	add(view.ViewOps, exporter.View{Subsystem: "table_io", Label: "table", Tabler: app.table_io_ops, Shared: true}) // collected with table_io_latency
+	add(view.ViewIO, exporter.View{Subsystem: "file_io", Label: "file", Tabler: app.file_io_latency})
+	add(view.ViewMutex, exporter.View{Subsystem: "mutex", Label: "mutex", Tabler: app.mutex_latency})
+	add(view.ViewStages, exporter.View{Subsystem: "stage", Label: "stage", Tabler: app.stages_latency})
+	add(view.ViewMemory, exporter.View{
+		Subsystem: "memory",
+		Label:     "event",
+		Tabler:    app.memory,
+		Gauges:    []string{"current_bytes", "high_bytes", "current_count", "high_count"},
+	})
+	for _, v := range view.Custom() {
+		add(v, exporter.View{Subsystem: v.String(), Label: "name", Tabler: app.custom[v], Gauges: app.custom[v].Gauges()})
+	}
+
+	return views
+}
+
+// serve publishes the data on /metrics, collecting it on each scrape,
+// until we are asked to stop.
+func (app *App) serve() {
+	logger.Println("app.serve() listening on", app.listen)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter.NewExporter(app.exporterViews()...))
+	server := &http.Server{Addr: app.listen, Handler: mux}
+
+	errChan := make(chan error, 1)
+	go func() {
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			errChan <- err
+		}
+	}()
+
+	select {
+	case sig := <-app.sigChan:
+		logger.Println("app.serve() caught signal:", sig)
+		_ = server.Close()
+	case err := <-errChan:
+		log.Printf("Unable to serve metrics on %s: %v", app.listen, err)
+	}
+	app.Finished = true
+}
+
 // Run runs the application in a loop until we're ready to finish
 func (app *App) Run() {
 	logger.Println("app.Run()")
@@ -267,7 +731,15 @@ func (app *App) Run() {
 	app.sigChan = make(chan os.Signal, 10) // 10 entries
 	signal.Notify(app.sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	if app.listen != "" {
+		app.serve()
+		return
+	}
+
 	eventChan := app.display.EventChan()
+	if app.collector != nil {
+		app.collector.Start()
+	}
 
 	for !app.Finished {
 		select {
@@ -275,15 +747,31 @@ func (app *App) Run() {
 			fmt.Println("Caught signal: ", sig)
 			app.Finished = true
 		case <-app.wi.WaitNextPeriod():
-			app.Collect()
-			app.Display()
+			if app.replayer != nil {
+				app.replayer.Tick(app.wi.WaitInterval())
+			}
+			// don't repeat the last data when writing to stdout
+			if err := app.Collect(); err == nil || !app.stdout {
+				app.Display()
+			}
 			if app.stdout {
 				app.setFirstFromLast()
 			}
+		case result := <-app.results():
+			app.backgroundCollected(result)
 		case inputEvent := <-eventChan:
+			if app.pendingKill != nil && inputEvent.Type != event.EventResizeScreen {
+				// any key other than y cancels the kill
+				if inputEvent.Type == event.EventConfirm {
+					app.confirmKill()
+				} else {
+					app.cancelKill()
+				}
+				continue
+			}
 			switch inputEvent.Type {
 			case event.EventAnonymise:
-				anonymiser.Enable(!anonymiser.Enabled()) // toggle current behaviour
+				lib.EnableAnonymise(!lib.AnonymiseEnabled()) // toggle current behaviour
 			case event.EventFinished:
 				app.Finished = true
 			case event.EventViewNext:
@@ -292,26 +780,114 @@ func (app *App) Run() {
 				app.displayPrevious()
 			case event.EventDecreasePollTime:
 				if app.wi.WaitInterval() > time.Second {
-					app.wi.SetWaitInterval(app.wi.WaitInterval() - time.Second)
+					app.setWaitInterval(app.wi.WaitInterval() - time.Second)
 				}
 			case event.EventIncreasePollTime:
-				app.wi.SetWaitInterval(app.wi.WaitInterval() + time.Second)
+				app.setWaitInterval(app.wi.WaitInterval() + time.Second)
 			case event.EventHelp:
 				app.SetHelp(!app.Help)
 			case event.EventToggleWantRelative:
 				app.ctx.SetWantRelativeStats(!app.ctx.WantRelativeStats())
 				app.Display()
+			case event.EventToggleWantRates:
+				app.ctx.SetWantRates(!app.ctx.WantRates())
+				app.Display()
+			case event.EventToggleWantWindow:
+				app.ctx.SetWantWindowStats(!app.ctx.WantWindowStats())
+				app.Display()
 			case event.EventResetStatistics:
 				app.resetDBStatistics()
 				app.Display()
+			case event.EventSortNext:
+				app.tabler(app.currentView.Get()).SortNext()
+				app.Display()
+			case event.EventSortPrev:
+				app.tabler(app.currentView.Get()).SortPrev()
+				app.Display()
+			case event.EventSortReverse:
+				app.tabler(app.currentView.Get()).SortReverse()
+				app.Display()
+			case event.EventReplayPause:
+				if app.replayer != nil {
+					app.replayer.TogglePause()
+					app.Display()
+				}
+			case event.EventReplayStep:
+				if app.replayer != nil {
+					app.replayer.Step()
+					app.Collect()
+					app.Display()
+				}
+			case event.EventReplayFaster:
+				if app.replayer != nil {
+					app.replayer.Faster()
+					app.Display()
+				}
+			case event.EventReplaySlower:
+				if app.replayer != nil {
+					app.replayer.Slower()
+					app.Display()
+				}
+			case event.EventCursorUp:
+				app.moveCursor(-1)
+			case event.EventCursorDown:
+				app.moveCursor(1)
+			case event.EventSelect:
+				app.selectRow()
+			case event.EventBack:
+				if app.showTableDetail {
+					app.back()
+				} else {
+					app.Finished = true
+				}
+			case event.EventGroupNext:
+				if app.currentView.Get() == view.ViewUsers {
+					unlock := app.lockViews(view.ViewUsers)
+					app.users.NextGrouping()
+					unlock()
+					app.display.ClearScreen()
+					app.Display()
+				}
+			case event.EventToggleIdle:
+				if app.currentView.Get() == view.ViewThreads {
+					unlock := app.lockViews(view.ViewThreads)
+					app.threads.ToggleIdle()
+					unlock()
+					app.display.ClearScreen()
+					app.Display()
+				}
+			case event.EventKillQuery:
+				app.requestKill(false)
+			case event.EventKillConnection:
+				app.requestKill(true)
+			case event.EventExpand:
+				if app.currentView.Get() == view.ViewWaits {
+					unlock := app.lockViews(view.ViewWaits)
+					app.wait_latency.Expand()
+					unlock()
+					app.Display()
+				}
+			case event.EventCollapse:
+				if app.currentView.Get() == view.ViewWaits {
+					unlock := app.lockViews(view.ViewWaits)
+					app.wait_latency.Collapse()
+					unlock()
+					app.display.ClearScreen()
+					app.Display()
+				}
 			case event.EventResizeScreen:
 				width, height := inputEvent.Width, inputEvent.Height
 				app.display.Resize(width, height)
 				app.Display()
 			case event.EventError:
-				log.Fatalf("Quitting because of EventError error")
+				log.Println("Quitting because of EventError error")
+				app.Finished = true
 			}
 		}
+		// stop sending to stdout once the whole recording has been replayed
+		if app.stdout && app.replayer != nil && app.replayer.Finished() {
+			app.Finished = true
+		}
 		// provide a hook to stop the application if the counter goes down to zero
 		if app.stdout && app.count > 0 {
 			app.count--
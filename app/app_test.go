@@ -0,0 +1,38 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/custom"
+	"github.com/sjmudd/ps-top/model/filter"
+	"github.com/sjmudd/ps-top/view"
+	custom_wrapper "github.com/sjmudd/ps-top/wrapper/custom"
+)
+
+func TestSetFirstFromLastCustom(t *testing.T) {
+	d, err := custom.NewDefinition("reads", "SELECT name, reads FROM t", []string{"name"}, []string{"reads"}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewDefinition(): unexpected error: %v", err)
+	}
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	ctx.SetWantRelativeStats(true)
+	source := &custom.MemorySource{Rows: custom.Rows{{Name: "a", Values: []uint64{10}}}}
+	cw := custom_wrapper.NewCustomWithSource(ctx, source, d)
+	app := &App{ctx: ctx, custom: map[view.Code]*custom_wrapper.Wrapper{view.Code(100): cw}}
+	app.setupModels(Settings{})
+
+	cw.Collect()
+	source.Rows = custom.Rows{{Name: "a", Values: []uint64{15}}}
+	cw.Collect()
+	if cw.Len() != 1 {
+		t.Fatalf("Collect(): expected the changed row to be shown, got %d row(s)", cw.Len())
+	}
+
+	app.setFirstFromLast()
+	if cw.Len() != 0 {
+		t.Errorf("setFirstFromLast(): expected the custom view to be reset, got %d row(s)", cw.Len())
+	}
+}
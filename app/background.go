@@ -0,0 +1,168 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sjmudd/ps-top/collector"
+	"github.com/sjmudd/ps-top/logger"
+	"github.com/sjmudd/ps-top/view"
+)
+
+// collectedViews are the views whose data is collected, in the
+// background if possible. table_io_ops shares the data of table_io_latency.
+var collectedViews = []view.Code{
+	view.ViewLatency,
+	view.ViewIndexes,
+	view.ViewIO,
+	view.ViewLocks,
+	view.ViewBlocking,
+	view.ViewUsers,
+	view.ViewThreads,
+	view.ViewWaits,
+	view.ViewMutex,
+	view.ViewStages,
+	view.ViewMemory,
+	view.ViewDigest,
+	view.ViewReplication,
+	view.ViewStatus,
+}
+
+// viewsToCollect returns the built-in views to collect followed by
+// the views defined by the user
+func viewsToCollect() []view.Code {
+	views := make([]view.Code, 0, len(collectedViews)+len(view.Custom()))
+
+	return append(append(views, collectedViews...), view.Custom()...)
+}
+
+const (
+	uptimeJob     = "uptime"     // name of the job collecting MySQL's uptime
+	connectionJob = "connection" // name of the job checking the connection to MySQL
+)
+
+// jobName returns the name of the job collecting the data of the view
+func jobName(v view.Code) string {
+	if v == view.ViewOps {
+		return view.ViewLatency.String()
+	}
+	return v.String()
+}
+
+// startCollector sets up the collection of the data of each view in
+// the background, every interval or the interval given for the view.
+func (app *App) startCollector(intervals map[string]time.Duration) error {
+	byJob := make(map[string]time.Duration)
+	for name, interval := range intervals {
+		v, ok := view.Lookup(name)
+		if !ok {
+			return fmt.Errorf("unknown view %q given an interval", name)
+		}
+		byJob[jobName(v)] = interval
+	}
+
+	app.collector = collector.NewCollector(app.wi.WaitInterval())
+	for _, v := range viewsToCollect() {
+		// the server may not have the tables or we may not be able to read them
+		if !v.Selectable() {
+			continue
+		}
+		t := app.tabler(v)
+		t.SetLocker(app.collector.Add(jobName(v), byJob[jobName(v)], t.Collect))
+	}
+	// the uptime is shown with every view and shows if MySQL has restarted.
+	// It is collected now as the views have been before starting.
+	app.ctx.Uptime()
+	app.collector.Add(uptimeJob, 0, func() error {
+		app.ctx.Uptime()
+		return nil
+	})
+	// the connection is checked and made again if it is lost in the
+	// background too, as MySQL may take a while to answer, if at all.
+	if app.connector != nil {
+		app.collector.Add(connectionJob, 0, app.connection)
+	}
+	logger.Println("app.startCollector() collecting", len(viewsToCollect()), "views in the background")
+
+	return nil
+}
+
+// results returns the channel the results of the background collections
+// are sent on, which is nil if we do not collect in the background.
+func (app *App) results() <-chan collector.Result {
+	if app.collector == nil {
+		return nil
+	}
+	return app.collector.Results()
+}
+
+// shownViews returns the views whose data is being shown
+func (app *App) shownViews() []view.Code {
+	if app.showTableDetail {
+		return []view.Code{view.ViewIndexes, view.ViewLocks, view.ViewIO}
+	}
+	return []view.Code{app.currentView.Get()}
+}
+
+// lockViews stops the background collection of the data of the given
+// views until the returned function is called so the data can be used.
+func (app *App) lockViews(views ...view.Code) (unlock func()) {
+	if app.collector == nil {
+		return func() {}
+	}
+	names := make([]string, 0, len(views))
+	for _, v := range views {
+		names = append(names, jobName(v))
+	}
+	return app.collector.Lock(names...)
+}
+
+// collectViewsNow asks for the data of the given views to be collected
+// in the background now, without waiting for it to be collected.
+func (app *App) collectViewsNow(views ...view.Code) {
+	names := make([]string, 0, len(views))
+	for _, v := range views {
+		names = append(names, jobName(v))
+	}
+	app.collector.CollectNow(names...)
+}
+
+// backgroundCollected shows the data collected in the background if
+// it is being shown, or the error if it could not be collected.
+func (app *App) backgroundCollected(result collector.Result) {
+	if result.Name == connectionJob {
+		if result.Err == nil {
+			app.currentView.Set(app.currentView.Get()) // move on if the view is no longer selectable
+		}
+		return
+	}
+	if result.Err != nil {
+		logger.Println("app.backgroundCollected()", result.Name, "collection failed:", result.Err)
+		app.collector.CollectNow(connectionJob)
+	}
+	shown := false
+	for _, v := range app.shownViews() {
+		shown = shown || jobName(v) == result.Name
+	}
+	if !shown {
+		return
+	}
+	if result.Err != nil {
+		app.setStatus(collectError(result.Err))
+	} else {
+		app.setStatus(app.action)
+	}
+	app.Display()
+}
+
+// connection checks the connection to MySQL in the background and
+// connects again if it has been lost.
+func (app *App) connection() error {
+	if !app.ctx.Disconnected() {
+		app.checkConnection()
+	}
+	if app.ctx.Disconnected() {
+		return app.reconnect()
+	}
+	return nil
+}
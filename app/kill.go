@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sjmudd/ps-top/logger"
+	"github.com/sjmudd/ps-top/view"
+)
+
+// maxPromptStatement is the length of the statement shown when asking
+// the user to confirm a kill
+const maxPromptStatement = 60
+
+// kill is a KILL statement waiting for the user to confirm it
+type kill struct {
+	connection bool   // KILL CONNECTION rather than KILL QUERY
+	id         uint64 // processlist id of the thread
+	statement  string // statement the thread was running
+}
+
+// sql returns the statement to run to kill the thread
+func (k kill) sql() string {
+	if k.connection {
+		return fmt.Sprintf("KILL CONNECTION %d", k.id)
+	}
+	return fmt.Sprintf("KILL QUERY %d", k.id)
+}
+
+// prompt returns the question asking the user to confirm the kill
+func (k kill) prompt() string {
+	statement := k.statement
+	if len(statement) > maxPromptStatement {
+		statement = statement[:maxPromptStatement] + "..."
+	}
+	return fmt.Sprintf("%s (%s)? Press y to confirm or any other key to cancel", k.sql(), statement)
+}
+
+// setAction shows the result of an action, or a question, in the status line
+func (app *App) setAction(message string) {
+	app.action = message
+	app.setStatus(message)
+	app.Display()
+}
+
+// requestKill asks the user to confirm killing the query or connection
+// of the thread under the cursor in the threads view.
+func (app *App) requestKill(connection bool) {
+	if !app.allowKill {
+		app.setAction("Killing threads is disabled, start " + app.ctx.MyName() + " with --allow-kill to enable it")
+		return
+	}
+	if app.db == nil {
+		app.setAction("Threads can not be killed when replaying a recording")
+		return
+	}
+	cursor := app.display.Cursor()
+	if app.currentView.Get() != view.ViewThreads || cursor < 0 {
+		app.setAction("Select a connection with the cursor in the threads view to kill it")
+		return
+	}
+	unlock := app.lockViews(view.ViewThreads)
+	records := app.threads.Records()
+	unlock()
+	if cursor >= len(records) {
+		return
+	}
+	id, _ := records[cursor].Value("id")
+	statement, _ := records[cursor].Value("statement")
+
+	app.pendingKill = &kill{
+		connection: connection,
+		id:         id.(uint64),
+		statement:  statement.(string),
+	}
+	app.setAction(app.pendingKill.prompt())
+}
+
+// confirmKill runs the KILL statement the user has confirmed
+func (app *App) confirmKill() {
+	k := app.pendingKill
+	app.pendingKill = nil
+
+	logger.Println("app.confirmKill():", k.sql(), "statement:", k.statement)
+	ctx, cancel := context.WithTimeout(context.Background(), app.ctx.QueryTimeout())
+	defer cancel()
+	if _, err := app.db.ExecContext(ctx, k.sql()); err != nil {
+		logger.Println("app.confirmKill():", k.sql(), "failed:", err)
+		app.setAction(fmt.Sprintf("%s failed: %v", k.sql(), err))
+		return
+	}
+	app.setAction(k.sql() + " done")
+}
+
+// cancelKill forgets the KILL statement the user has not confirmed
+func (app *App) cancelKill() {
+	k := app.pendingKill
+	app.pendingKill = nil
+
+	logger.Println("app.cancelKill():", k.sql(), "cancelled")
+	app.setAction(k.sql() + " cancelled")
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKillSQL(t *testing.T) {
+	tests := []struct {
+		kill     kill
+		expected string
+	}{
+		{kill{id: 12}, "KILL QUERY 12"},
+		{kill{connection: true, id: 12}, "KILL CONNECTION 12"},
+	}
+	for _, test := range tests {
+		if got := test.kill.sql(); got != test.expected {
+			t.Errorf("%+v.sql() = %q, expected %q", test.kill, got, test.expected)
+		}
+	}
+}
+
+func TestKillPrompt(t *testing.T) {
+	k := kill{id: 7, statement: strings.Repeat("x", maxPromptStatement+10)}
+	prompt := k.prompt()
+	if !strings.HasPrefix(prompt, "KILL QUERY 7 (") {
+		t.Errorf("prompt() = %q, expected it to start with the KILL statement", prompt)
+	}
+	if !strings.Contains(prompt, strings.Repeat("x", maxPromptStatement)+"...)") {
+		t.Errorf("prompt() = %q, expected the statement to be truncated", prompt)
+	}
+}
@@ -4,10 +4,12 @@ package baseobject
 
 import (
 	"log"
+	"sync"
 	"time"
 
 	"github.com/sjmudd/ps-top/context"
 	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/model/filter"
 )
 
@@ -35,7 +37,99 @@ func (ct *CollectTime) SetLastCollectTime(last time.Time) {
 // Row holds a row of data from table_lock_waits_summary_by_table
 type BaseObject struct {
 	CollectTime
-	ctx *context.Context
+	ctx          *context.Context
+	snapshots    snapshots     // history of collected data for window statistics
+	truncated    time.Time     // when the table was last seen to be truncated
+	restarts     int           // number of MySQL restarts seen
+	query        *queryContext // context of the collection in progress, a pointer so copies of the object do not read it
+	locker       sync.Locker   // held while applying the collected data, if set
+	compensation compensation  // values needed to compensate for counters being reset
+}
+
+// FirstCollectTime returns the time of the data which is used as the
+// baseline for the results.  In window mode this is the oldest data
+// inside the window.
+func (o BaseObject) FirstCollectTime() time.Time {
+	if o.ctx != nil && o.ctx.WantWindowStats() {
+		if s, ok := o.snapshots.oldest(); ok {
+			return s.collected
+		}
+	}
+	return o.CollectTime.FirstCollectTime()
+}
+
+// AddSnapshot records the data collected at the last collection time
+// so it can be used later as a baseline for window statistics.
+// - the data should not be modified after being added.
+func (o *BaseObject) AddSnapshot(data interface{}) {
+	o.snapshots.add(o.LastCollectTime(), data, o.ctx.Window())
+}
+
+// ResetSnapshots removes all the collected snapshots, needed if
+// the collected data is no longer comparable with previous values.
+func (o *BaseObject) ResetSnapshots() {
+	o.snapshots = nil
+}
+
+// WindowBaseline returns the oldest data held in the window if there is any
+func (o BaseObject) WindowBaseline() (interface{}, bool) {
+	s, ok := o.snapshots.oldest()
+	return s.data, ok
+}
+
+// CollectFrom collects the named data from the context's data source,
+// calling query to collect it from MySQL if needed, and then records
+// the time the data was collected and calls apply, if not nil, to use
+// it.  The queries made by query should use QueryContext() so they are
+// cancelled if they take longer than the context's query timeout.  Only
+// apply is called holding the lock set with SetLocker so the data
+// collected before can be used while querying.  If an error is returned
+// the collection time is left unchanged and apply is not called.
+func (o *BaseObject) CollectFrom(name string, data interface{}, query func() error, apply func()) error {
+	o.startQuery()
+	collected, err := o.ctx.Source().Collect(name, data, query)
+	if err = o.endQuery(name, err); err != nil {
+		return err
+	}
+	if o.locker != nil {
+		o.locker.Lock()
+		defer o.locker.Unlock()
+	}
+	o.SetLastCollectTime(collected)
+	if apply != nil {
+		apply()
+	}
+
+	return nil
+}
+
+// SetLocker sets the lock held while the collected data is applied so
+// the data is not changed while it is being used elsewhere.
+func (o *BaseObject) SetLocker(locker sync.Locker) {
+	o.locker = locker
+}
+
+// SetTruncated records when the table was seen to be truncated, that is
+// when the counters of a row have gone down.
+func (o *BaseObject) SetTruncated(truncated time.Time) {
+	o.truncated = truncated
+}
+
+// LastTruncation returns when the table was last seen to be truncated,
+// or the zero time if it has not been.
+func (o BaseObject) LastTruncation() time.Time {
+	return o.truncated
+}
+
+// ServerRestarted returns true the first time it is called after MySQL
+// has been seen to restart.  Counters going down after a restart are
+// not due to truncation.
+func (o *BaseObject) ServerRestarted() bool {
+	if restarts := o.ctx.ServerRestarts(); restarts != o.restarts {
+		o.restarts = restarts
+		return true
+	}
+	return false
 }
 
 // DatabaseFilter returns the context's DatabaseFilter()
@@ -50,6 +144,7 @@ func (o *BaseObject) SetContext(ctx *context.Context) {
 		log.Fatal("BaseObject.SetContext(ctx) ctx should not be nil")
 	}
 	o.ctx = ctx
+	o.query = new(queryContext)
 }
 
 // Variables returns a pointer to the global variables
@@ -60,6 +155,49 @@ func (o BaseObject) Variables() *global.Variables {
 	return o.ctx.Variables()
 }
 
+// WantRates indicates whether we want to see values per second
+func (o BaseObject) WantRates() bool {
+	if o.ctx == nil {
+		log.Fatal("BaseObject.WantRates(): o.ctx should not be nil")
+		return false
+	}
+	return o.ctx.WantRates()
+}
+
+// Elapsed returns the number of seconds the results cover: from the
+// baseline to the last collection for relative or window statistics,
+// otherwise from the time the server started.
+func (o BaseObject) Elapsed() float64 {
+	if o.WantWindowStats() || o.WantRelativeStats() {
+		return o.LastCollectTime().Sub(o.FirstCollectTime()).Seconds()
+	}
+	return o.ServerElapsed()
+}
+
+// ServerElapsed returns the number of seconds from the time the
+// server started until the last collection.
+func (o BaseObject) ServerElapsed() float64 {
+	return o.LastCollectTime().Sub(o.ctx.StartTime()).Seconds()
+}
+
+// Rate returns the value per second over the Elapsed() time if we
+// want to see rates, otherwise the value is returned unchanged.
+func (o BaseObject) Rate(value uint64) uint64 {
+	if !o.WantRates() {
+		return value
+	}
+	return lib.PerSecond(value, o.Elapsed())
+}
+
+// WantWindowStats indicates whether we want statistics over the sliding window
+func (o BaseObject) WantWindowStats() bool {
+	if o.ctx == nil {
+		log.Fatal("BaseObject.WantWindowStats(): o.ctx should not be nil")
+		return false
+	}
+	return o.ctx.WantWindowStats()
+}
+
 // WantRelativeStats indicates whether we want relative stats or not
 // - FIXME and optmise me away
 func (o BaseObject) WantRelativeStats() bool {
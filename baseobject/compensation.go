@@ -0,0 +1,79 @@
+package baseobject
+
+import (
+	"sort"
+)
+
+// Counters gives access to the rows collected by a model so that their
+// counters can be kept increasing when they are reset, e.g. when the
+// table is truncated.  The rows are passed as interface{} values which
+// the model converts back to its own row type.
+type Counters interface {
+	Len() int                                   // Len returns the number of rows collected
+	Key(i int) string                           // Key returns the name identifying row i
+	Row(i int) interface{}                      // Row returns a copy of row i
+	Decreased(i int, previous interface{}) bool // Decreased returns true if the counters of row i went down since previous was collected
+	Add(i int, offset interface{})              // Add adds offset to the counters of row i
+	Append(row interface{})                     // Append adds a row which was not collected
+}
+
+// compensation holds, by row name, what is needed to compensate for resets
+type compensation struct {
+	collected   map[string]interface{} // values collected last time
+	compensated map[string]interface{} // values collected last time with their offset added
+	offsets     map[string]interface{} // values accumulated from resets
+}
+
+// Compensate keeps the counters of the collected rows increasing when
+// they are reset, by adding the values seen before each reset.  A row
+// whose counters went down is treated as a reset and in the collection
+// where this is seen the rows which were not collected are kept too, as
+// a truncated table does not show until it is used again.  Otherwise the
+// rows which were not collected have gone and are forgotten.  After a
+// server restart the counters start again from the collected values.
+// It returns true if a reset was seen.
+func (o *BaseObject) Compensate(rows Counters) bool {
+	c := &o.compensation
+	if o.ServerRestarted() || c.collected == nil {
+		*c = compensation{
+			collected:   make(map[string]interface{}),
+			compensated: make(map[string]interface{}),
+			offsets:     make(map[string]interface{}),
+		}
+	}
+
+	var reset bool
+	seen := make(map[string]bool)
+	for i := 0; i < rows.Len(); i++ {
+		key := rows.Key(i)
+		if previous, ok := c.collected[key]; ok && rows.Decreased(i, previous) {
+			c.offsets[key] = c.compensated[key]
+			reset = true
+		}
+		c.collected[key] = rows.Row(i)
+		if offset, ok := c.offsets[key]; ok {
+			rows.Add(i, offset)
+		}
+		c.compensated[key] = rows.Row(i)
+		seen[key] = true
+	}
+
+	var missing []string
+	for key := range c.collected {
+		if !seen[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	for _, key := range missing {
+		if reset {
+			rows.Append(c.compensated[key])
+			continue
+		}
+		delete(c.collected, key)
+		delete(c.compensated, key)
+		delete(c.offsets, key)
+	}
+
+	return reset
+}
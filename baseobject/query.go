@@ -0,0 +1,60 @@
+package baseobject
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeoutError is returned when a collection takes longer than the
+// query timeout and its queries have been cancelled.
+type TimeoutError struct {
+	Name    string        // name of the data being collected
+	Timeout time.Duration // how long the collection was allowed to take
+}
+
+// Error returns the error as a string
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("collecting %s timed out after %v", e.Name, e.Timeout)
+}
+
+// queryContext holds the context of the collection in progress so its
+// queries can be cancelled if they take too long
+type queryContext struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+}
+
+// startQuery starts a collection whose queries must finish within
+// the query timeout, if one is set
+func (o *BaseObject) startQuery() {
+	o.query.timeout = o.ctx.QueryTimeout()
+	if o.query.timeout > 0 {
+		o.query.ctx, o.query.cancel = context.WithTimeout(context.Background(), o.query.timeout)
+	} else {
+		o.query.ctx, o.query.cancel = context.WithCancel(context.Background())
+	}
+}
+
+// endQuery finishes the collection, returning a TimeoutError if it
+// failed because it took too long
+func (o *BaseObject) endQuery(name string, err error) error {
+	timedOut := o.query.ctx.Err() == context.DeadlineExceeded
+	o.query.cancel()
+	o.query.ctx, o.query.cancel = nil, nil
+
+	if err != nil && timedOut {
+		return TimeoutError{Name: name, Timeout: o.query.timeout}
+	}
+	return err
+}
+
+// QueryContext returns the context the queries of the collection in
+// progress must use so they are cancelled if they take too long.
+func (o *BaseObject) QueryContext() context.Context {
+	if o.query.ctx == nil {
+		return context.Background()
+	}
+	return o.query.ctx
+}
@@ -0,0 +1,41 @@
+// Package baseobject contains the library
+// routines for base stuff of an object
+package baseobject
+
+import (
+	"time"
+
+	"github.com/sjmudd/ps-top/window"
+)
+
+// snapshot holds a copy of the data collected at a given time
+type snapshot struct {
+	collected time.Time
+	data      interface{}
+}
+
+// snapshots holds a history of collected data, oldest first,
+// trimmed so that it only covers the current window.
+type snapshots []snapshot
+
+// add appends the data to the history and removes entries
+// no longer needed to cover the window.
+func (s *snapshots) add(collected time.Time, data interface{}, w window.Window) {
+	*s = append(*s, snapshot{collected: collected, data: data})
+
+	times := make([]time.Time, len(*s))
+	for i := range *s {
+		times[i] = (*s)[i].collected
+	}
+	if expired := w.Expired(times); expired > 0 {
+		*s = append(snapshots{}, (*s)[expired:]...)
+	}
+}
+
+// oldest returns the oldest snapshot held
+func (s snapshots) oldest() (snapshot, bool) {
+	if len(s) == 0 {
+		return snapshot{}, false
+	}
+	return s[0], true
+}
@@ -0,0 +1,252 @@
+// Package capabilities determines what a server can do from its version
+// so that we know in advance which tables and columns may be used rather
+// than finding out from the errors returned by the queries.
+package capabilities
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Flavour is the type of server we are connected to
+type Flavour int
+
+// The server flavours we know about
+const (
+	Unknown Flavour = iota // the version has not been seen
+	MySQL                  // Oracle MySQL
+	MariaDB                // MariaDB
+	Percona                // Percona Server, which behaves as MySQL
+)
+
+func (f Flavour) String() string {
+	switch f {
+	case MySQL:
+		return "MySQL"
+	case MariaDB:
+		return "MariaDB"
+	case Percona:
+		return "Percona Server"
+	}
+	return "unknown"
+}
+
+// Feature is something which is only available on some servers
+type Feature int
+
+// The features which depend on the server flavour and version
+const (
+	PerformanceSchemaDefault   Feature = iota // performance_schema is enabled by default
+	PerformanceSchemaVariables                // global variables and status are in performance_schema
+	MemoryInstrumentation                     // performance_schema.memory_summary_* tables
+	MetadataLocks                             // performance_schema.metadata_locks
+	DataLocks                                 // performance_schema.data_lock_waits replaces the I_S InnoDB lock tables
+	ReplicationTables                         // performance_schema.replication_* tables
+	ReplicationTimestamps                     // the replication tables have the *_TIMESTAMP columns
+	Threads                                   // performance_schema.threads with the PROCESSLIST_* columns
+	SysSchema                                 // the sys schema is installed
+)
+
+var featureNames = map[Feature]string{
+	PerformanceSchemaDefault:   "performance_schema enabled by default",
+	PerformanceSchemaVariables: "performance_schema.global_variables and global_status",
+	MemoryInstrumentation:      "memory instrumentation",
+	MetadataLocks:              "performance_schema.metadata_locks",
+	DataLocks:                  "performance_schema.data_lock_waits",
+	ReplicationTables:          "performance_schema replication tables",
+	ReplicationTimestamps:      "replication timestamp columns",
+	Threads:                    "performance_schema.threads",
+	SysSchema:                  "sys schema",
+}
+
+func (f Feature) String() string {
+	return featureNames[f]
+}
+
+// version holds the numeric part of a server version
+type version struct {
+	major, minor, patch int
+}
+
+func (v version) atLeast(other version) bool {
+	if v.major != other.major {
+		return v.major > other.major
+	}
+	if v.minor != other.minor {
+		return v.minor > other.minor
+	}
+	return v.patch >= other.patch
+}
+
+// minimum versions of MySQL and MariaDB providing each feature.
+// A feature missing for a flavour is not available in any version.
+// Percona Server follows MySQL.
+var minimum = map[Flavour]map[Feature]version{
+	MySQL: {
+		PerformanceSchemaDefault:   {5, 6, 6},
+		PerformanceSchemaVariables: {5, 7, 6},
+		MemoryInstrumentation:      {5, 7, 2},
+		MetadataLocks:              {5, 7, 3},
+		DataLocks:                  {8, 0, 1},
+		ReplicationTables:          {5, 7, 2},
+		ReplicationTimestamps:      {8, 0, 2},
+		Threads:                    {5, 6, 0},
+		SysSchema:                  {5, 7, 7},
+	},
+	MariaDB: {
+		MemoryInstrumentation: {10, 5, 2},
+		MetadataLocks:         {10, 5, 2},
+		ReplicationTables:     {10, 5, 2},
+		Threads:               {10, 0, 0},
+		SysSchema:             {10, 6, 0},
+	},
+}
+
+// Capabilities describes the server we are connected to
+type Capabilities struct {
+	flavour Flavour
+	version version
+	raw     string
+}
+
+// New returns the capabilities of a server given its version and
+// version_comment.
+func New(serverVersion, versionComment string) Capabilities {
+	c := Capabilities{raw: serverVersion}
+	if serverVersion == "" {
+		return c
+	}
+
+	switch {
+	case strings.Contains(serverVersion, "MariaDB"):
+		c.flavour = MariaDB
+	case strings.Contains(versionComment, "Percona"):
+		c.flavour = Percona
+	default:
+		c.flavour = MySQL
+	}
+	c.version = parseVersion(serverVersion)
+
+	return c
+}
+
+// parseVersion returns the leading numeric part of a version such as
+// 8.0.22-13 or 10.5.8-MariaDB-log.  Missing parts are left as 0.
+func parseVersion(serverVersion string) version {
+	if i := strings.IndexAny(serverVersion, "-+ "); i >= 0 {
+		serverVersion = serverVersion[:i]
+	}
+
+	var parts [3]int
+	for i, part := range strings.SplitN(serverVersion, ".", 3) {
+		if n, err := strconv.Atoi(part); err == nil {
+			parts[i] = n
+		}
+	}
+
+	return version{major: parts[0], minor: parts[1], patch: parts[2]}
+}
+
+// Flavour returns the type of server
+func (c Capabilities) Flavour() Flavour {
+	return c.flavour
+}
+
+// Version returns the server version as reported by the server
+func (c Capabilities) Version() string {
+	return c.raw
+}
+
+// Known returns true if we have seen the server's version
+func (c Capabilities) Known() bool {
+	return c.flavour != Unknown
+}
+
+// Has returns true if the server provides the given feature.  If the
+// version is not known we assume the server is a current MySQL version.
+func (c Capabilities) Has(f Feature) bool {
+	flavour := c.flavour
+	switch flavour {
+	case Unknown:
+		return true
+	case Percona:
+		flavour = MySQL
+	}
+
+	min, ok := minimum[flavour][f]
+	return ok && c.version.atLeast(min)
+}
+
+// Require returns an error if the server does not provide the given feature
+func (c Capabilities) Require(f Feature) error {
+	if c.Has(f) {
+		return nil
+	}
+	return fmt.Errorf("%s %s does not provide %s", c.flavour, c.raw, f)
+}
+
+// VariablesTable returns the table holding the global variables
+func (c Capabilities) VariablesTable() string {
+	if c.Has(PerformanceSchemaVariables) {
+		return "performance_schema.global_variables"
+	}
+	return "INFORMATION_SCHEMA.GLOBAL_VARIABLES"
+}
+
+// StatusTable returns the table holding the global status
+func (c Capabilities) StatusTable() string {
+	if c.Has(PerformanceSchemaVariables) {
+		return "performance_schema.global_status"
+	}
+	return "INFORMATION_SCHEMA.GLOBAL_STATUS"
+}
+
+func (c Capabilities) String() string {
+	if !c.Known() {
+		return "unknown server version"
+	}
+
+	var features []string
+	for f := PerformanceSchemaDefault; f <= SysSchema; f++ {
+		if c.Has(f) {
+			features = append(features, f.String())
+		}
+	}
+	return fmt.Sprintf("%s %s with %s", c.flavour, c.raw, strings.Join(features, ", "))
+}
+
+// server holds the version information as collected, so it can be recorded
+type server struct {
+	Version        string
+	VersionComment string
+}
+
+// Collect returns the capabilities of the server, collecting its version
+// via the given source so it may be recorded or replayed.  dbh is only
+// used (and must be set) if the source collects from MySQL.  If nothing
+// was recorded the capabilities are not Known.
+func Collect(source datasource.Source, dbh *sql.DB) (Capabilities, error) {
+	var s server
+	if _, err := source.Collect("capabilities", &s, func() error {
+		if dbh == nil {
+			return errors.New("capabilities.Collect(): dbh == nil")
+		}
+		if err := dbh.QueryRow("SELECT @@global.version, @@global.version_comment").Scan(&s.Version, &s.VersionComment); err != nil {
+			return fmt.Errorf("unable to collect the server version: %v", err)
+		}
+		return nil
+	}); err != nil {
+		return Capabilities{}, err
+	}
+
+	c := New(s.Version, s.VersionComment)
+	logger.Println("capabilities.Collect():", c)
+
+	return c, nil
+}
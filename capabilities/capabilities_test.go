@@ -0,0 +1,93 @@
+package capabilities
+
+import (
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	var tests = []struct {
+		version string
+		comment string
+		flavour Flavour
+		parsed  version
+	}{
+		{"", "", Unknown, version{}},
+		{"5.6.51-log", "MySQL Community Server (GPL)", MySQL, version{5, 6, 51}},
+		{"8.0.22", "MySQL Community Server - GPL", MySQL, version{8, 0, 22}},
+		{"8.0.22-13", "Percona Server (GPL), Release 13, Revision 6f7822f", Percona, version{8, 0, 22}},
+		{"10.5.8-MariaDB-1:10.5.8+maria~focal-log", "mariadb.org binary distribution", MariaDB, version{10, 5, 8}},
+		{"8.0", "", MySQL, version{8, 0, 0}},
+	}
+
+	for _, test := range tests {
+		c := New(test.version, test.comment)
+		if c.Flavour() != test.flavour || c.version != test.parsed {
+			t.Errorf("New(%q, %q): expected %v %v, got %v %v", test.version, test.comment, test.flavour, test.parsed, c.Flavour(), c.version)
+		}
+	}
+}
+
+func TestHas(t *testing.T) {
+	var tests = []struct {
+		version  string
+		comment  string
+		feature  Feature
+		expected bool
+	}{
+		{"5.6.51", "", PerformanceSchemaDefault, true},
+		{"5.6.51", "", MemoryInstrumentation, false},
+		{"5.6.51", "", PerformanceSchemaVariables, false},
+		{"5.7.5", "", PerformanceSchemaVariables, false},
+		{"5.7.6", "", PerformanceSchemaVariables, true},
+		{"5.7.32", "", MetadataLocks, true},
+		{"5.7.32", "", DataLocks, false},
+		{"5.7.32", "", ReplicationTimestamps, false},
+		{"8.0.22", "", DataLocks, true},
+		{"8.0.22", "", ReplicationTimestamps, true},
+		{"8.0.22", "", SysSchema, true},
+		{"5.7.32-35", "Percona Server (GPL), Release 35", MemoryInstrumentation, true},
+		{"5.7.32-35", "Percona Server (GPL), Release 35", DataLocks, false},
+		{"10.3.27-MariaDB", "", PerformanceSchemaDefault, false},
+		{"10.3.27-MariaDB", "", MetadataLocks, false},
+		{"10.3.27-MariaDB", "", Threads, true},
+		{"5.5.62", "", Threads, false},
+		{"10.5.8-MariaDB", "", MetadataLocks, true},
+		{"10.5.8-MariaDB", "", SysSchema, false},
+		{"10.5.8-MariaDB", "", DataLocks, false},
+		{"10.5.8-MariaDB", "", PerformanceSchemaVariables, false},
+		{"10.6.1-MariaDB", "", SysSchema, true},
+		{"", "", DataLocks, true}, // unknown servers are assumed to be current
+	}
+
+	for _, test := range tests {
+		c := New(test.version, test.comment)
+		if got := c.Has(test.feature); got != test.expected {
+			t.Errorf("%q.Has(%v): expected %v, got %v", test.version, test.feature, test.expected, got)
+		}
+		if err := c.Require(test.feature); (err == nil) != test.expected {
+			t.Errorf("%q.Require(%v): unexpected error %v", test.version, test.feature, err)
+		}
+	}
+}
+
+func TestTables(t *testing.T) {
+	var tests = []struct {
+		version   string
+		variables string
+		status    string
+	}{
+		{"5.6.51", "INFORMATION_SCHEMA.GLOBAL_VARIABLES", "INFORMATION_SCHEMA.GLOBAL_STATUS"},
+		{"8.0.22", "performance_schema.global_variables", "performance_schema.global_status"},
+		{"10.5.8-MariaDB", "INFORMATION_SCHEMA.GLOBAL_VARIABLES", "INFORMATION_SCHEMA.GLOBAL_STATUS"},
+	}
+
+	for _, test := range tests {
+		c := New(test.version, "")
+		if got := c.VariablesTable(); got != test.variables {
+			t.Errorf("%q.VariablesTable(): expected %q, got %q", test.version, test.variables, got)
+		}
+		if got := c.StatusTable(); got != test.status {
+			t.Errorf("%q.StatusTable(): expected %q, got %q", test.version, test.status, got)
+		}
+	}
+}
@@ -10,13 +10,19 @@ import (
 	"os"
 	"runtime/pprof"
 	"strconv"
+	"strings"
 
 	"github.com/sjmudd/ps-top/app"
 	"github.com/sjmudd/ps-top/connector"
+	"github.com/sjmudd/ps-top/display"
 	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/logger"
 	"github.com/sjmudd/ps-top/model/filter"
+	"github.com/sjmudd/ps-top/model/global_status"
+	"github.com/sjmudd/ps-top/model/wait_latency"
+	"github.com/sjmudd/ps-top/rc"
 	"github.com/sjmudd/ps-top/version"
+	"github.com/sjmudd/ps-top/window"
 )
 
 var (
@@ -26,13 +32,25 @@ var (
 
 	cpuprofile         = flag.String("cpuprofile", "", "write cpu profile to file")
 	flagAnonymise      = flag.Bool("anonymise", false, "Anonymise hostname, user, db and table names (default: false)")
+	flagCount          = flag.Int("count", 0, "Provide the number of iterations to make if not given as an argument (default: 0 is forever)")
 	flagDebug          = flag.Bool("debug", false, "Enabling debug logging")
 	flagDatabaseFilter = flag.String("database-filter", "", "Optional comma-separated filter of database names")
+	flagFormat         = flag.String("format", "", "Output format: text, json, csv or tsv (default: text)")
 	flagHelp           = flag.Bool("help", false, "Provide some help for "+lib.MyName())
+	flagInterval       = flag.Int("interval", 1, "Set the poll interval if not given as an argument (default 1 second)")
+	flagListen         = flag.String("listen", "", "Serve Prometheus metrics on /metrics at this address (e.g. :9474) rather than writing to stdout")
 	flagLimit          = flag.Int("limit", 0, "Show a maximum of limit entries (defaults to screen size if output to screen)")
 	flagTotals         = flag.Bool("totals", false, "Only show the totals when in stdout mode and no detail (default: false)")
+	flagProfile        = flag.String("profile", "", "Use the settings of the [server:<profile>] section of ~/.pstoprc")
+	flagRates          = flag.Bool("rates", false, "Show values per second rather than the collected values (default: false)")
+	flagRecord         = flag.String("record", "", "Record the data collected from MySQL to this file")
+	flagReplay         = flag.String("replay", "", "Replay the data from this file rather than collecting it from MySQL")
+	flagSort           = flag.String("sort", "", "Provide the column to sort the initial view by (default: the first column)")
+	flagStatusPrefix   = flag.String("status-prefix", "", "Optional comma-separated prefixes of the variables to show in the global_status view (e.g. Com_,Innodb_)")
 	flagVersion        = flag.Bool("version", false, "Show the version of "+lib.MyName())
 	flagView           = flag.String("view", "", "Provide view to show when starting "+lib.MyName()+" (default: table_io_latency)")
+	flagWaitClasses    = flag.String("wait-classes", "", "Optional comma-separated wait classes to collect in the wait_latency view (default: all)")
+	flagWindow         = flag.String("window", "", "Show statistics over a sliding window of this duration or number of samples (e.g. 60s or 10)")
 )
 
 func usage() {
@@ -44,20 +62,34 @@ func usage() {
 	fmt.Println("Usage: " + lib.MyName() + " <options> [delay [count]]")
 	fmt.Println("")
 	fmt.Println("Options:")
+	fmt.Println("--count=<count>                          Set the number of times to collect if not given as an argument")
 	fmt.Println("--database-filter=db1[,db2,db3,...]      Optional database names to filter on")
 	fmt.Println("--defaults-file=/path/to/defaults.file   Connect to MySQL using given defaults-file")
+	fmt.Println("--format=<text|json|csv|tsv>             Output format (default: text). json, csv and tsv give raw values")
 	fmt.Println("--help                                   Show this help message")
 	fmt.Println("--host=<hostname>                        MySQL host to connect to")
+	fmt.Println("--interval=<seconds>                     Set the poll interval (in seconds) if not given as an argument")
 	fmt.Println("--limit=<rows>                           Limit the number of lines of output (excluding headers)")
+	fmt.Println("--listen=<address>                       Serve Prometheus metrics on http://<address>/metrics, e.g. :9474")
 	fmt.Println("--password=<password>                    Password to use when connecting")
 	fmt.Println("--port=<port>                            MySQL port to connect to")
+	fmt.Println("--profile=<name>                         Use the settings in the [server:<name>] section of ~/.pstoprc")
+	fmt.Println("--rates                                  Show values per second rather than the collected values")
+	fmt.Println("--record=<file>                          Record the data collected from MySQL to the given file")
+	fmt.Println("--replay=<file>                          Replay the data recorded in the given file rather than connecting to MySQL")
 	fmt.Println("--socket=<path>                          MySQL path of the socket to connect to")
+	fmt.Println("--sort=<column>                          Sort the initial view by the given column, e.g. latency, ops or name")
+	fmt.Println("--status-prefix=<prefix>[,<prefix>...]   Only show the global_status variables starting with these prefixes, e.g. Com_,Innodb_")
 	fmt.Println("--totals                                 Only send the totals to stdout (in stdout mode)")
 	fmt.Println("--user=<user>                            User to connect with")
 	fmt.Println("--use-environment                        Connect to MySQL using a go dsn collected from MYSQL_DSN e.g. MYSQL_DSN='test_user:test_pass@tcp(127.0.0.1:3306)/performance_schema'")
 	fmt.Println("--version                                Show the version")
 	fmt.Println("--view=<view>                            Determine the view you want to see when " + lib.MyName() + " starts (default: table_io_latency")
-	fmt.Println("                                         Possible values: table_io_latency table_io_ops file_io_latency table_lock_latency user_latency mutex_latency stages_latency")
+	fmt.Println("                                         Possible values: table_io_latency table_io_ops index_usage file_io_latency table_lock_latency blocking user_latency threads mutex_latency wait_latency stages_latency digest_latency replication global_status")
+	fmt.Println("                                         or the name of a view defined in ~/.pstoprc")
+	fmt.Println("--wait-classes=<classes>                 Comma-separated wait classes to collect in the wait_latency view (default: all)")
+	fmt.Println("                                         Possible values: " + strings.Join(wait_latency.Classes, " "))
+	fmt.Println("--window=<duration|samples>              Show statistics over a sliding window, e.g. 60s or 10 (samples)")
 }
 
 func main() {
@@ -73,6 +105,10 @@ func main() {
 	var err = errors.New("unknown")
 
 	flag.Parse()
+	// settings not given as flags are taken from ~/.pstoprc
+	if err := rc.Apply(flag.CommandLine, *flagProfile); err != nil {
+		log.Fatal(err)
+	}
 
 	// Too many arguments
 	if len(flag.Args()) > 2 {
@@ -86,7 +122,7 @@ func main() {
 			log.Fatal("Unable to parse delay: ", err)
 		}
 	} else {
-		delay = 1
+		delay = *flagInterval
 	}
 	// count
 	if len(flag.Args()) >= 2 {
@@ -95,7 +131,7 @@ func main() {
 			log.Fatal("Unable to parse count: ", err)
 		}
 	} else {
-		count = 0
+		count = *flagCount
 	}
 
 	if *cpuprofile != "" {
@@ -119,19 +155,54 @@ func main() {
 		return
 	}
 
+	if *flagRecord != "" && *flagReplay != "" {
+		log.Fatal("--record and --replay can not be used together")
+	}
+
+	w, err := window.Parse(*flagWindow)
+	if err != nil {
+		log.Fatal(err)
+	}
+	waitClasses, err := wait_latency.ParseClasses(*flagWaitClasses)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	format, err := display.ParseFormat(*flagFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	views, err := rc.Views()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	settings := app.Settings{
-		Anonymise:  *flagAnonymise,
-		ConnFlags:  connectorFlags,
-		Count:      count,
-		Filter:     filter.NewDatabaseFilter(*flagDatabaseFilter),
-		Interval:   delay,
-		Limit:      *flagLimit,
-		OnlyTotals: true,
-		Stdout:     true,
-		View:       *flagView,
+		Anonymise:      *flagAnonymise,
+		ConnFlags:      connectorFlags,
+		Count:          count,
+		Filter:         filter.NewDatabaseFilter(*flagDatabaseFilter),
+		Format:         format,
+		HiddenColumns:  rc.HiddenColumns(),
+		Interval:       delay,
+		Limit:          *flagLimit,
+		Listen:         *flagListen,
+		OnlyTotals:     *flagTotals,
+		Stdout:         true,
+		Record:         *flagRecord,
+		Replay:         *flagReplay,
+		Sort:           *flagSort,
+		SortColumns:    rc.SortColumns(),
+		StatusPrefixes: global_status.ParsePrefixes(*flagStatusPrefix),
+		View:           *flagView,
+		Views:          views,
+		WaitClasses:    waitClasses,
+		WantRates:      *flagRates,
+		WantWindow:     *flagWindow != "",
+		Window:         w,
 	}
 
 	app := app.NewApp(settings)
+	defer app.Cleanup()
 	app.Run()
-	app.Cleanup()
 }
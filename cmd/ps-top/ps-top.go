@@ -8,18 +8,25 @@ import (
 	"log"
 	"os"
 	"runtime/pprof"
+	"strings"
 
 	"github.com/sjmudd/ps-top/app"
+	"github.com/sjmudd/ps-top/collector"
 	"github.com/sjmudd/ps-top/connector"
 	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/logger"
 	"github.com/sjmudd/ps-top/model/filter"
+	"github.com/sjmudd/ps-top/model/global_status"
+	"github.com/sjmudd/ps-top/model/wait_latency"
+	"github.com/sjmudd/ps-top/rc"
 	"github.com/sjmudd/ps-top/version"
+	"github.com/sjmudd/ps-top/window"
 )
 
 var (
 	connectorFlags     connector.Flags
 	cpuprofile         = flag.String("cpuprofile", "", "write cpu profile to file")
+	flagAllowKill      = flag.Bool("allow-kill", false, "Allow killing queries and connections from the threads view (default: false)")
 	flagAnonymise      = flag.Bool("anonymise", false, "Anonymise hostname, user, db and table names (default: false)")
 	flagCount          = flag.Int("count", 0, "Provide the number of iterations to make (default: 0 is forever)")
 	flagDatabaseFilter = flag.String("database-filter", "", "Optional comma-separated filter of database names")
@@ -27,8 +34,17 @@ var (
 	flagHelp           = flag.Bool("help", false, "Provide some help for "+lib.MyName())
 	flagInterval       = flag.Int("interval", 1, "Set the initial poll interval (default 1 second)")
 	flagLimit          = flag.Int("limit", 0, "Show a maximum of limit entries (defaults to screen size if output to screen)")
+	flagProfile        = flag.String("profile", "", "Use the settings of the [server:<profile>] section of ~/.pstoprc")
+	flagRates          = flag.Bool("rates", false, "Show values per second rather than the collected values (default: false)")
+	flagRecord         = flag.String("record", "", "Record the data collected from MySQL to this file")
+	flagReplay         = flag.String("replay", "", "Replay the data from this file rather than collecting it from MySQL")
+	flagSort           = flag.String("sort", "", "Provide the column to sort the initial view by (default: the first column)")
+	flagStatusPrefix   = flag.String("status-prefix", "", "Optional comma-separated prefixes of the variables to show in the global_status view (e.g. Com_,Innodb_)")
 	flagVersion        = flag.Bool("version", false, "Show the version of "+lib.MyName())
+	flagViewInterval   = flag.String("view-interval", "", "Optional comma-separated view=seconds intervals to collect views at rather than --interval (e.g. digest_latency=10)")
 	flagView           = flag.String("view", "", "Provide view to show when starting "+lib.MyName()+" (default: table_io_latency)")
+	flagWaitClasses    = flag.String("wait-classes", "", "Optional comma-separated wait classes to collect in the wait_latency view (default: all)")
+	flagWindow         = flag.String("window", "", "Show statistics over a sliding window of this duration or number of samples (e.g. 60s or 10)")
 )
 
 func usage() {
@@ -40,6 +56,7 @@ func usage() {
 	fmt.Println("Usage: " + lib.MyName() + " <options>")
 	fmt.Println("")
 	fmt.Println("Options:")
+	fmt.Println("--allow-kill                             Allow killing queries and connections from the threads view")
 	fmt.Println("--anonymise=<true|false>                 Anonymise hostname, user, db and table names")
 	fmt.Println("--count=<count>                          Set the number of times to watch")
 	fmt.Println("--database-filter=db1[,db2,db3,...]      Optional database names to filter on")
@@ -50,12 +67,23 @@ func usage() {
 	fmt.Println("--limit=<rows>                           Limit the number of lines of output (excluding headers)")
 	fmt.Println("--password=<password>                    Password to use when connecting")
 	fmt.Println("--port=<port>                            MySQL port to connect to")
+	fmt.Println("--profile=<name>                         Use the settings in the [server:<name>] section of ~/.pstoprc")
+	fmt.Println("--rates                                  Show values per second rather than the collected values")
+	fmt.Println("--record=<file>                          Record the data collected from MySQL to the given file")
+	fmt.Println("--replay=<file>                          Replay the data recorded in the given file rather than connecting to MySQL")
 	fmt.Println("--socket=<path>                          MySQL path of the socket to connect to")
+	fmt.Println("--sort=<column>                          Sort the initial view by the given column, e.g. latency, ops or name")
+	fmt.Println("--status-prefix=<prefix>[,<prefix>...]   Only show the global_status variables starting with these prefixes, e.g. Com_,Innodb_")
 	fmt.Println("--user=<user>                            User to connect with")
 	fmt.Println("--use-environment                        Connect to MySQL using a go dsn collected from MYSQL_DSN e.g. MYSQL_DSN='test_user:test_pass@tcp(127.0.0.1:3306)/performance_schema'")
 	fmt.Println("--version                                Show the version")
 	fmt.Println("--view=<view>                            Determine the view you want to see when " + lib.MyName() + " starts (default: table_io_latency")
-	fmt.Println("                                         Possible values: table_io_latency table_io_ops file_io_latency table_lock_latency user_latency mutex_latency stages_latency")
+	fmt.Println("                                         Possible values: table_io_latency table_io_ops index_usage file_io_latency table_lock_latency blocking user_latency threads mutex_latency wait_latency stages_latency digest_latency replication global_status")
+	fmt.Println("                                         or the name of a view defined in ~/.pstoprc")
+	fmt.Println("--view-interval=<view>=<seconds>[,...]   Collect the given views in the background at their own interval, e.g. digest_latency=10")
+	fmt.Println("--wait-classes=<classes>                 Comma-separated wait classes to collect in the wait_latency view (default: all)")
+	fmt.Println("                                         Possible values: " + strings.Join(wait_latency.Classes, " "))
+	fmt.Println("--window=<duration|samples>              Show statistics over a sliding window, e.g. 60s or 10 (samples)")
 }
 
 func main() {
@@ -70,6 +98,10 @@ func main() {
 	}
 
 	flag.Parse()
+	// settings not given as flags are taken from ~/.pstoprc
+	if err := rc.Apply(flag.CommandLine, *flagProfile); err != nil {
+		log.Fatal(err)
+	}
 
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
@@ -92,16 +124,50 @@ func main() {
 		return
 	}
 
+	if *flagRecord != "" && *flagReplay != "" {
+		log.Fatal("--record and --replay can not be used together")
+	}
+
+	w, err := window.Parse(*flagWindow)
+	if err != nil {
+		log.Fatal(err)
+	}
+	waitClasses, err := wait_latency.ParseClasses(*flagWaitClasses)
+	if err != nil {
+		log.Fatal(err)
+	}
+	viewIntervals, err := collector.ParseIntervals(*flagViewInterval)
+	if err != nil {
+		log.Fatal(err)
+	}
+	views, err := rc.Views()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	app := app.NewApp(app.Settings{
-		Anonymise:  *flagAnonymise,
-		ConnFlags:  connectorFlags,
-		Count:      *flagCount,
-		Filter:     filter.NewDatabaseFilter(*flagDatabaseFilter),
-		Interval:   *flagInterval,
-		Limit:      *flagLimit,
-		OnlyTotals: false,
-		Stdout:     false,
-		View:       *flagView,
+		AllowKill:      *flagAllowKill,
+		Anonymise:      *flagAnonymise,
+		ConnFlags:      connectorFlags,
+		Count:          *flagCount,
+		Filter:         filter.NewDatabaseFilter(*flagDatabaseFilter),
+		HiddenColumns:  rc.HiddenColumns(),
+		Interval:       *flagInterval,
+		Limit:          *flagLimit,
+		OnlyTotals:     false,
+		Stdout:         false,
+		Record:         *flagRecord,
+		Replay:         *flagReplay,
+		Sort:           *flagSort,
+		SortColumns:    rc.SortColumns(),
+		StatusPrefixes: global_status.ParsePrefixes(*flagStatusPrefix),
+		View:           *flagView,
+		ViewIntervals:  viewIntervals,
+		Views:          views,
+		WaitClasses:    waitClasses,
+		WantRates:      *flagRates,
+		WantWindow:     *flagWindow != "",
+		Window:         w,
 	})
 	defer app.Cleanup()
 	app.Run()
@@ -0,0 +1,230 @@
+// Package collector collects the data of the views in the background,
+// each view in its own goroutine and on its own schedule, so the data
+// of every view is fresh when it is shown and a slow view does not
+// delay the others.
+package collector
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sjmudd/ps-top/logger"
+	"github.com/sjmudd/ps-top/wait_info"
+)
+
+// Result is sent each time a job has collected its data
+type Result struct {
+	Name string // name of the job
+	Err  error  // error collecting the data if any
+}
+
+// job collects the data of one or more views which share their data
+type job struct {
+	sync.Mutex                    // held while applying or using the collected data
+	name       string             // name of the job, usually the view's name
+	collect    func() error       // collects the data
+	interval   time.Duration      // interval between collections, 0 uses the default
+	wi         wait_info.WaitInfo // when to collect next (guarded by Collector.mu)
+	now        chan struct{}      // asks for the data to be collected now
+}
+
+// Collector runs the jobs which collect the data in the background
+type Collector struct {
+	mu       sync.Mutex // protects the scheduling of the jobs
+	jobs     map[string]*job
+	interval time.Duration // default interval between collections
+	results  chan Result
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewCollector returns a Collector collecting the data of the jobs
+// added to it every interval unless they have their own interval.
+func NewCollector(interval time.Duration) *Collector {
+	return &Collector{
+		jobs:     make(map[string]*job),
+		interval: interval,
+		results:  make(chan Result, 100),
+		done:     make(chan struct{}),
+	}
+}
+
+// Add adds a job to collect data using the given function every
+// interval, or every default interval if interval is 0. Jobs must be
+// added before Start is called.  The lock of the job is returned which
+// collect must hold while it changes the collected data, but not while
+// querying MySQL, so the data collected before can be used meanwhile.
+func (c *Collector) Add(name string, interval time.Duration, collect func() error) sync.Locker {
+	j := &job{name: name, collect: collect, interval: interval, now: make(chan struct{}, 1)}
+	j.wi.SetWaitInterval(c.jobInterval(j))
+	j.wi.CollectedNow() // the data has been collected before starting
+	c.jobs[name] = j
+
+	return j
+}
+
+// jobInterval returns the interval between the collections of the job
+func (c *Collector) jobInterval(j *job) time.Duration {
+	if j.interval > 0 {
+		return j.interval
+	}
+	return c.interval
+}
+
+// SetInterval changes the default interval between collections
+// which is used by jobs without their own interval.
+func (c *Collector) SetInterval(interval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interval = interval
+	for _, j := range c.jobs {
+		j.wi.SetWaitInterval(c.jobInterval(j))
+	}
+}
+
+// Start starts collecting the data of each job in its own goroutine
+func (c *Collector) Start() {
+	for _, j := range c.jobs {
+		c.wg.Add(1)
+		go c.run(j)
+	}
+}
+
+// Stop stops collecting data, waiting for any collections in
+// progress to finish.
+func (c *Collector) Stop() {
+	close(c.done)
+	c.wg.Wait()
+}
+
+// Results returns the channel on which the result of each
+// collection is sent
+func (c *Collector) Results() <-chan Result {
+	return c.results
+}
+
+// run collects the data of the job on its schedule until stopped
+func (c *Collector) run(j *job) {
+	defer c.wg.Done()
+
+	for {
+		c.mu.Lock()
+		next := j.wi.WaitNextPeriod()
+		c.mu.Unlock()
+
+		select {
+		case <-c.done:
+			return
+		case <-next:
+		case <-j.now:
+		}
+
+		start := time.Now()
+		err := j.collect()
+		logger.Println("Collector.run()", j.name, "took", time.Since(start), "error:", err)
+
+		c.mu.Lock()
+		if err != nil {
+			j.wi.CollectFailed()
+		} else {
+			j.wi.CollectedNow()
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-c.done:
+			return
+		case c.results <- Result{Name: j.name, Err: err}:
+		}
+	}
+}
+
+// CollectNow asks the named jobs to collect their data now rather than
+// at their next collection, without waiting for them to do so. The
+// result of each collection is sent on the Results channel as usual.
+// Unknown names are ignored.
+func (c *Collector) CollectNow(names ...string) {
+	for _, name := range names {
+		if j, ok := c.jobs[name]; ok {
+			select {
+			case j.now <- struct{}{}:
+			default: // already asked
+			}
+		}
+	}
+}
+
+// CollectAllNow asks all the jobs to collect their data now
+func (c *Collector) CollectAllNow() {
+	c.CollectNow(c.names()...)
+}
+
+// Lock stops the named jobs from changing their collected data until
+// the returned function is called, so the data may be used or changed.
+// The jobs may still query MySQL meanwhile.  Unknown names are ignored.
+func (c *Collector) Lock(names ...string) (unlock func()) {
+	// always lock in the same order to avoid deadlocks
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	var locked []*job
+	for i, name := range sorted {
+		if i > 0 && name == sorted[i-1] {
+			continue
+		}
+		if j, ok := c.jobs[name]; ok {
+			j.Lock()
+			locked = append(locked, j)
+		}
+	}
+
+	return func() {
+		for _, j := range locked {
+			j.Unlock()
+		}
+	}
+}
+
+// LockAll stops all the jobs from changing their collected data until
+// the returned function is called.
+func (c *Collector) LockAll() (unlock func()) {
+	return c.Lock(c.names()...)
+}
+
+// names returns the names of the jobs in order
+func (c *Collector) names() []string {
+	names := make([]string, 0, len(c.jobs))
+	for name := range c.jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ParseIntervals returns the intervals given as a comma-separated list
+// of name=seconds, e.g. digest_latency=10,memory_usage=5.
+func ParseIntervals(list string) (map[string]time.Duration, error) {
+	intervals := make(map[string]time.Duration)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid interval %q: expected <view>=<seconds>", item)
+		}
+		seconds, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || seconds < 1 {
+			return nil, fmt.Errorf("invalid interval %q: expected a number of seconds of at least 1", item)
+		}
+		intervals[strings.TrimSpace(parts[0])] = time.Duration(seconds) * time.Second
+	}
+
+	return intervals, nil
+}
@@ -0,0 +1,141 @@
+package collector
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// counter counts the collections made by a job, holding the lock of
+// the job while counting as a view does while applying its data
+type counter struct {
+	lock  sync.Locker
+	count int
+	err   error
+}
+
+func (c *counter) collect() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.count++
+	return c.err
+}
+
+func (c *counter) collected() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.count
+}
+
+func TestCollector(t *testing.T) {
+	var fast, slow counter
+	slow.err = errors.New("slow failed")
+
+	c := NewCollector(10 * time.Millisecond)
+	fast.lock = c.Add("fast", 0, fast.collect)
+	slow.lock = c.Add("slow", time.Hour, slow.collect)
+	c.Start()
+	defer c.Stop()
+
+	seen := make(map[string]error)
+	for len(seen) < 1 || fast.collected() < 3 {
+		select {
+		case r := <-c.Results():
+			seen[r.Name] = r.Err
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the fast job, results: %v", seen)
+		}
+	}
+
+	if _, ok := seen["slow"]; ok || slow.collected() != 0 {
+		t.Errorf("the slow job collected %d time(s) before its interval", slow.collected())
+	}
+	c.CollectNow("slow", "unknown")
+	for {
+		select {
+		case r := <-c.Results():
+			if r.Name != "slow" {
+				continue
+			}
+			if r.Err != slow.err {
+				t.Errorf("CollectNow(): expected error %v, got %v", slow.err, r.Err)
+			}
+			if slow.collected() != 1 {
+				t.Errorf("CollectNow(): expected the slow job to collect once, got %d", slow.collected())
+			}
+			return
+		case <-time.After(5 * time.Second):
+			t.Fatalf("CollectNow(): timed out waiting for the slow job")
+		}
+	}
+}
+
+func TestLock(t *testing.T) {
+	var fast counter
+	c := NewCollector(time.Millisecond)
+	fast.lock = c.Add("fast", 0, fast.collect)
+	c.Start()
+	defer c.Stop()
+
+	<-c.Results()
+	unlock := c.Lock("fast", "unknown")
+	before := fast.count
+	time.Sleep(20 * time.Millisecond)
+	if after := fast.count; after != before {
+		t.Errorf("Lock(): expected no collections while locked, got %d", after-before)
+	}
+	unlock()
+	<-c.Results()
+}
+
+func TestLockWhileQuerying(t *testing.T) {
+	var once sync.Once
+	querying, release := make(chan struct{}), make(chan struct{})
+	c := NewCollector(time.Millisecond)
+	c.Add("hung", 0, func() error {
+		once.Do(func() { close(querying) })
+		<-release // the query does not finish until the test does
+		return nil
+	})
+	c.Start()
+	defer c.Stop()
+	defer close(release)
+
+	<-querying
+	locked := make(chan struct{})
+	go func() {
+		c.LockAll()()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("LockAll(): waited for a query which has not finished")
+	}
+}
+
+func TestParseIntervals(t *testing.T) {
+	var tests = []struct {
+		list     string
+		expected map[string]time.Duration
+		ok       bool
+	}{
+		{"", map[string]time.Duration{}, true},
+		{"digest_latency=10, memory_usage=5", map[string]time.Duration{"digest_latency": 10 * time.Second, "memory_usage": 5 * time.Second}, true},
+		{"digest_latency", nil, false},
+		{"digest_latency=0", nil, false},
+		{"digest_latency=x", nil, false},
+	}
+
+	for _, test := range tests {
+		intervals, err := ParseIntervals(test.list)
+		if (err == nil) != test.ok {
+			t.Errorf("ParseIntervals(%q): unexpected error: %v", test.list, err)
+		}
+		if test.ok && !reflect.DeepEqual(intervals, test.expected) {
+			t.Errorf("ParseIntervals(%q): expected %v, got %v", test.list, test.expected, intervals)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Package columns remembers which columns of a view are hidden and
+// formats the rows of the view without them.
+package columns
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Hidden holds the indexes of the columns which are not shown
+type Hidden struct {
+	columns map[int]bool
+}
+
+// Name returns the name used to select a column from its heading, e.g.
+// "Table Name" is table_name. Any /s suffix shown when showing rates is
+// removed so Latency/s is latency.
+func Name(heading string) string {
+	name := strings.ToLower(strings.TrimSuffix(heading, "/s"))
+	return strings.NewReplacer(" ", "_", "/", "_").Replace(name)
+}
+
+// Hide hides the named columns of the given headings. All the columns
+// with a matching name are hidden, e.g. each of the % columns.
+func (h *Hidden) Hide(headings []string, names []string) error {
+	hidden := make(map[int]bool)
+	for _, name := range names {
+		found := false
+		for i := range headings {
+			if Name(headings[i]) == name {
+				hidden[i] = true
+				found = true
+			}
+		}
+		if !found {
+			known := make([]string, 0, len(headings))
+			for i := range headings {
+				known = append(known, Name(headings[i]))
+			}
+			return fmt.Errorf("unknown column %q. Try one of: %s", name, strings.Join(known, " "))
+		}
+	}
+	h.columns = hidden
+	return nil
+}
+
+// Sprintf formats the values of the columns which are not hidden.
+// Each verb of the format is a column and the separator next to a
+// hidden column is removed with it, preferring a space to a |.
+func (h Hidden) Sprintf(format string, args ...interface{}) string {
+	if len(h.columns) == 0 {
+		return fmt.Sprintf(format, args...)
+	}
+
+	verbs, separators := split(format)
+	for i := len(verbs) - 1; i >= 0; i-- {
+		if !h.columns[i] || i >= len(args) {
+			continue
+		}
+		// separators[i] is before verbs[i], separators[0] starts the format
+		s := i
+		if i == 0 || (!blank(separators[i]) && i+1 < len(verbs) && blank(separators[i+1])) {
+			s = i + 1
+		}
+		if s == len(verbs) {
+			s = i // keep the text after the last verb
+		}
+		separators = append(separators[:s], separators[s+1:]...)
+		verbs = append(verbs[:i], verbs[i+1:]...)
+		args = append(args[:i:i], args[i+1:]...)
+	}
+
+	var b strings.Builder
+	for i := range verbs {
+		b.WriteString(separators[i])
+		b.WriteString(verbs[i])
+	}
+	b.WriteString(separators[len(separators)-1])
+
+	return fmt.Sprintf(b.String(), args...)
+}
+
+// Strings formats the given strings, e.g. the headings, as Sprintf does
+func (h Hidden) Strings(format string, values []string) string {
+	args := make([]interface{}, 0, len(values))
+	for i := range values {
+		args = append(args, values[i])
+	}
+	return h.Sprintf(format, args...)
+}
+
+// split returns the verbs of the format and the text around them, one
+// more piece of text than there are verbs
+func split(format string) ([]string, []string) {
+	var verbs, separators []string
+
+	start := 0 // start of the current separator
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		if i+1 < len(format) && format[i+1] == '%' {
+			i++ // %% is not a verb
+			continue
+		}
+		end := i + 1
+		for end < len(format) && strings.IndexByte("0123456789.-+#", format[end]) >= 0 {
+			end++
+		}
+		if end < len(format) {
+			end++ // the verb character
+		}
+		separators = append(separators, format[start:i])
+		verbs = append(verbs, format[i:end])
+		start = end
+		i = end - 1
+	}
+	separators = append(separators, format[start:])
+
+	return verbs, separators
+}
+
+// blank returns true if the separator is only spaces
+func blank(separator string) bool {
+	return strings.TrimLeft(separator, " ") == ""
+}
@@ -0,0 +1,73 @@
+package columns
+
+import (
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	var tests = []struct {
+		heading  string
+		expected string
+	}{
+		{"Latency", "latency"},
+		{"Latency/s", "latency"},
+		{"Table Name", "table_name"},
+		{"Channel/Thread", "channel_thread"},
+		{"%", "%"},
+	}
+
+	for _, test := range tests {
+		if got := Name(test.heading); got != test.expected {
+			t.Errorf("Name(%q): expected %q, got %q", test.heading, test.expected, got)
+		}
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	const format = "%10s %6s|%6s %6s|%s"
+	headings := []string{"Latency/s", "%", "Fetch", "Insert", "Table Name"}
+
+	var tests = []struct {
+		hide     []string
+		expected string
+	}{
+		{nil, "   Latency      %| Fetch Insert|db.t"},
+		{[]string{"%"}, "   Latency| Fetch Insert|db.t"},
+		{[]string{"latency"}, "     %| Fetch Insert|db.t"},
+		{[]string{"fetch"}, "   Latency      %|Insert|db.t"},
+		{[]string{"fetch", "insert"}, "   Latency      %|db.t"},
+		{[]string{"table_name"}, "   Latency      %| Fetch Insert"},
+		{[]string{"latency", "%", "fetch", "insert"}, "db.t"},
+	}
+
+	for _, test := range tests {
+		var h Hidden
+		if err := h.Hide(headings, test.hide); err != nil {
+			t.Fatalf("Hide(%v): unexpected error: %v", test.hide, err)
+		}
+		if got := h.Sprintf(format, "Latency", "%", "Fetch", "Insert", "db.t"); got != test.expected {
+			t.Errorf("Sprintf() hiding %v: expected %q, got %q", test.hide, test.expected, got)
+		}
+	}
+}
+
+func TestSprintfText(t *testing.T) {
+	var h Hidden
+	if err := h.Hide([]string{"Value", "Type", "Variable"}, []string{"type"}); err != nil {
+		t.Fatalf("Hide(): unexpected error: %v", err)
+	}
+
+	if got, expected := h.Sprintf("%5s %-5.5s 100%%|Totals: %d", "1", "Counter", 3), "    1 100%|Totals: 3"; got != expected {
+		t.Errorf("Sprintf(): expected %q, got %q", expected, got)
+	}
+	if got, expected := h.Strings("%5s %-5s|%s", []string{"Value", "Type", "Variable"}), "Value|Variable"; got != expected {
+		t.Errorf("Strings(): expected %q, got %q", expected, got)
+	}
+}
+
+func TestHideUnknown(t *testing.T) {
+	var h Hidden
+	if err := h.Hide([]string{"Latency", "Table Name"}, []string{"name"}); err == nil {
+		t.Errorf("Hide(%q) expected an error", "name")
+	}
+}
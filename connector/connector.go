@@ -3,8 +3,10 @@
 package connector
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"time"
 
 	"github.com/sjmudd/mysql_defaults_file"
 	"github.com/sjmudd/ps-top/logger"
@@ -12,7 +14,9 @@ import (
 
 const (
 	db           = "performance_schema"
-	MaxOpenConns = 5 // hard-coded value!
+	MaxOpenConns = 5               // hard-coded value!
+	MaxIdleConns = 2               // the database/sql default
+	pingTimeout  = 5 * time.Second // how long to wait for MySQL to answer a ping
 	sqlDriver    = "mysql"
 
 	// ConnectByDefaultsFile indicates we want to connect using a MySQL defaults file
@@ -62,6 +66,27 @@ func (c *Connector) postConnectAction() {
 	c.dbh.SetMaxOpenConns(MaxOpenConns)
 }
 
+// Ping checks the connection to the database is still alive, giving
+// up if MySQL does not answer within pingTimeout
+func (c *Connector) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	return c.dbh.PingContext(ctx)
+}
+
+// Reconnect connects to the database again using the same settings as
+// before. Idle connections, which may have been broken by the server
+// restarting or failing over, are closed so new ones are made. The
+// handle returned by Handle() does not change.
+func (c *Connector) Reconnect() error {
+	logger.Println("Connector.Reconnect() Reconnecting...")
+	c.dbh.SetMaxIdleConns(0) // closes the idle connections
+	c.dbh.SetMaxIdleConns(MaxIdleConns)
+
+	return c.Ping()
+}
+
 // SetConnectBy records how we want to connect
 func (c *Connector) SetConnectBy(connectHow int) {
 	c.connectMethod = connectHow
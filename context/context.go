@@ -2,42 +2,73 @@
 package context
 
 import (
+	"context"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/sjmudd/ps-top/capabilities"
+	"github.com/sjmudd/ps-top/datasource"
 	"github.com/sjmudd/ps-top/global"
 	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/logger"
 	"github.com/sjmudd/ps-top/model/filter"
 	"github.com/sjmudd/ps-top/version"
+	"github.com/sjmudd/ps-top/window"
 )
 
-// Context holds the common information
+// Context holds the common information. It may be used by the views
+// collected in the background so the values which change are protected
+// by mu.
 type Context struct {
+	mu                sync.RWMutex
+	capabilities      capabilities.Capabilities
 	databaseFilter    *filter.DatabaseFilter
+	disconnected      bool // has the connection to MySQL been lost?
 	last              time.Time
+	queryTimeout      time.Duration // how long a collection may take, 0 for no limit
+	reconnects        int           // number of times we have reconnected to MySQL
+	restarts          int           // number of times MySQL has been seen to restart
+	source            datasource.Source
+	started           time.Time
 	status            *global.Status
 	uptime            int
 	variables         *global.Variables
 	version           string
+	wantRates         bool
 	wantRelativeStats bool
+	wantWindowStats   bool
+	window            window.Window
 }
 
-// NewContext returns the pointer to a new (empty) context
-func NewContext(status *global.Status, variables *global.Variables, databaseFilter *filter.DatabaseFilter) *Context {
+// NewContext returns the pointer to a new (empty) context.
+// status is not used (and may be nil) if the source does not collect from MySQL.
+func NewContext(source datasource.Source, status *global.Status, variables *global.Variables, databaseFilter *filter.DatabaseFilter) *Context {
 	return &Context{
 		databaseFilter: databaseFilter,
+		source:         source,
 		status:         status,
 		variables:      variables,
 	}
 }
 
+// Source returns the source the data is collected from
+func (c *Context) Source() datasource.Source {
+	return c.source
+}
+
+// Now returns the current time as seen by the data source
+func (c *Context) Now() time.Time {
+	return c.source.Now()
+}
+
 // DatabaseFilter returns the database filter to apply on queries (if appropriate)
-func (c Context) DatabaseFilter() *filter.DatabaseFilter {
+func (c *Context) DatabaseFilter() *filter.DatabaseFilter {
 	return c.databaseFilter
 }
 
 // Hostname returns the current short hostname
-func (c Context) Hostname() string {
+func (c *Context) Hostname() string {
 	hostname := c.variables.Get("hostname")
 	if index := strings.Index(hostname, "."); index >= 0 {
 		hostname = hostname[0:index]
@@ -46,36 +77,203 @@ func (c Context) Hostname() string {
 }
 
 // MySQLVersion returns the current MySQL version
-func (c Context) MySQLVersion() string {
+func (c *Context) MySQLVersion() string {
 	return c.variables.Get("version")
 }
 
 // Version returns the Application version
-func (c Context) Version() string {
+func (c *Context) Version() string {
 	return version.Version()
 }
 
 // MyName returns the program's name
-func (c Context) MyName() string {
+func (c *Context) MyName() string {
 	return lib.MyName()
 }
 
-// Uptime returns the time that MySQL has been up
-func (c Context) Uptime() int {
-	return c.status.Get("Uptime")
+// Uptime returns the time that MySQL has been up.
+// If it can not be collected the last value collected is returned.
+func (c *Context) Uptime() int {
+	var uptime int
+
+	collected, err := c.source.Collect("status/Uptime", &uptime, func() (err error) {
+		ctx, cancel := c.queryContext()
+		defer cancel()
+		uptime, err = c.status.Get(ctx, "Uptime")
+		return err
+	})
+	if err != nil {
+		logger.Println("Context.Uptime() unable to collect Uptime:", err)
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.uptime
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if uptime < c.uptime {
+		logger.Println("Context.Uptime() has gone backwards from", c.uptime, "to", uptime, "so MySQL has restarted")
+		c.restarts++
+		c.started = time.Time{}
+	}
+	c.uptime = uptime
+	if c.started.IsZero() {
+		c.started = collected.Add(-time.Duration(uptime) * time.Second)
+	}
+
+	return uptime
+}
+
+// LastUptime returns the uptime collected last by Uptime without
+// asking MySQL for it again.
+func (c *Context) LastUptime() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.uptime
+}
+
+// ServerRestarts returns the number of times MySQL has been seen to
+// restart, that is when Uptime() has gone backwards.
+func (c *Context) ServerRestarts() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.restarts
+}
+
+// SetDisconnected records whether the connection to MySQL has been lost
+func (c *Context) SetDisconnected(disconnected bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disconnected = disconnected
+}
+
+// Disconnected returns true if the connection to MySQL has been lost
+func (c *Context) Disconnected() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.disconnected
+}
+
+// SetQueryTimeout sets how long a collection may take before its
+// queries are cancelled, 0 for no limit
+func (c *Context) SetQueryTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queryTimeout = timeout
+}
+
+// QueryTimeout returns how long a collection may take before its
+// queries are cancelled, 0 for no limit
+func (c *Context) QueryTimeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.queryTimeout
+}
+
+// SetCapabilities sets the capabilities of the server we collect from
+func (c *Context) SetCapabilities(caps capabilities.Capabilities) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.capabilities = caps
+}
+
+// Capabilities returns the capabilities of the server we collect from
+func (c *Context) Capabilities() capabilities.Capabilities {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.capabilities
+}
+
+// queryContext returns a context which is cancelled after the query timeout
+func (c *Context) queryContext() (context.Context, context.CancelFunc) {
+	if timeout := c.QueryTimeout(); timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// AddReconnect records that we have reconnected to MySQL
+func (c *Context) AddReconnect() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reconnects++
+}
+
+// Reconnects returns the number of times we have reconnected to MySQL
+func (c *Context) Reconnects() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.reconnects
+}
+
+// StartTime returns the time the MySQL server started, worked out when
+// Uptime() is collected so MySQL is not queried.
+func (c *Context) StartTime() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.started.IsZero() {
+		return c.Now() // Uptime() has not been collected yet
+	}
+	return c.started
 }
 
 // Variables returns a pointer to global.Variables
-func (c Context) Variables() *global.Variables {
+func (c *Context) Variables() *global.Variables {
 	return c.variables
 }
 
 // SetWantRelativeStats tells what we want to see
 func (c *Context) SetWantRelativeStats(w bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.wantRelativeStats = w
 }
 
 // WantRelativeStats tells us what we have asked for
-func (c Context) WantRelativeStats() bool {
+func (c *Context) WantRelativeStats() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.wantRelativeStats
 }
+
+// SetWindow sets the size of the sliding window
+func (c *Context) SetWindow(w window.Window) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.window = w
+}
+
+// Window returns the size of the sliding window
+func (c *Context) Window() window.Window {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.window
+}
+
+// SetWantWindowStats tells us if we want to see statistics over the sliding window
+func (c *Context) SetWantWindowStats(w bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.wantWindowStats = w
+}
+
+// WantWindowStats tells us if we want to see statistics over the sliding window.
+// This takes precedence over WantRelativeStats().
+func (c *Context) WantWindowStats() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.wantWindowStats
+}
+
+// SetWantRates tells us if we want to see values per second
+func (c *Context) SetWantRates(w bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.wantRates = w
+}
+
+// WantRates tells us if we want to see values per second
+func (c *Context) WantRates() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.wantRates
+}
@@ -0,0 +1,62 @@
+package context
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+// uptimeSource returns the given uptimes in turn for status/Uptime
+type uptimeSource struct {
+	uptimes []int
+}
+
+func (s *uptimeSource) Collect(name string, data interface{}, query func() error) (time.Time, error) {
+	*data.(*int) = s.uptimes[0]
+	s.uptimes = s.uptimes[1:]
+	return s.Now(), nil
+}
+func (s *uptimeSource) Now() time.Time { return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC) }
+func (s *uptimeSource) State() string  { return "" }
+func (s *uptimeSource) Close() error   { return nil }
+
+func TestServerRestarts(t *testing.T) {
+	source := &uptimeSource{uptimes: []int{100, 101, 5, 6}}
+	c := NewContext(source, nil, new(global.Variables), filter.NewDatabaseFilter(""))
+
+	for _, expected := range []int{0, 0, 1, 1} {
+		c.Uptime()
+		if c.ServerRestarts() != expected {
+			t.Errorf("ServerRestarts() after Uptime() %d: expected %d, got %d", c.uptime, expected, c.ServerRestarts())
+		}
+	}
+}
+
+func TestLastUptime(t *testing.T) {
+	source := &uptimeSource{uptimes: []int{100}}
+	c := NewContext(source, nil, new(global.Variables), filter.NewDatabaseFilter(""))
+
+	c.Uptime()
+	if got := c.LastUptime(); got != 100 {
+		t.Errorf("LastUptime(): expected 100, got %d", got)
+	}
+}
+
+func TestStartTimeAfterRestart(t *testing.T) {
+	source := &uptimeSource{uptimes: []int{100, 10}}
+	c := NewContext(source, nil, new(global.Variables), filter.NewDatabaseFilter(""))
+
+	if got, expected := c.StartTime(), source.Now(); !got.Equal(expected) {
+		t.Errorf("StartTime() before Uptime(): expected %v, got %v", expected, got)
+	}
+	c.Uptime()
+	if got, expected := c.StartTime(), source.Now().Add(-100*time.Second); !got.Equal(expected) {
+		t.Errorf("StartTime(): expected %v, got %v", expected, got)
+	}
+	c.Uptime() // the server has restarted
+	if got, expected := c.StartTime(), source.Now().Add(-10*time.Second); !got.Equal(expected) {
+		t.Errorf("StartTime() after restart: expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,54 @@
+// Package datasource provides the data used by the models. Normally
+// this is collected from MySQL but it may also be recorded to a file
+// as it is collected, or replayed later from such a file without
+// needing access to the database.
+package datasource
+
+import (
+	"time"
+)
+
+// Source is where the models get their data from
+type Source interface {
+	// Collect stores the named data in data, which must be a pointer,
+	// calling query to collect it from MySQL if needed, and returns
+	// the time the data was collected or the error from collecting it.
+	Collect(name string, data interface{}, query func() error) (time.Time, error)
+	// Now returns the current time as seen by the source
+	Now() time.Time
+	// State returns a short description of the source's state to show to the user
+	State() string
+	// Close releases any resources used by the source
+	Close() error
+}
+
+// MySQL is a Source which collects the data directly from MySQL
+type MySQL struct{}
+
+// NewMySQL returns a Source which collects data from MySQL
+func NewMySQL() *MySQL {
+	return &MySQL{}
+}
+
+// Collect collects the data from MySQL by calling query
+func (m *MySQL) Collect(name string, data interface{}, query func() error) (time.Time, error) {
+	if err := query(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Now(), nil
+}
+
+// Now returns the current time
+func (m *MySQL) Now() time.Time {
+	return time.Now()
+}
+
+// State returns an empty string as there is nothing special to show
+func (m *MySQL) State() string {
+	return ""
+}
+
+// Close does nothing for MySQL
+func (m *MySQL) Close() error {
+	return nil
+}
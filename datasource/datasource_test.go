@@ -0,0 +1,106 @@
+package datasource
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeSource collects data at fixed times, one second apart
+type fakeSource struct {
+	now time.Time
+}
+
+func (f *fakeSource) Collect(name string, data interface{}, query func() error) (time.Time, error) {
+	if err := query(); err != nil {
+		return time.Time{}, err
+	}
+	f.now = f.now.Add(time.Second)
+	return f.now, nil
+}
+func (f *fakeSource) Now() time.Time { return f.now }
+func (f *fakeSource) State() string  { return "" }
+func (f *fakeSource) Close() error   { return nil }
+
+type row struct {
+	Name  string
+	Value uint64
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "datasource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "recording")
+
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	recorder, err := NewRecorder(filename, &fakeSource{now: start})
+	if err != nil {
+		t.Fatalf("NewRecorder() failed: %v", err)
+	}
+	var rows []row
+	for i := uint64(1); i <= 3; i++ {
+		// at start + i seconds
+		if _, err := recorder.Collect("rows", &rows, func() error { rows = []row{{"a", i}}; return nil }); err != nil {
+			t.Fatalf("Collect() failed: %v", err)
+		}
+		// failed collections are not recorded
+		failed := errors.New("lost connection")
+		if _, err := recorder.Collect("rows", &rows, func() error { rows = []row{{"b", 0}}; return failed }); err != failed {
+			t.Fatalf("Collect(): expected error %v, got %v", failed, err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	replayer, err := NewReplayer(filename)
+	if err != nil {
+		t.Fatalf("NewReplayer() failed: %v", err)
+	}
+
+	collect := func(expected uint64) {
+		t.Helper()
+		var got []row
+		collected, err := replayer.Collect("rows", &got, func() error {
+			t.Error("query should not be called when replaying")
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Collect() at %v: unexpected error: %v", replayer.Now(), err)
+		}
+		if len(got) != 1 || got[0].Value != expected {
+			t.Errorf("Collect() at %v: expected value %d, got %+v", replayer.Now(), expected, got)
+		}
+		if want := start.Add(time.Duration(expected) * time.Second); !collected.Equal(want) {
+			t.Errorf("Collect() at %v: expected collection time %v, got %v", replayer.Now(), want, collected)
+		}
+	}
+
+	collect(1) // clock is before the first entry so use that
+	replayer.Tick(time.Second)
+	collect(1)
+	replayer.TogglePause()
+	replayer.Tick(time.Second)
+	collect(1)
+	if state := replayer.State(); state != "[REPLAY PAUSED]" {
+		t.Errorf("State(): expected [REPLAY PAUSED], got %q", state)
+	}
+	replayer.Step()
+	collect(2)
+	replayer.TogglePause()
+	replayer.Faster()
+	if state := replayer.State(); state != "[REPLAY x2]" {
+		t.Errorf("State(): expected [REPLAY x2], got %q", state)
+	}
+	replayer.Tick(time.Second)
+	collect(3)
+	if state := replayer.State(); state != "[REPLAY END]" {
+		t.Errorf("State(): expected [REPLAY END], got %q", state)
+	}
+}
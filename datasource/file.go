@@ -0,0 +1,44 @@
+package datasource
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"time"
+)
+
+// fileVersion is the version of the recording file format
+const fileVersion = 1
+
+// header is written at the start of a recording
+type header struct {
+	Version int
+	Started time.Time
+}
+
+// entry holds a single collection of named data.  The data is gob
+// encoded separately so that the entries can be decoded without
+// needing to know the types of all the data in the file.
+type entry struct {
+	Time time.Time
+	Name string
+	Data []byte
+}
+
+// encode returns the gob encoding of data
+func encode(data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode(data); err != nil {
+		return nil, fmt.Errorf("unable to encode data: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decode decodes the gob encoded data into data
+func decode(encoded []byte, data interface{}) error {
+	if err := gob.NewDecoder(bytes.NewReader(encoded)).Decode(data); err != nil {
+		return fmt.Errorf("unable to decode data: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package datasource
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Recorder is a Source which collects data from another Source and
+// writes everything collected to a compressed recording file.
+type Recorder struct {
+	sync.Mutex
+	source  Source
+	file    *os.File
+	buffer  *bufio.Writer
+	gzip    *gzip.Writer
+	encoder *gob.Encoder
+	entries int
+}
+
+// NewRecorder returns a Recorder writing the data collected from source to filename
+func NewRecorder(filename string, source Source) (*Recorder, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create recording %s: %v", filename, err)
+	}
+
+	r := &Recorder{
+		source: source,
+		file:   file,
+		buffer: bufio.NewWriter(file),
+	}
+	r.gzip = gzip.NewWriter(r.buffer)
+	r.encoder = gob.NewEncoder(r.gzip)
+
+	if err := r.encoder.Encode(header{Version: fileVersion, Started: source.Now()}); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("unable to write to recording %s: %v", filename, err)
+	}
+	logger.Println("NewRecorder() recording to", filename)
+
+	return r, nil
+}
+
+// Collect collects the data from the underlying source and records it.
+// Nothing is recorded if the data could not be collected.
+func (r *Recorder) Collect(name string, data interface{}, query func() error) (time.Time, error) {
+	collected, err := r.source.Collect(name, data, query)
+	if err != nil {
+		return collected, err
+	}
+
+	encoded, err := encode(data)
+	if err == nil {
+		r.Lock()
+		err = r.encoder.Encode(entry{Time: collected, Name: name, Data: encoded})
+		r.entries++
+		r.Unlock()
+	}
+	if err != nil {
+		return collected, fmt.Errorf("unable to record %s: %v", name, err)
+	}
+
+	return collected, nil
+}
+
+// Now returns the current time of the underlying source
+func (r *Recorder) Now() time.Time {
+	return r.source.Now()
+}
+
+// State shows that we are recording
+func (r *Recorder) State() string {
+	return "[REC]"
+}
+
+// Close flushes the recording to disk and closes the underlying source
+func (r *Recorder) Close() error {
+	r.Lock()
+	defer r.Unlock()
+
+	logger.Println("Recorder.Close() recorded", r.entries, "entries")
+	if err := r.gzip.Close(); err != nil {
+		return err
+	}
+	if err := r.buffer.Flush(); err != nil {
+		return err
+	}
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	return r.source.Close()
+}
@@ -0,0 +1,205 @@
+package datasource
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// the range of playback speeds allowed
+const (
+	minSpeed = 1.0 / 16
+	maxSpeed = 64
+)
+
+// Replayer is a Source which provides the data from a recording.
+// It keeps a playback clock which is moved forward by Tick() or Step()
+// and returns the most recent data recorded at or before this time.
+type Replayer struct {
+	sync.Mutex
+	entries map[string][]entry // recorded entries by name in time order
+	times   []time.Time        // the times of all entries in order
+	clock   time.Time          // current playback time
+	speed   float64            // playback speed, 1 is real time
+	paused  bool
+}
+
+// NewReplayer returns a Replayer for the recording in filename
+func NewReplayer(filename string) (*Replayer, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open recording %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	r, err := newReplayer(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read recording %s: %v", filename, err)
+	}
+	logger.Println("NewReplayer() read", len(r.times), "entries from", filename)
+
+	return r, nil
+}
+
+// newReplayer returns a Replayer reading the recording from reader
+func newReplayer(reader io.Reader) (*Replayer, error) {
+	gz, err := gzip.NewReader(bufio.NewReader(reader))
+	if err != nil {
+		return nil, err
+	}
+	decoder := gob.NewDecoder(gz)
+
+	var h header
+	if err := decoder.Decode(&h); err != nil {
+		return nil, err
+	}
+	if h.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported version %d, expected %d", h.Version, fileVersion)
+	}
+
+	r := &Replayer{
+		entries: make(map[string][]entry),
+		clock:   h.Started,
+		speed:   1,
+	}
+	for {
+		var e entry
+		if err := decoder.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		r.entries[e.Name] = append(r.entries[e.Name], e)
+		r.times = append(r.times, e.Time)
+	}
+	if len(r.times) == 0 {
+		return nil, fmt.Errorf("no data recorded")
+	}
+	sort.Slice(r.times, func(i, j int) bool { return r.times[i].Before(r.times[j]) })
+
+	return r, nil
+}
+
+// Collect stores the most recent named data recorded at or before the
+// playback clock in data.  If nothing was recorded before the clock the
+// first recorded data is used.  query is not used.
+func (r *Replayer) Collect(name string, data interface{}, query func() error) (time.Time, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	entries := r.entries[name]
+	if len(entries) == 0 {
+		logger.Println("Replayer.Collect() no data recorded for", name)
+		return r.clock, nil
+	}
+
+	// find the first entry after the clock and use the one before it
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].Time.After(r.clock) })
+	if i > 0 {
+		i--
+	}
+	if err := decode(entries[i].Data, data); err != nil {
+		return r.clock, fmt.Errorf("unable to replay %s: %v", name, err)
+	}
+
+	return entries[i].Time, nil
+}
+
+// Now returns the playback time
+func (r *Replayer) Now() time.Time {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.clock
+}
+
+// State returns the state of the playback
+func (r *Replayer) State() string {
+	r.Lock()
+	defer r.Unlock()
+
+	switch {
+	case r.finished():
+		return "[REPLAY END]"
+	case r.paused:
+		return "[REPLAY PAUSED]"
+	}
+	return fmt.Sprintf("[REPLAY x%g]", r.speed)
+}
+
+// Close does nothing as the recording has already been read
+func (r *Replayer) Close() error {
+	return nil
+}
+
+// Finished returns true if all the recorded data has been replayed
+func (r *Replayer) Finished() bool {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.finished()
+}
+
+// finished returns true if the clock has passed the last recorded data
+func (r *Replayer) finished() bool {
+	return !r.clock.Before(r.times[len(r.times)-1])
+}
+
+// Tick moves the playback clock forward by the interval adjusted for
+// the playback speed, unless playback is paused or has finished.
+func (r *Replayer) Tick(interval time.Duration) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.paused || r.finished() {
+		return
+	}
+	r.clock = r.clock.Add(time.Duration(float64(interval) * r.speed))
+}
+
+// Step moves the playback clock forward to the time of the next recorded data
+func (r *Replayer) Step() {
+	r.Lock()
+	defer r.Unlock()
+
+	i := sort.Search(len(r.times), func(i int) bool { return r.times[i].After(r.clock) })
+	if i < len(r.times) {
+		r.clock = r.times[i]
+	}
+}
+
+// TogglePause pauses or resumes playback
+func (r *Replayer) TogglePause() {
+	r.Lock()
+	defer r.Unlock()
+
+	r.paused = !r.paused
+}
+
+// Faster doubles the playback speed
+func (r *Replayer) Faster() {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.speed < maxSpeed {
+		r.speed *= 2
+	}
+}
+
+// Slower halves the playback speed
+func (r *Replayer) Slower() {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.speed > minSpeed {
+		r.speed /= 2
+	}
+}
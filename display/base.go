@@ -3,6 +3,7 @@ package display
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/sjmudd/ps-top/context"
@@ -13,7 +14,11 @@ import (
 // to put what's needed in the header.  Make the internal members
 // visible without functions for now.
 type BaseDisplay struct {
-	ctx *context.Context
+	ctx      *context.Context
+	view     string        // name of the view being displayed
+	interval time.Duration // the poll interval
+	status   string        // status message such as an error to show to the user
+	cursor   int           // row the cursor is on, -1 if no cursor is shown
 }
 
 // SetContext sets the context from the given pointer
@@ -21,12 +26,72 @@ func (d *BaseDisplay) SetContext(ctx *context.Context) {
 	d.ctx = ctx
 }
 
-// return ctx.Uptime() but protect against nil pointers
+// SetView records the name of the view being displayed
+func (d *BaseDisplay) SetView(name string) {
+	d.view = name
+}
+
+// SetInterval records the interval between collections
+func (d *BaseDisplay) SetInterval(interval time.Duration) {
+	d.interval = interval
+}
+
+// SetStatus records a status message to show to the user, such as why
+// the data could not be collected. An empty message clears it.
+func (d *BaseDisplay) SetStatus(message string) {
+	d.status = message
+}
+
+// SetCursor puts the cursor on the given row, -1 hides the cursor
+func (d *BaseDisplay) SetCursor(row int) {
+	d.cursor = row
+}
+
+// Cursor returns the row the cursor is on, -1 if no cursor is shown
+func (d BaseDisplay) Cursor() int {
+	return d.cursor
+}
+
+// MoveCursor moves the cursor up (negative) or down (positive) the
+// given number of rows, showing the cursor if it was hidden.
+// The cursor is kept within the rows shown when displaying them.
+func (d *BaseDisplay) MoveCursor(rows int) {
+	if d.cursor < 0 {
+		d.cursor = 0
+		return
+	}
+	d.cursor += rows
+	if d.cursor < 0 {
+		d.cursor = 0
+	}
+}
+
+// clampCursor returns the cursor moved if needed onto one of the given number of rows
+func clampCursor(cursor, rows int) int {
+	if cursor >= rows {
+		cursor = rows - 1
+	}
+	if cursor < -1 {
+		cursor = -1
+	}
+	return cursor
+}
+
+// firstRow returns the first of the rows to show so the cursor is visible
+func firstRow(cursor, maxRows int) int {
+	if cursor < maxRows {
+		return 0
+	}
+	return cursor - maxRows + 1
+}
+
+// return the uptime collected last, so showing it does not wait for
+// MySQL, but protect against nil pointers
 func (d BaseDisplay) Uptime() int {
 	if d.ctx == nil {
 		return 0
 	}
-	return d.ctx.Uptime()
+	return d.ctx.LastUptime()
 }
 
 // MyName returns the application name (binary name)
@@ -34,22 +99,83 @@ func (d BaseDisplay) MyName() string {
 	return lib.MyName()
 }
 
-// HeadingLine returns the heading line as a string
-func (d *BaseDisplay) HeadingLine(haveRelativeStats, wantRelativeStats bool, initial, last time.Time) string {
-	heading := d.MyName() + " " + d.ctx.Version() + " - " + nowHHMMSS() + " " + d.ctx.Hostname() + " / " + d.ctx.MySQLVersion() + ", up " + fmt.Sprintf("%-16s", lib.Uptime(d.Uptime()))
+// HeadingLine returns the heading line as a string.
+// truncated is the time the data was last seen to be truncated, if ever.
+func (d *BaseDisplay) HeadingLine(haveRelativeStats, wantRelativeStats bool, initial, last, truncated time.Time) string {
+	heading := d.MyName() + " " + d.ctx.Version() + " - " + hhmmss(d.ctx.Now()) + " " + d.ctx.Hostname() + " / " + d.ctx.MySQLVersion() + ", up " + fmt.Sprintf("%-16s", lib.Uptime(d.Uptime()))
 
 	if haveRelativeStats {
-		if wantRelativeStats {
-			heading += " [REL] " + fmt.Sprintf("%.0f seconds", time.Since(initial).Seconds())
+		if d.ctx.WantWindowStats() {
+			heading += " [WIN] " + fmt.Sprintf("%.0f seconds", d.ctx.Now().Sub(initial).Seconds())
+		} else if wantRelativeStats {
+			heading += " [REL] " + fmt.Sprintf("%.0f seconds", d.ctx.Now().Sub(initial).Seconds())
 		} else {
 			heading += " [ABS]             "
 		}
 	}
+	if !truncated.IsZero() {
+		heading += " [TRUNCATED " + hhmmss(truncated) + "]"
+	}
+	if state := d.ctx.Source().State(); state != "" {
+		heading += " " + state
+	}
+	if state := connectionState(d.ctx.Disconnected(), d.ctx.Reconnects()); state != "" {
+		heading += " " + state
+	}
 	return heading
 }
 
+// connectionState returns a short description of the state of the
+// connection to MySQL, which is empty if all is well.
+func connectionState(disconnected bool, reconnects int) string {
+	switch {
+	case disconnected && reconnects > 0:
+		return fmt.Sprintf("[DISCONNECTED, %d reconnects]", reconnects)
+	case disconnected:
+		return "[DISCONNECTED]"
+	case reconnects > 0:
+		return fmt.Sprintf("[%d reconnects]", reconnects)
+	}
+	return ""
+}
+
+// mode returns the type of statistics being shown: WIN, REL or ABS
+func (d *BaseDisplay) mode(haveRelativeStats, wantRelativeStats bool) string {
+	switch {
+	case !haveRelativeStats:
+		return "ABS"
+	case d.ctx.WantWindowStats():
+		return "WIN"
+	case wantRelativeStats:
+		return "REL"
+	}
+	return "ABS"
+}
+
+// headingOffset returns the position of heading within headings or -1 if
+// it is not found. The heading must be a complete column heading so must
+// be surrounded by spaces, column separators or the end of the line.
+func headingOffset(headings, heading string) int {
+	if heading == "" {
+		return -1
+	}
+	for start := 0; start+len(heading) <= len(headings); start++ {
+		offset := strings.Index(headings[start:], heading)
+		if offset < 0 {
+			break
+		}
+		offset += start
+		end := offset + len(heading)
+		if (offset == 0 || strings.ContainsRune(" |", rune(headings[offset-1]))) &&
+			(end == len(headings) || strings.ContainsRune(" |", rune(headings[end]))) {
+			return offset
+		}
+		start = offset
+	}
+	return -1
+}
+
 // if there's a better way of doing this do it better ...
-func nowHHMMSS() string {
-	t := time.Now()
+func hhmmss(t time.Time) string {
 	return fmt.Sprintf("%2d:%02d:%02d", t.Hour(), t.Minute(), t.Second())
 }
@@ -0,0 +1,99 @@
+package display
+
+import (
+	"testing"
+)
+
+func TestConnectionState(t *testing.T) {
+	var tests = []struct {
+		disconnected bool
+		reconnects   int
+		expected     string
+	}{
+		{false, 0, ""},
+		{false, 2, "[2 reconnects]"},
+		{true, 0, "[DISCONNECTED]"},
+		{true, 1, "[DISCONNECTED, 1 reconnects]"},
+	}
+
+	for _, test := range tests {
+		if got := connectionState(test.disconnected, test.reconnects); got != test.expected {
+			t.Errorf("connectionState(%v, %d): expected %q, got %q", test.disconnected, test.reconnects, test.expected, got)
+		}
+	}
+}
+
+func TestHeadingOffset(t *testing.T) {
+	var tests = []struct {
+		headings string
+		heading  string
+		expected int
+	}{
+		{"Latency      %|Table Name", "Latency", 0},
+		{"Latency      %|Table Name", "Table Name", 15},
+		{"Latency  Lat/s", "Lat", -1},
+		{"Latency", "", -1},
+	}
+
+	for _, test := range tests {
+		if got := headingOffset(test.headings, test.heading); got != test.expected {
+			t.Errorf("headingOffset(%q, %q): expected %d, got %d", test.headings, test.heading, test.expected, got)
+		}
+	}
+}
+
+func TestMoveCursor(t *testing.T) {
+	d := BaseDisplay{cursor: -1}
+
+	d.MoveCursor(-1)
+	if d.Cursor() != 0 {
+		t.Errorf("MoveCursor(-1) from a hidden cursor: expected 0, got %d", d.Cursor())
+	}
+	d.MoveCursor(3)
+	if d.Cursor() != 3 {
+		t.Errorf("MoveCursor(3): expected 3, got %d", d.Cursor())
+	}
+	d.MoveCursor(-5)
+	if d.Cursor() != 0 {
+		t.Errorf("MoveCursor(-5): expected 0, got %d", d.Cursor())
+	}
+}
+
+func TestClampCursor(t *testing.T) {
+	var tests = []struct {
+		cursor   int
+		rows     int
+		expected int
+	}{
+		{-1, 10, -1},
+		{-3, 10, -1},
+		{5, 10, 5},
+		{10, 10, 9},
+		{3, 0, -1},
+	}
+
+	for _, test := range tests {
+		if got := clampCursor(test.cursor, test.rows); got != test.expected {
+			t.Errorf("clampCursor(%d, %d): expected %d, got %d", test.cursor, test.rows, test.expected, got)
+		}
+	}
+}
+
+func TestFirstRow(t *testing.T) {
+	var tests = []struct {
+		cursor   int
+		maxRows  int
+		expected int
+	}{
+		{-1, 10, 0},
+		{9, 10, 0},
+		{10, 10, 1},
+		{25, 10, 16},
+	}
+
+	for _, test := range tests {
+		if got := firstRow(test.cursor, test.maxRows); got != test.expected {
+			t.Errorf("firstRow(%d, %d): expected %d, got %d", test.cursor, test.maxRows, test.expected, got)
+		}
+	}
+}
@@ -2,6 +2,8 @@
 package display
 
 import (
+	"time"
+
 	"github.com/sjmudd/ps-top/context"
 	"github.com/sjmudd/ps-top/event"
 )
@@ -10,9 +12,15 @@ import (
 type Display interface {
 	// set values which are used later
 	SetContext(ctx *context.Context)
+	SetInterval(interval time.Duration)
+	SetCursor(row int)
+	SetStatus(message string)
+	SetView(name string)
 
 	// stuff used by some of the objects
 	ClearScreen()
+	Cursor() int
+	MoveCursor(rows int)
 	Close()
 	EventChan() chan event.Event
 	Resize(width, height int)
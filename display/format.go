@@ -0,0 +1,113 @@
+package display
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sjmudd/ps-top/record"
+)
+
+// Format is the format used to output data to stdout
+type Format int
+
+// Format* are the different supported output formats
+const (
+	FormatText Format = iota // padded text as shown on the screen
+	FormatJSON               // one JSON object per collection
+	FormatCSV                // comma separated values
+	FormatTSV                // tab separated values
+)
+
+var formatNames = map[Format]string{
+	FormatText: "text",
+	FormatJSON: "json",
+	FormatCSV:  "csv",
+	FormatTSV:  "tsv",
+}
+
+// ParseFormat returns the Format for the given name. An empty name returns FormatText.
+func ParseFormat(name string) (Format, error) {
+	if name == "" {
+		return FormatText, nil
+	}
+	for f := range formatNames {
+		if formatNames[f] == name {
+			return f, nil
+		}
+	}
+	return FormatText, fmt.Errorf("unknown format %q. Try one of: text, json, csv, tsv", name)
+}
+
+func (f Format) String() string {
+	return formatNames[f]
+}
+
+// collection holds the records from one collection together with the
+// information needed to interpret them
+type collection struct {
+	View           string          `json:"view"`
+	Hostname       string          `json:"hostname"`
+	FirstCollected time.Time       `json:"first_collected"`
+	LastCollected  time.Time       `json:"last_collected"`
+	Interval       float64         `json:"interval"` // poll interval in seconds
+	Mode           string          `json:"mode"`     // REL, ABS or WIN
+	Rows           []record.Record `json:"rows,omitempty"`
+	Totals         record.Record   `json:"totals"`
+}
+
+// writeJSON writes the collection as a single line of JSON
+func writeJSON(w io.Writer, c collection) error {
+	return json.NewEncoder(w).Encode(c)
+}
+
+// recordWriter writes collections as comma or tab separated values.
+// A header line is written before the first collection and again if the
+// fields being written change (e.g. a different view is being shown).
+type recordWriter struct {
+	w      *csv.Writer
+	header string
+}
+
+// newRecordWriter returns a recordWriter using the given separator
+func newRecordWriter(w io.Writer, separator rune) *recordWriter {
+	cw := csv.NewWriter(w)
+	cw.Comma = separator
+
+	return &recordWriter{w: cw}
+}
+
+// write writes the rows and totals of the collection, one line per record
+func (rw *recordWriter) write(c collection) error {
+	prefix := []string{
+		c.View,
+		c.Hostname,
+		c.FirstCollected.Format(time.RFC3339Nano),
+		c.LastCollected.Format(time.RFC3339Nano),
+		fmt.Sprint(c.Interval),
+		c.Mode,
+	}
+
+	header := append([]string{"view", "hostname", "first_collected", "last_collected", "interval", "mode", "type"}, c.Totals.Names()...)
+	if joined := strings.Join(header, ","); joined != rw.header {
+		if err := rw.w.Write(header); err != nil {
+			return err
+		}
+		rw.header = joined
+	}
+
+	for i := range c.Rows {
+		if err := rw.w.Write(append(append(prefix, "row"), c.Rows[i].Strings()...)); err != nil {
+			return err
+		}
+	}
+	if err := rw.w.Write(append(append(prefix, "totals"), c.Totals.Strings()...)); err != nil {
+		return err
+	}
+
+	rw.w.Flush()
+	return rw.w.Error()
+}
@@ -0,0 +1,64 @@
+package display
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sjmudd/ps-top/record"
+)
+
+func TestParseFormat(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected Format
+		isError  bool
+	}{
+		{"", FormatText, false},
+		{"text", FormatText, false},
+		{"json", FormatJSON, false},
+		{"csv", FormatCSV, false},
+		{"tsv", FormatTSV, false},
+		{"xml", FormatText, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseFormat(test.input)
+		if (err != nil) != test.isError {
+			t.Errorf("ParseFormat(%q) error: expected %v, got %v", test.input, test.isError, err)
+		}
+		if got != test.expected {
+			t.Errorf("ParseFormat(%q): expected %v, got %v", test.input, test.expected, got)
+		}
+	}
+}
+
+func TestRecordWriter(t *testing.T) {
+	collected := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := collection{
+		View:           "mutex_latency",
+		Hostname:       "myhost",
+		FirstCollected: collected,
+		LastCollected:  collected.Add(time.Second),
+		Interval:       1,
+		Mode:           "REL",
+		Rows:           []record.Record{{{Name: "name", Value: "a"}, {Name: "latency_ps", Value: uint64(10)}}},
+		Totals:         record.Record{{Name: "name", Value: "Totals"}, {Name: "latency_ps", Value: uint64(10)}},
+	}
+	const expected = "view\thostname\tfirst_collected\tlast_collected\tinterval\tmode\ttype\tname\tlatency_ps\n" +
+		"mutex_latency\tmyhost\t2020-01-02T03:04:05Z\t2020-01-02T03:04:06Z\t1\tREL\trow\ta\t10\n" +
+		"mutex_latency\tmyhost\t2020-01-02T03:04:05Z\t2020-01-02T03:04:06Z\t1\tREL\ttotals\tTotals\t10\n" +
+		"mutex_latency\tmyhost\t2020-01-02T03:04:05Z\t2020-01-02T03:04:06Z\t1\tREL\trow\ta\t10\n" +
+		"mutex_latency\tmyhost\t2020-01-02T03:04:05Z\t2020-01-02T03:04:06Z\t1\tREL\ttotals\tTotals\t10\n"
+
+	var buf bytes.Buffer
+	rw := newRecordWriter(&buf, '\t')
+	for i := 0; i < 2; i++ {
+		if err := rw.write(c); err != nil {
+			t.Fatalf("write() failed: %v", err)
+		}
+	}
+	if buf.String() != expected {
+		t.Errorf("write(): expected\n%s\ngot\n%s", expected, buf.String())
+	}
+}
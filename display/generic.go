@@ -2,6 +2,8 @@ package display
 
 import (
 	"time"
+
+	"github.com/sjmudd/ps-top/record"
 )
 
 // GenericData is a generic interface to data collected from P_S (multiple rows)
@@ -10,9 +12,13 @@ type GenericData interface {
 	Headings() string            // headings for the data
 	FirstCollectTime() time.Time // initial time data was collected
 	LastCollectTime() time.Time  // last time data was collected
+	LastTruncation() time.Time   // last time the table was seen to be truncated
 	Len() int                    // the number row rows of data
 	RowContent() []string        // a slice of rows of content
+	Records() []record.Record    // the rows of content as typed records
+	SortHeading() string         // the heading of the column the rows are sorted by
 	TotalRowContent() string     // a string containing the details of a single row
+	TotalRecord() record.Record  // the totals as a typed record
 	EmptyRowContent() string     // a string containing the details of an empty row
 	HaveRelativeStats() bool     // does this data type have relative statistics
 	WantRelativeStats() bool     // do we want to show relative statistics
@@ -21,6 +21,7 @@ type ScreenDisplay struct {
 func NewScreenDisplay(limit int, onlyTotals bool) *ScreenDisplay {
 	s := new(ScreenDisplay)
 
+	s.cursor = -1 // shown once the cursor is moved
 	s.screen = new(screen.TermboxScreen)
 	s.screen.Initialise()
 	s.termboxChan = s.screen.TermBoxChan()
@@ -30,20 +31,32 @@ func NewScreenDisplay(limit int, onlyTotals bool) *ScreenDisplay {
 
 // Display displays the wanted view to the screen
 func (s *ScreenDisplay) Display(t GenericData) {
-	s.screen.PrintAt(0, 0, s.HeadingLine(t.HaveRelativeStats(), t.WantRelativeStats(), t.FirstCollectTime(), t.LastCollectTime()))
+	s.screen.PrintAt(0, 0, s.HeadingLine(t.HaveRelativeStats(), t.WantRelativeStats(), t.FirstCollectTime(), t.LastCollectTime(), t.LastTruncation()))
 	s.screen.PrintAt(0, 1, t.Description())
 	s.screen.BoldPrintAt(0, 2, t.Headings())
+	if x := headingOffset(t.Headings(), t.SortHeading()); x >= 0 {
+		s.screen.HighlightPrintAt(x, 2, t.SortHeading())
+	}
 
 	maxRows := s.screen.Height() - 4
 	lastRow := s.screen.Height() - 1
+	if s.status != "" {
+		maxRows-- // leave space for the status line above the totals
+	}
 	content := t.RowContent()
+	s.cursor = clampCursor(s.cursor, len(content))
+	first := firstRow(s.cursor, maxRows) // scroll to keep the cursor visible
 
 	for k := 0; k < maxRows; k++ {
 		y := 3 + k
-		if k <= len(content)-1 && k < maxRows {
-			// print out rows
-			s.screen.PrintAt(0, y, content[k])
-			s.screen.ClearLine(len(content[k]), y)
+		if row := first + k; row <= len(content)-1 && k < maxRows {
+			// print out rows, highlighting the row the cursor is on
+			if row == s.cursor {
+				s.screen.HighlightPrintAt(0, y, content[row])
+			} else {
+				s.screen.PrintAt(0, y, content[row])
+			}
+			s.screen.ClearLine(len(content[row]), y)
 		} else {
 			// print out empty rows
 			if y < lastRow {
@@ -52,6 +65,11 @@ func (s *ScreenDisplay) Display(t GenericData) {
 		}
 	}
 
+	if s.status != "" {
+		s.screen.HighlightPrintAt(0, lastRow-1, s.status)
+		s.screen.ClearLine(len(s.status), lastRow-1)
+	}
+
 	// print out the totals at the bottom
 	total := t.TotalRowContent()
 	s.screen.BoldPrintAt(0, lastRow, total)
@@ -72,16 +90,36 @@ func (s *ScreenDisplay) DisplayHelp() {
 	s.screen.PrintAt(0, 3, "performance_schema schema. Ideas based on mysql-sys.")
 
 	s.screen.PrintAt(0, 5, "Keys:")
-	s.screen.PrintAt(0, 6, "- - reduce the poll interval by 1 second (minimum 1 second)")
-	s.screen.PrintAt(0, 7, "+ - increase the poll interval by 1 second")
-	s.screen.PrintAt(0, 8, "h/? - this help screen")
-	s.screen.PrintAt(0, 9, "q - quit")
-	s.screen.PrintAt(0, 10, "s - sort differently (where enabled) - sorts on a different column")
-	s.screen.PrintAt(0, 11, "t - toggle between showing time since resetting statistics or since P_S data was collected")
-	s.screen.PrintAt(0, 12, "z - reset statistics")
-	s.screen.PrintAt(0, 13, "<tab> or <right arrow> - change display modes between: latency, ops, file I/O, lock and user modes")
-	s.screen.PrintAt(0, 14, "<left arrow> - change display modes to the previous screen (see above)")
-	s.screen.PrintAt(0, 16, "Press h to return to main screen")
+	keys := []string{
+		"- - reduce the poll interval by 1 second (minimum 1 second)",
+		"+ - increase the poll interval by 1 second",
+		"c or e - in the wait_latency view collapse or expand the wait event hierarchy by a level",
+		"g - in the user_latency view group by user, host or account",
+		"h/? - this help screen",
+		"i - in the threads view show or hide idle connections",
+		"k or K - in the threads view kill the query or connection under the cursor (needs --allow-kill)",
+		"n - when replaying, step to the next recorded collection",
+		"p - when replaying, pause or resume playback",
+		"q - quit",
+		"r - toggle between showing values per second (rates) and showing the collected values",
+		"R - reverse the sort order of the current column",
+		"s - sort on the next column (the sorted column heading is highlighted)",
+		"S - sort on the previous column",
+		"t - toggle between showing time since resetting statistics or since P_S data was collected",
+		"w - toggle showing statistics over a sliding window of the most recently collected data",
+		"y - confirm killing a query or connection",
+		"z - reset statistics",
+		"< or > - when replaying, halve or double the playback speed",
+		"<tab> or <right arrow> - change display modes between: latency, ops, file I/O, lock and user modes",
+		"<left arrow> - change display modes to the previous screen (see above)",
+		"<up arrow> or <down arrow> - move the cursor between rows",
+		"<enter> - show the index, lock and file I/O details of the table under the cursor",
+		"<esc> - return from the details to the list (or quit)",
+	}
+	for i := range keys {
+		s.screen.PrintAt(0, 6+i, keys[i])
+	}
+	s.screen.PrintAt(0, 7+len(keys), "Press h to return to main screen")
 }
 
 // Resize records the new size of the screen and resizes it
@@ -106,18 +144,58 @@ func (s *ScreenDisplay) pollEvent() event.Event {
 				e = event.Event{Type: event.EventDecreasePollTime}
 			case '+':
 				e = event.Event{Type: event.EventIncreasePollTime}
+			case 'c':
+				e = event.Event{Type: event.EventCollapse}
+			case 'e':
+				e = event.Event{Type: event.EventExpand}
+			case 'g':
+				e = event.Event{Type: event.EventGroupNext}
 			case 'h', '?':
 				e = event.Event{Type: event.EventHelp}
+			case 'i':
+				e = event.Event{Type: event.EventToggleIdle}
+			case 'k':
+				e = event.Event{Type: event.EventKillQuery}
+			case 'K':
+				e = event.Event{Type: event.EventKillConnection}
+			case 'n':
+				e = event.Event{Type: event.EventReplayStep}
+			case 'p':
+				e = event.Event{Type: event.EventReplayPause}
 			case 'q':
 				e = event.Event{Type: event.EventFinished}
+			case 'r':
+				e = event.Event{Type: event.EventToggleWantRates}
+			case 'R':
+				e = event.Event{Type: event.EventSortReverse}
+			case 's':
+				e = event.Event{Type: event.EventSortNext}
+			case 'S':
+				e = event.Event{Type: event.EventSortPrev}
 			case 't':
 				e = event.Event{Type: event.EventToggleWantRelative}
+			case 'w':
+				e = event.Event{Type: event.EventToggleWantWindow}
+			case 'y':
+				e = event.Event{Type: event.EventConfirm}
 			case 'z':
 				e = event.Event{Type: event.EventResetStatistics}
+			case '<':
+				e = event.Event{Type: event.EventReplaySlower}
+			case '>':
+				e = event.Event{Type: event.EventReplayFaster}
 			}
 			switch tbEvent.Key {
-			case termbox.KeyCtrlZ, termbox.KeyCtrlC, termbox.KeyEsc:
+			case termbox.KeyCtrlZ, termbox.KeyCtrlC:
 				e = event.Event{Type: event.EventFinished}
+			case termbox.KeyEsc:
+				e = event.Event{Type: event.EventBack}
+			case termbox.KeyArrowUp:
+				e = event.Event{Type: event.EventCursorUp}
+			case termbox.KeyArrowDown:
+				e = event.Event{Type: event.EventCursorDown}
+			case termbox.KeyEnter:
+				e = event.Event{Type: event.EventSelect}
 			case termbox.KeyArrowLeft:
 				e = event.Event{Type: event.EventViewPrev}
 			case termbox.KeyTab, termbox.KeyArrowRight:
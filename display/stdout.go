@@ -2,8 +2,12 @@ package display
 
 import (
 	"fmt"
+	"io"
+	"log"
+	"os"
 
 	"github.com/sjmudd/ps-top/event"
+	"github.com/sjmudd/ps-top/record"
 )
 
 // StdoutDisplay holds specific information needed for sending data to stdout.
@@ -11,25 +15,53 @@ type StdoutDisplay struct {
 	BaseDisplay // embedded
 	limit       int
 	totals      bool
+	format      Format
+	out         io.Writer
+	records     *recordWriter    // used for csv and tsv output
+	events      chan event.Event // used to tell the app we can not write any more
 }
 
 // return a setup StdoutDisplay
-func NewStdoutDisplay(limit int, onlyTotals bool) *StdoutDisplay {
+func NewStdoutDisplay(limit int, onlyTotals bool, format Format) *StdoutDisplay {
 	s := new(StdoutDisplay)
 
 	s.limit = limit
 	s.totals = onlyTotals
+	s.format = format
+	s.out = os.Stdout
+	s.events = make(chan event.Event, 1)
+
+	switch format {
+	case FormatCSV:
+		s.records = newRecordWriter(s.out, ',')
+	case FormatTSV:
+		s.records = newRecordWriter(s.out, '\t')
+	}
 
 	return s
 }
 
+// SetStatus records the status message, writing any new message to stderr
+// so it does not get mixed up with the data.
+func (s *StdoutDisplay) SetStatus(message string) {
+	if message != "" && message != s.status {
+		fmt.Fprintln(os.Stderr, message)
+	}
+	s.BaseDisplay.SetStatus(message)
+}
+
 // ClearScreen does nothing for StdoutDisplay
 func (s *StdoutDisplay) ClearScreen() {
 }
 
 // Display displays the data for the required view
 func (s *StdoutDisplay) Display(p GenericData) {
-	fmt.Println(s.HeadingLine(p.HaveRelativeStats(), p.WantRelativeStats(), p.FirstCollectTime(), p.LastCollectTime()))
+	if s.format != FormatText {
+		s.displayRecords(p)
+		return
+	}
+
+	fmt.Println(s.HeadingLine(p.HaveRelativeStats(), p.WantRelativeStats(), p.FirstCollectTime(), p.LastCollectTime(), p.LastTruncation()))
 	fmt.Println(p.Description())
 	fmt.Println(p.Headings())
 
@@ -52,6 +84,45 @@ func (s *StdoutDisplay) Display(p GenericData) {
 	fmt.Println(p.TotalRowContent())
 }
 
+// displayRecords writes the data for the required view as typed records
+func (s *StdoutDisplay) displayRecords(p GenericData) {
+	c := collection{
+		View:           s.view,
+		Hostname:       s.ctx.Hostname(),
+		FirstCollected: p.FirstCollectTime(),
+		LastCollected:  p.LastCollectTime(),
+		Interval:       s.interval.Seconds(),
+		Mode:           s.mode(p.HaveRelativeStats(), p.WantRelativeStats()),
+		Totals:         p.TotalRecord(),
+	}
+
+	if !s.totals {
+		c.Rows = make([]record.Record, 0)
+		for _, r := range p.Records() {
+			if s.limit > 0 && len(c.Rows) >= s.limit {
+				break
+			}
+			if r.HasData() {
+				c.Rows = append(c.Rows, r)
+			}
+		}
+	}
+
+	var err error
+	if s.format == FormatJSON {
+		err = writeJSON(s.out, c)
+	} else {
+		err = s.records.write(c)
+	}
+	if err != nil {
+		log.Printf("Unable to write %s output: %v", s.format, err)
+		select {
+		case s.events <- event.Event{Type: event.EventError}:
+		default: // already told the app
+		}
+	}
+}
+
 // DisplayHelp does nothing on a StdoutDisplay
 func (s *StdoutDisplay) DisplayHelp() {
 }
@@ -64,10 +135,8 @@ func (s *StdoutDisplay) Close() {
 func (s *StdoutDisplay) Resize(width, height int) {
 }
 
-// EventChan creates a channel for event.Events and return the channel.
-// currently does nothing...
+// EventChan returns the channel for event.Events which is only used to
+// report that the output can not be written.
 func (s *StdoutDisplay) EventChan() chan event.Event {
-	e := make(chan event.Event)
-
-	return e
+	return s.events
 }
@@ -15,7 +15,27 @@ const (
 	EventIncreasePollTime               // increase the poll time
 	EventHelp                           // provide me with help
 	EventToggleWantRelative             // toggle beween wanting absolute or relative stats
+	EventToggleWantWindow               // toggle between wanting sliding window stats or not
+	EventToggleWantRates                // toggle between wanting values per second or not
 	EventResetStatistics                // reset the current stats back to zero
+	EventSortNext                       // sort on the next column
+	EventSortPrev                       // sort on the previous column
+	EventSortReverse                    // reverse the current sort order
+	EventReplayPause                    // pause or resume replaying a recording
+	EventReplayStep                     // step to the next recorded collection
+	EventReplayFaster                   // replay faster
+	EventReplaySlower                   // replay slower
+	EventCursorUp                       // move the cursor up a row
+	EventCursorDown                     // move the cursor down a row
+	EventSelect                         // show the details of the row under the cursor
+	EventBack                           // return from the details to the list
+	EventExpand                         // show one more level of a hierarchy
+	EventCollapse                       // show one less level of a hierarchy
+	EventGroupNext                      // group by the next of user, host or account
+	EventToggleIdle                     // show or hide idle connections
+	EventKillQuery                      // kill the query of the connection under the cursor
+	EventKillConnection                 // kill the connection under the cursor
+	EventConfirm                        // confirm the pending action
 	EventResizeScreen                   // not really a event but a state change
 	EventUnknown                        // something weird has happened
 	EventError                          // some error
@@ -0,0 +1,196 @@
+// Package exporter publishes the data collected by the views as
+// Prometheus metrics using the Prometheus text exposition format.
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sjmudd/ps-top/logger"
+	"github.com/sjmudd/ps-top/ps_table"
+	"github.com/sjmudd/ps-top/record"
+)
+
+// ContentType is the content type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// prefix is used at the start of every metric name
+const prefix = "pstop_"
+
+// picoseconds in a second, used to convert latencies to seconds
+const picoseconds = 1e12
+
+// View describes how the records of a Tabler are exported.
+// Each numeric field of a record becomes a metric named
+// pstop_<Subsystem>_<field> with the record's name as the Label.
+type View struct {
+	Subsystem string          // metric subsystem, e.g. table_io
+	Label     string          // name of the label holding the row name, e.g. table
+	Tabler    ps_table.Tabler // provides the data
+	Gauges    []string        // fields which are gauges rather than counters
+	Shared    bool            // the data is shared with the previous view so is not collected again
+}
+
+// isGauge returns true if the named field is a gauge
+func (v View) isGauge(field string) bool {
+	for i := range v.Gauges {
+		if v.Gauges[i] == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Exporter is an http.Handler which collects data from the views on
+// each scrape and writes it out as Prometheus metrics.
+type Exporter struct {
+	sync.Mutex // only one collection may take place at once
+	views      []View
+}
+
+// NewExporter returns an Exporter for the given views
+func NewExporter(views ...View) *Exporter {
+	return &Exporter{views: views}
+}
+
+// ServeHTTP collects the data and writes out the metrics
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger.Println("Exporter.ServeHTTP() from", r.RemoteAddr)
+	w.Header().Set("Content-Type", ContentType)
+
+	if err := e.Write(w); err != nil {
+		logger.Println("Exporter.ServeHTTP() failed to write metrics:", err)
+	}
+}
+
+// Write collects the data from each view and writes it to w
+func (e *Exporter) Write(w io.Writer) error {
+	e.Lock()
+	defer e.Unlock()
+
+	bw := bufio.NewWriter(w)
+	up := 1
+	failed := true // did the collection of the last view collected fail, or is there none yet?
+	for _, v := range e.views {
+		if !v.Shared {
+			failed = false
+			if err := v.Tabler.Collect(); err != nil {
+				logger.Println("Exporter.Write() unable to collect", v.Subsystem, "data:", err)
+				up = 0
+				failed = true
+			}
+		}
+		if failed {
+			continue // skip the view rather than publish stale values
+		}
+		writeView(bw, v)
+	}
+	fmt.Fprintf(bw, "# HELP %sup 1 if the data of all the views was collected, otherwise 0\n", prefix)
+	fmt.Fprintf(bw, "# TYPE %sup gauge\n", prefix)
+	fmt.Fprintf(bw, "%sup %d\n", prefix, up)
+
+	return bw.Flush()
+}
+
+// metric holds the name of the metric generated from a record field
+type metric struct {
+	field   string  // name of the record field
+	name    string  // full name of the metric
+	help    string  // help text
+	kind    string  // counter or gauge
+	divisor float64 // convert the raw value to the metric's unit
+}
+
+// newMetric returns the metric generated for the field of the view
+func newMetric(v View, field string) metric {
+	m := metric{field: field, kind: "counter", divisor: 1}
+	name := field
+	unit := ""
+
+	if strings.HasSuffix(field, "_ps") {
+		name = strings.TrimSuffix(field, "_ps") + "_seconds"
+		m.divisor = picoseconds
+		unit = " in seconds"
+	}
+	if v.isGauge(field) {
+		m.kind = "gauge"
+	} else {
+		name += "_total"
+	}
+	m.name = prefix + v.Subsystem + "_" + name
+	m.help = fmt.Sprintf("%s %s by %s%s", v.Subsystem, strings.Replace(strings.TrimSuffix(field, "_ps"), "_", " ", -1), v.Label, unit)
+
+	return m
+}
+
+// writeView writes the metrics of a view grouping the samples by metric
+func writeView(w io.Writer, v View) {
+	records := v.Tabler.Records()
+	if len(records) == 0 {
+		return
+	}
+
+	for _, field := range records[0].Names() {
+		if field == "name" {
+			continue
+		}
+		m := newMetric(v, field)
+		fmt.Fprintf(w, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.kind)
+
+		for _, r := range records {
+			if !r.HasData() {
+				continue
+			}
+			name, value, ok := fields(r, field)
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(w, "%s{%s=\"%s\"} %s\n", m.name, v.Label, escape(name), formatValue(value, m.divisor))
+		}
+	}
+}
+
+// fields returns the name of the record and the value of the given field
+func fields(r record.Record, field string) (string, interface{}, bool) {
+	var (
+		name  string
+		value interface{}
+		found bool
+	)
+	for i := range r {
+		switch r[i].Name {
+		case "name":
+			name = fmt.Sprint(r[i].Value)
+		case field:
+			value, found = r[i].Value, true
+		}
+	}
+	return name, value, found
+}
+
+// formatValue returns the value as a string in the metric's unit
+func formatValue(value interface{}, divisor float64) string {
+	switch v := value.(type) {
+	case uint64:
+		if divisor != 1 {
+			return fmt.Sprint(float64(v) / divisor)
+		}
+		return fmt.Sprint(v)
+	case int64:
+		if divisor != 1 {
+			return fmt.Sprint(float64(v) / divisor)
+		}
+		return fmt.Sprint(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// escape escapes a label value as required by the text exposition format
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,135 @@
+package exporter
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sjmudd/ps-top/ps_table"
+	"github.com/sjmudd/ps-top/record"
+)
+
+// fakeTabler provides fixed records. Only the methods used by the
+// exporter are implemented.
+type fakeTabler struct {
+	ps_table.Tabler
+	collected int
+	err       error
+	records   []record.Record
+}
+
+func (f *fakeTabler) Collect() error           { f.collected++; return f.err }
+func (f *fakeTabler) Records() []record.Record { return f.records }
+
+func TestExporter(t *testing.T) {
+	tables := &fakeTabler{
+		records: []record.Record{
+			{{Name: "name", Value: `db."t1"`}, {Name: "latency_ps", Value: uint64(2500000000000)}, {Name: "ops", Value: uint64(3)}},
+			{{Name: "name", Value: "db.t2"}, {Name: "latency_ps", Value: uint64(0)}, {Name: "ops", Value: uint64(0)}},
+		},
+	}
+	memory := &fakeTabler{
+		records: []record.Record{
+			{{Name: "name", Value: "memory/sql/THD"}, {Name: "current_bytes", Value: int64(-10)}, {Name: "ops", Value: int64(7)}},
+		},
+	}
+	server := httptest.NewServer(NewExporter(
+		View{Subsystem: "table_io", Label: "table", Tabler: tables},
+		View{Subsystem: "memory", Label: "event", Tabler: memory, Gauges: []string{"current_bytes"}},
+	))
+	defer server.Close()
+
+	response, body := scrape(t, server.URL)
+	if got := response.Header.Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type: expected %q, got %q", ContentType, got)
+	}
+	if tables.collected != 1 || memory.collected != 1 {
+		t.Errorf("Collect() expected to be called once per scrape, got %d and %d", tables.collected, memory.collected)
+	}
+
+	expected := []string{
+		"# TYPE pstop_table_io_latency_seconds_total counter",
+		`pstop_table_io_latency_seconds_total{table="db.\"t1\""} 2.5`,
+		"# TYPE pstop_table_io_ops_total counter",
+		`pstop_table_io_ops_total{table="db.\"t1\""} 3`,
+		"# TYPE pstop_memory_current_bytes gauge",
+		`pstop_memory_current_bytes{event="memory/sql/THD"} -10`,
+		`pstop_memory_ops_total{event="memory/sql/THD"} 7`,
+		"pstop_up 1",
+	}
+	for _, line := range expected {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("expected line %q not found in:\n%s", line, body)
+		}
+	}
+	if strings.Contains(string(body), "db.t2") {
+		t.Errorf("rows without data should not be exported:\n%s", body)
+	}
+}
+
+func TestExporterCollectError(t *testing.T) {
+	tables := &fakeTabler{
+		err: errors.New("lost connection"),
+		records: []record.Record{
+			{{Name: "name", Value: "db.t1"}, {Name: "latency_ps", Value: uint64(1)}},
+		},
+	}
+	server := httptest.NewServer(NewExporter(View{Subsystem: "table_io", Label: "table", Tabler: tables}))
+	defer server.Close()
+
+	_, body := scrape(t, server.URL)
+	if strings.Contains(string(body), "db.t1") {
+		t.Errorf("views which could not be collected should not be exported:\n%s", body)
+	}
+	if !strings.Contains(string(body), "pstop_up 0\n") {
+		t.Errorf("expected pstop_up 0 in:\n%s", body)
+	}
+}
+
+func TestExporterShared(t *testing.T) {
+	latency := &fakeTabler{
+		records: []record.Record{{{Name: "name", Value: "db.t1"}, {Name: "latency_ps", Value: uint64(1)}}},
+	}
+	ops := &fakeTabler{
+		records: []record.Record{{{Name: "name", Value: "db.t1"}, {Name: "ops", Value: uint64(2)}}},
+	}
+	server := httptest.NewServer(NewExporter(
+		View{Subsystem: "table_io", Label: "table", Tabler: latency},
+		View{Subsystem: "table_io", Label: "table", Tabler: ops, Shared: true},
+	))
+	defer server.Close()
+
+	_, body := scrape(t, server.URL)
+	if latency.collected != 1 || ops.collected != 0 {
+		t.Errorf("Collect() expected to be called once for the shared data, got %d and %d", latency.collected, ops.collected)
+	}
+	if !strings.Contains(string(body), `pstop_table_io_ops_total{table="db.t1"} 2`+"\n") {
+		t.Errorf("expected the shared view to be exported:\n%s", body)
+	}
+
+	// if the shared data can not be collected neither view is exported
+	latency.err = errors.New("lost connection")
+	_, body = scrape(t, server.URL)
+	if strings.Contains(string(body), "db.t1") {
+		t.Errorf("views which could not be collected should not be exported:\n%s", body)
+	}
+}
+
+// scrape returns the response and body of a request for the metrics
+func scrape(t *testing.T, url string) (*http.Response, []byte) {
+	t.Helper()
+	response, err := http.Get(url + "/metrics")
+	if err != nil {
+		t.Fatalf("http.Get() failed: %v", err)
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("reading the response failed: %v", err)
+	}
+
+	return response, body
+}
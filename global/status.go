@@ -2,30 +2,29 @@
 package global
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 
+	"github.com/sjmudd/ps-top/capabilities"
 	"github.com/sjmudd/ps-top/logger"
 )
 
-func selectStatusFrom(seenError bool) string {
-	if !seenError {
-		return "INFORMATION_SCHEMA.GLOBAL_STATUS"
-	}
-	return "performance_schema.global_status"
-}
-
 // really just stores the handle but we don't show that. Could cache stuff later maybe?
 type Status struct {
-	dbh *sql.DB
+	dbh   *sql.DB
+	table string
 }
 
-// NewStatus returns a *Status structure to the user
-func NewStatus(dbh *sql.DB) *Status {
+// NewStatus returns a *Status structure to the user, reading the status
+// from the table the server's capabilities say to use.
+func NewStatus(dbh *sql.DB, caps capabilities.Capabilities) *Status {
 	if dbh == nil {
 		logger.Fatal("NewStatus() dbh is nil")
 	}
 	s := new(Status)
 	s.dbh = dbh
+	s.table = caps.StatusTable()
 
 	return s
 }
@@ -40,26 +39,21 @@ func NewStatus(dbh *sql.DB) *Status {
 * 1 row in set (0.00 sec)
 **/
 
-// Get returns the value of the variable name requested (if found), or if not an error
-// - note: we assume we have checked a variable first as there's no logic here to switch between I_S and P_S
-func (status *Status) Get(name string) int {
+// Get returns the value of the variable name requested (if found), or if not an error.
+// The query is cancelled if ctx is done first.
+func (status *Status) Get(ctx context.Context, name string) (int, error) {
 	var value int
 
-	query := "SELECT VARIABLE_VALUE from " + selectStatusFrom(seenCompatibiltyError) + " WHERE VARIABLE_NAME = ?"
+	query := "SELECT VARIABLE_VALUE from " + status.table + " WHERE VARIABLE_NAME = ?"
 
-	err := status.dbh.QueryRow(query, name).Scan(&value)
+	err := status.dbh.QueryRowContext(ctx, query, name).Scan(&value)
 	switch {
 	case err == sql.ErrNoRows:
 		logger.Println("global.SelectStatusByName(" + name + "): no status with this name")
+		return 0, fmt.Errorf("no status variable %s", name)
 	case err != nil:
-		logger.Fatal(err)
-	default:
-		// fmt.Println("value for", name, "is", value)
-	}
-
-	if err != nil {
-		logger.Fatal("Unable to retrieve status for '"+name+"':", err)
+		return 0, fmt.Errorf("unable to retrieve status for %s: %v", name, err)
 	}
 
-	return value
+	return value, nil
 }
@@ -3,42 +3,33 @@ package global
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"strings"
 
+	"github.com/sjmudd/ps-top/capabilities"
+	"github.com/sjmudd/ps-top/datasource"
 	"github.com/sjmudd/ps-top/logger"
 )
 
-const (
-	showCompatibility56Error    = "Error 3167: The 'INFORMATION_SCHEMA.GLOBAL_VARIABLES' feature is disabled; see the documentation for 'show_compatibility_56'"
-	globalVariablesNotInISError = "Error 1109: Unknown table 'GLOBAL_VARIABLES' in information_schema"
-)
-
-// We expect to use I_S to query Global Variables. 5.7 now wants us to use P_S,
-// so this variable will be changed if we see the show_compatibility_56 error message
-var seenCompatibiltyError = false
-
-func selectVariablesFrom(seenError bool) string {
-	if !seenError {
-		return "INFORMATION_SCHEMA.GLOBAL_VARIABLES"
-	}
-	return "performance_schema.global_variables"
-}
-
 // Variables holds the handle and variables collected from the database
 type Variables struct {
 	dbh       *sql.DB
+	table     string
 	variables map[string]string
 }
 
 // NewVariables returns a pointer to an initialised Variables structure
-func NewVariables(dbh *sql.DB) *Variables {
-	if dbh == nil {
-		logger.Fatal("NewVariables(): dbh == nil")
+// with the variables collected from the given source.  dbh is only
+// used (and must be set) if the source collects from MySQL.  The
+// server's capabilities determine which table the variables are read from.
+func NewVariables(source datasource.Source, dbh *sql.DB, caps capabilities.Capabilities) (*Variables, error) {
+	v := &Variables{dbh: dbh, table: caps.VariablesTable()}
+	if _, err := source.Collect("variables", &v.variables, v.selectAll); err != nil {
+		return nil, err
 	}
-	v := &Variables{dbh: dbh}
-	v.selectAll()
 
-	return v
+	return v, nil
 }
 
 // Get returns the value of the given variable
@@ -55,25 +46,18 @@ func (v Variables) Get(key string) string {
 
 // selectAll() collects all variables from the database and stores for later use.
 // - all returned keys are lower-cased.
-func (v *Variables) selectAll() {
+func (v *Variables) selectAll() error {
+	if v.dbh == nil {
+		return errors.New("Variables.selectAll(): dbh == nil")
+	}
 	hashref := make(map[string]string)
 
-	query := "SELECT VARIABLE_NAME, VARIABLE_VALUE FROM " + selectVariablesFrom(seenCompatibiltyError)
+	query := "SELECT VARIABLE_NAME, VARIABLE_VALUE FROM " + v.table
 	logger.Println("query:", query)
 
 	rows, err := v.dbh.Query(query)
 	if err != nil {
-		if !seenCompatibiltyError && (err.Error() == showCompatibility56Error || err.Error() == globalVariablesNotInISError) {
-			logger.Println("selectAll() I_S query failed, trying with P_S")
-			seenCompatibiltyError = true
-			query = "SELECT VARIABLE_NAME, VARIABLE_VALUE FROM " + selectVariablesFrom(seenCompatibiltyError)
-			logger.Println("query:", query)
-
-			rows, err = v.dbh.Query(query)
-		}
-		if err != nil {
-			logger.Fatal("selectAll() query failed with:", err)
-		}
+		return fmt.Errorf("unable to collect global variables: %v", err)
 	}
 	logger.Println("selectAll() query succeeded")
 	defer rows.Close()
@@ -81,14 +65,16 @@ func (v *Variables) selectAll() {
 	for rows.Next() {
 		var variable, value string
 		if err := rows.Scan(&variable, &value); err != nil {
-			logger.Fatal(err)
+			return err
 		}
 		hashref[strings.ToLower(variable)] = value
 	}
 	if err := rows.Err(); err != nil {
-		logger.Fatal(err)
+		return err
 	}
 	logger.Println("selectAll() result has", len(hashref), "rows")
 
 	v.variables = hashref
+
+	return nil
 }
@@ -0,0 +1,50 @@
+package lib
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/sjmudd/anonymiser"
+)
+
+// anonymiserMu serialises the use of the anonymiser, which is not safe
+// for concurrent use, as the views may be collected in the background.
+var anonymiserMu sync.Mutex
+
+// Anonymise returns the anonymised name in the given group, or the
+// name if anonymising is not enabled.
+func Anonymise(group, name string) string {
+	anonymiserMu.Lock()
+	defer anonymiserMu.Unlock()
+
+	return anonymiser.Anonymise(group, name)
+}
+
+// AnonymiseEnabled returns true if names are being anonymised
+func AnonymiseEnabled() bool {
+	anonymiserMu.Lock()
+	defer anonymiserMu.Unlock()
+
+	return anonymiser.Enabled()
+}
+
+// EnableAnonymise enables or disables anonymising names
+func EnableAnonymise(enable bool) {
+	anonymiserMu.Lock()
+	defer anonymiserMu.Unlock()
+
+	anonymiser.Enable(enable)
+}
+
+// AnonymiseStatement returns the statement, or only its first word if
+// anonymising is enabled as the rest may contain names and literal values.
+func AnonymiseStatement(statement string) string {
+	if !AnonymiseEnabled() {
+		return statement
+	}
+	words := strings.Fields(statement)
+	if len(words) > 1 {
+		return words[0] + " ..."
+	}
+	return strings.Join(words, " ")
+}
@@ -7,8 +7,6 @@ import (
 	"os"
 	"regexp"
 	"strconv"
-
-	"github.com/sjmudd/anonymiser"
 )
 
 const (
@@ -200,6 +198,15 @@ func Divide(a uint64, b uint64) float64 {
 	return float64(a) / float64(b)
 }
 
+// PerSecond returns the value divided by the number of seconds given
+// rounded to the nearest integer, or 0 if the number of seconds is not positive.
+func PerSecond(value uint64, seconds float64) uint64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return uint64(math.Round(float64(value) / seconds))
+}
+
 // SignedDivide divides a by b except if b is 0 in which case we return 0.
 func SignedDivide(a int64, b int64) float64 {
 	if b == 0 {
@@ -237,8 +244,8 @@ func Uptime(uptime int) string {
 
 // TableName returns the table name from the columns as '<schema>.<table>'
 func TableName(schema, table string) string {
-	schema = anonymiser.Anonymise("schema", schema)
-	table = anonymiser.Anonymise("table", table)
+	schema = Anonymise("schema", schema)
+	table = Anonymise("table", table)
 
 	var name string
 	if len(schema) > 0 {
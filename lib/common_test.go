@@ -31,3 +31,46 @@ func TestFormatTime(t *testing.T) {
 		}
 	}
 }
+
+func TestPerSecond(t *testing.T) {
+	var tests = []struct {
+		value    uint64
+		seconds  float64
+		expected uint64
+	}{
+		{0, 10, 0},
+		{100, 0, 0},
+		{100, -1, 0},
+		{100, 10, 10},
+		{15, 10, 2},
+		{14, 10, 1},
+		{1000000000000, 0.5, 2000000000000},
+	}
+	for _, test := range tests {
+		if got := PerSecond(test.value, test.seconds); got != test.expected {
+			t.Errorf("PerSecond(%v,%v) expected to be %v but actually was %v", test.value, test.seconds, test.expected, got)
+		}
+	}
+}
+
+func TestAnonymiseStatement(t *testing.T) {
+	defer EnableAnonymise(AnonymiseEnabled())
+
+	var tests = []struct {
+		statement string
+		anonymise bool
+		expected  string
+	}{
+		{"SELECT * FROM t1 WHERE c = 'secret'", false, "SELECT * FROM t1 WHERE c = 'secret'"},
+		{"SELECT *\n  FROM t1 WHERE c = 'secret'", true, "SELECT ..."},
+		{" COMMIT", true, "COMMIT"},
+		{"", true, ""},
+	}
+
+	for _, test := range tests {
+		EnableAnonymise(test.anonymise)
+		if got := AnonymiseStatement(test.statement); got != test.expected {
+			t.Errorf("AnonymiseStatement(%q) with anonymise %v: expected %q, got %q", test.statement, test.anonymise, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package blocking contains the library routines for managing the
+// metadata and InnoDB lock waits.
+package blocking
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sjmudd/ps-top/baseobject"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Blocking holds the threads currently waiting for locks
+type Blocking struct {
+	baseobject.BaseObject
+	Results Rows // current lock waits
+	Totals  Row  // totals of results
+	source  Source
+}
+
+// NewBlocking returns a Blocking collecting data from MySQL using the given db handle
+func NewBlocking(ctx *context.Context, db *sql.DB) *Blocking {
+	return NewBlockingWithSource(ctx, NewMySQLSource(db, ctx.Capabilities()))
+}
+
+// NewBlockingWithSource returns a Blocking collecting data from the given source
+func NewBlockingWithSource(ctx *context.Context, source Source) *Blocking {
+	logger.Println("NewBlocking()")
+	b := &Blocking{
+		source: source,
+	}
+	b.SetContext(ctx)
+
+	return b
+}
+
+// Collect collects the current lock waits and determines the thread
+// at the start of each wait chain.  Lock waits are not counters so
+// there are no relative values.
+func (b *Blocking) Collect() error {
+	start := time.Now()
+	var collected Rows
+	if err := b.CollectFrom("blocking", &collected, func() (err error) {
+		collected, err = b.source.Collect(b.QueryContext())
+		return err
+	}, func() {
+		collected.setRootBlockers()
+		b.Results = collected
+		b.Totals = b.Results.totals()
+		logger.Println("Blocking.Collect() collected", len(b.Results), "lock wait(s), took:", time.Duration(time.Since(start)).String())
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Blockers returns the number of threads at the start of a wait chain
+func (b Blocking) Blockers() int {
+	roots := make(map[uint64]bool)
+	for i := range b.Results {
+		roots[b.Results[i].RootBlockingID] = true
+	}
+	return len(roots)
+}
+
+// HaveRelativeStats returns if we have relative information
+func (b Blocking) HaveRelativeStats() bool {
+	return false
+}
+
+// SetFirstFromLast - NOT IMPLEMENTED
+func (b *Blocking) SetFirstFromLast() {
+	logger.Println("blocking.Blocking.SetFirstFromLast() NOT IMPLEMENTED")
+}
@@ -0,0 +1,97 @@
+package blocking
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sjmudd/ps-top/capabilities"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+func newTestContext() *context.Context {
+	return context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+}
+
+func TestNewMySQLSource(t *testing.T) {
+	var tests = []struct {
+		version  string
+		expected bool
+	}{
+		{"5.7.32-log", true},
+		{"8.0.22", false},
+		{"10.5.8-MariaDB", true},
+	}
+
+	for _, test := range tests {
+		if got := NewMySQLSource(nil, capabilities.New(test.version, "")).mysql57; got != test.expected {
+			t.Errorf("NewMySQLSource(%q): expected mysql57 %v, got %v", test.version, test.expected, got)
+		}
+	}
+}
+
+func TestSingleLine(t *testing.T) {
+	if got := singleLine("UPDATE t\n\tSET a = 1\n WHERE id = 2"); got != "UPDATE t SET a = 1 WHERE id = 2" {
+		t.Errorf("singleLine(): got %q", got)
+	}
+}
+
+func TestObject(t *testing.T) {
+	if got := (Row{Name: "db.t"}).Object(); got != "db.t" {
+		t.Errorf("Object() without index: got %q", got)
+	}
+	if got := (Row{Name: "db.t", Index: "PRIMARY"}).Object(); got != "db.t (PRIMARY)" {
+		t.Errorf("Object() with index: got %q", got)
+	}
+}
+
+func TestSetRootBlockers(t *testing.T) {
+	rows := Rows{
+		{WaitingID: 3, BlockingID: 2},
+		{WaitingID: 2, BlockingID: 1},
+		{WaitingID: 4, BlockingID: 1},
+		{WaitingID: 5, BlockingID: 6}, // 5 and 6 wait for each other
+		{WaitingID: 6, BlockingID: 5},
+	}
+	rows.setRootBlockers()
+
+	expected := []uint64{1, 1, 1, 6, 5}
+	for i := range rows {
+		if rows[i].RootBlockingID != expected[i] {
+			t.Errorf("setRootBlockers(): thread %d: expected root %d, got %d", rows[i].WaitingID, expected[i], rows[i].RootBlockingID)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	source := &MemorySource{
+		Rows: Rows{
+			{Name: "db.t", Kind: innodbLock, WaitingID: 12, BlockingID: 11, WaitAge: 5},
+			{Name: "db.t", Kind: metadataLock, WaitingID: 13, BlockingID: 12, WaitAge: 2},
+		},
+	}
+	b := NewBlockingWithSource(newTestContext(), source)
+	if err := b.Collect(); err != nil {
+		t.Fatalf("Collect(): unexpected error: %v", err)
+	}
+
+	if len(b.Results) != 2 {
+		t.Fatalf("Collect(): expected 2 rows, got %v", b.Results)
+	}
+	if b.Results[1].RootBlockingID != 11 {
+		t.Errorf("Collect(): expected thread 13 to be blocked by 11, got %d", b.Results[1].RootBlockingID)
+	}
+	if b.Totals.WaitAge != 5 {
+		t.Errorf("Collect(): expected the oldest wait to be 5, got %d", b.Totals.WaitAge)
+	}
+	if b.Blockers() != 1 {
+		t.Errorf("Blockers(): expected 1, got %d", b.Blockers())
+	}
+
+	source.Err = errors.New("collection failed")
+	if err := b.Collect(); err == nil {
+		t.Errorf("Collect(): expected an error")
+	}
+}
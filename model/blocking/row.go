@@ -0,0 +1,26 @@
+// Package blocking contains the library routines for managing the
+// metadata and InnoDB lock waits.
+package blocking
+
+// Row contains a thread waiting for a lock held by another thread
+type Row struct {
+	Name             string // object being waited for, e.g. db.table
+	Index            string // InnoDB index being waited for ("" for metadata locks)
+	Kind             string // kind of lock: metadata or innodb
+	WaitingID        uint64 // processlist id of the waiting thread
+	BlockingID       uint64 // processlist id of the thread holding the lock
+	RootBlockingID   uint64 // processlist id of the thread at the start of the wait chain
+	LockType         string // type of lock being waited for
+	BlockingLockType string // type of lock being held
+	WaitAge          uint64 // seconds the thread has been waiting
+	WaitingQuery     string // statement which is waiting
+	BlockingQuery    string // statement of the blocking thread (if it is running one)
+}
+
+// Object returns the object being waited for including any index
+func (row Row) Object() string {
+	if row.Index == "" {
+		return row.Name
+	}
+	return row.Name + " (" + row.Index + ")"
+}
@@ -0,0 +1,162 @@
+// Package blocking contains the library routines for managing the
+// metadata and InnoDB lock waits.
+package blocking
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/logger"
+	"github.com/sjmudd/ps-top/rc"
+)
+
+// Rows contains a slice of Row
+type Rows []Row
+
+// Kinds of lock waited for
+const (
+	metadataLock = "metadata"
+	innodbLock   = "innodb"
+)
+
+// Metadata lock waits exist in 5.7 and 8.0.  A pending lock is shown
+// as waiting for each lock granted to another thread on the same table.
+const metadataQuery = `-- blocking (metadata)
+SELECT	p.OBJECT_SCHEMA,
+	p.OBJECT_NAME,
+	'',
+	IFNULL(wt.PROCESSLIST_ID, 0),
+	IFNULL(bt.PROCESSLIST_ID, 0),
+	p.LOCK_TYPE,
+	g.LOCK_TYPE,
+	IFNULL(wt.PROCESSLIST_TIME, 0),
+	IFNULL(wt.PROCESSLIST_INFO, ''),
+	IFNULL(bt.PROCESSLIST_INFO, '')
+FROM	performance_schema.metadata_locks p
+JOIN	performance_schema.metadata_locks g ON g.OBJECT_TYPE = p.OBJECT_TYPE AND g.OBJECT_SCHEMA = p.OBJECT_SCHEMA AND g.OBJECT_NAME = p.OBJECT_NAME AND g.LOCK_STATUS = 'GRANTED' AND g.OWNER_THREAD_ID <> p.OWNER_THREAD_ID
+JOIN	performance_schema.threads wt ON wt.THREAD_ID = p.OWNER_THREAD_ID
+JOIN	performance_schema.threads bt ON bt.THREAD_ID = g.OWNER_THREAD_ID
+WHERE	p.LOCK_STATUS = 'PENDING'
+AND	p.OBJECT_TYPE = 'TABLE'`
+
+// MySQL 8.0 shows InnoDB lock waits in performance_schema.
+const innodbQuery = `-- blocking (innodb)
+SELECT	rl.OBJECT_SCHEMA,
+	rl.OBJECT_NAME,
+	IFNULL(rl.INDEX_NAME, ''),
+	IFNULL(wt.PROCESSLIST_ID, 0),
+	IFNULL(bt.PROCESSLIST_ID, 0),
+	rl.LOCK_MODE,
+	bl.LOCK_MODE,
+	IFNULL(TIMESTAMPDIFF(SECOND, r.trx_wait_started, NOW()), 0),
+	IFNULL(wt.PROCESSLIST_INFO, ''),
+	IFNULL(bt.PROCESSLIST_INFO, '')
+FROM	performance_schema.data_lock_waits w
+JOIN	performance_schema.data_locks rl ON rl.ENGINE_LOCK_ID = w.REQUESTING_ENGINE_LOCK_ID
+JOIN	performance_schema.data_locks bl ON bl.ENGINE_LOCK_ID = w.BLOCKING_ENGINE_LOCK_ID
+JOIN	performance_schema.threads wt ON wt.THREAD_ID = w.REQUESTING_THREAD_ID
+JOIN	performance_schema.threads bt ON bt.THREAD_ID = w.BLOCKING_THREAD_ID
+LEFT JOIN information_schema.innodb_trx r ON r.trx_id = w.REQUESTING_ENGINE_TRANSACTION_ID`
+
+// MySQL 5.7 shows InnoDB lock waits in information_schema where the
+// table is given as `db`.`table`.
+const innodbQuery57 = `-- blocking (innodb 5.7)
+SELECT	SUBSTRING_INDEX(REPLACE(rl.lock_table, '` + "`" + `', ''), '.', 1),
+	SUBSTRING_INDEX(REPLACE(rl.lock_table, '` + "`" + `', ''), '.', -1),
+	IFNULL(rl.lock_index, ''),
+	r.trx_mysql_thread_id,
+	b.trx_mysql_thread_id,
+	rl.lock_mode,
+	bl.lock_mode,
+	IFNULL(TIMESTAMPDIFF(SECOND, r.trx_wait_started, NOW()), 0),
+	IFNULL(r.trx_query, ''),
+	IFNULL(b.trx_query, '')
+FROM	information_schema.innodb_lock_waits w
+JOIN	information_schema.innodb_trx r ON r.trx_id = w.requesting_trx_id
+JOIN	information_schema.innodb_trx b ON b.trx_id = w.blocking_trx_id
+JOIN	information_schema.innodb_locks rl ON rl.lock_id = w.requested_lock_id
+JOIN	information_schema.innodb_locks bl ON bl.lock_id = w.blocking_lock_id`
+
+// totals returns the number of waits and the oldest wait
+func (rows Rows) totals() Row {
+	var totals Row
+	totals.Name = "Totals"
+
+	for i := range rows {
+		if rows[i].WaitAge > totals.WaitAge {
+			totals.WaitAge = rows[i].WaitAge
+		}
+	}
+
+	return totals
+}
+
+// setRootBlockers follows the wait chain of each row to the thread
+// which is not waiting itself, stopping if the chain loops.
+func (rows Rows) setRootBlockers() {
+	blockedBy := make(map[uint64]uint64)
+	for i := range rows {
+		if _, ok := blockedBy[rows[i].WaitingID]; !ok {
+			blockedBy[rows[i].WaitingID] = rows[i].BlockingID
+		}
+	}
+
+	for i := range rows {
+		root := rows[i].BlockingID
+		seen := map[uint64]bool{rows[i].WaitingID: true}
+		for {
+			next, ok := blockedBy[root]
+			if !ok || seen[next] {
+				break
+			}
+			seen[root] = true
+			root = next
+		}
+		rows[i].RootBlockingID = root
+	}
+}
+
+// singleLine returns the statement on one line so it can be displayed
+func singleLine(statement string) string {
+	return strings.Join(strings.Fields(statement), " ")
+}
+
+func collect(ctx context.Context, dbh *sql.DB, query, kind string) (Rows, error) {
+	var t Rows
+
+	logger.Println("Querying db:", query)
+	rows, err := dbh.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schema, table string
+		r := Row{Kind: kind}
+		if err := rows.Scan(
+			&schema,
+			&table,
+			&r.Index,
+			&r.WaitingID,
+			&r.BlockingID,
+			&r.LockType,
+			&r.BlockingLockType,
+			&r.WaitAge,
+			&r.WaitingQuery,
+			&r.BlockingQuery); err != nil {
+			return nil, err
+		}
+		r.Name = rc.Munge(lib.TableName(schema, table))
+		r.WaitingQuery = lib.AnonymiseStatement(singleLine(r.WaitingQuery))
+		r.BlockingQuery = lib.AnonymiseStatement(singleLine(r.BlockingQuery))
+		t = append(t, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
@@ -0,0 +1,63 @@
+package blocking
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sjmudd/ps-top/capabilities"
+)
+
+// Source provides the metadata and InnoDB lock waits
+type Source interface {
+	Collect(ctx context.Context) (Rows, error)
+}
+
+// MySQLSource is the default Source which collects the rows from MySQL
+type MySQLSource struct {
+	db      *sql.DB
+	mysql57 bool // true if the MySQL 5.7 InnoDB query must be used
+}
+
+// NewMySQLSource returns a Source collecting the rows using the given db
+// handle, using the MySQL 5.7 InnoDB query if the server does not have the
+// MySQL 8.0 data lock tables.
+func NewMySQLSource(db *sql.DB, caps capabilities.Capabilities) *MySQLSource {
+	return &MySQLSource{db: db, mysql57: !caps.Has(capabilities.DataLocks)}
+}
+
+// Collect returns the metadata lock waits followed by the InnoDB lock waits.
+func (s *MySQLSource) Collect(ctx context.Context) (Rows, error) {
+	rows, err := collect(ctx, s.db, metadataQuery, metadataLock)
+	if err != nil {
+		return nil, err
+	}
+
+	query := innodbQuery
+	if s.mysql57 {
+		query = innodbQuery57
+	}
+	innodb, err := collect(ctx, s.db, query, innodbLock)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(rows, innodb...), nil
+}
+
+// MemorySource is a Source holding the rows to return in memory which
+// is useful for testing. If Err is set it is returned instead of the rows.
+type MemorySource struct {
+	Rows Rows
+	Err  error
+}
+
+// Collect returns a copy of the rows held in memory.
+func (s *MemorySource) Collect(ctx context.Context) (Rows, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	rows := make(Rows, len(s.Rows))
+	copy(rows, s.Rows)
+
+	return rows, nil
+}
@@ -0,0 +1,121 @@
+package custom
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/sjmudd/ps-top/baseobject"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Custom holds the rows collected by a custom view
+type Custom struct {
+	baseobject.BaseObject            // embedded
+	definition            Definition // what to collect and show
+	first                 Rows       // initial data for relative values
+	last                  Rows       // last loaded values
+	Results               Rows       // results (maybe with subtraction)
+	Totals                Row        // totals of results
+	source                Source
+}
+
+// NewCustom returns a Custom collecting data from MySQL using the given db handle
+func NewCustom(ctx *context.Context, db *sql.DB, definition Definition) *Custom {
+	return NewCustomWithSource(ctx, NewMySQLSource(db, definition), definition)
+}
+
+// NewCustomWithSource returns a Custom collecting the data of the
+// given definition from the given source
+func NewCustomWithSource(ctx *context.Context, source Source, definition Definition) *Custom {
+	logger.Println("NewCustom(", definition.Name, ")")
+	if ctx == nil {
+		log.Println("NewCustom() ctx == nil!")
+	}
+	c := &Custom{
+		definition: definition,
+		source:     source,
+	}
+	c.SetContext(ctx)
+
+	return c
+}
+
+// Definition returns the definition of the view
+func (c *Custom) Definition() Definition {
+	return c.definition
+}
+
+func (c *Custom) updateFirstFromLast() {
+	c.first = c.last.clone()
+	c.SetFirstCollectTime(c.LastCollectTime())
+}
+
+// Collect collects data from the db, updating first
+// values if needed, and then subtracting first values if we want
+// relative values, after which it stores totals.
+func (c *Custom) Collect() error {
+	start := time.Now()
+	columns := c.definition.Columns
+	var collected Rows
+	if err := c.CollectFrom("view:"+c.definition.Name, &collected, func() (err error) {
+		collected, err = c.source.Collect(c.QueryContext())
+		return err
+	}, func() {
+		if c.Compensate(counterRows{rows: &collected, columns: columns}) {
+			logger.Println("Custom.Collect()", c.definition.Name, "counter reset detected")
+			c.SetTruncated(c.LastCollectTime())
+		}
+		c.last = collected
+
+		logger.Println("t.current collected", len(c.last), "row(s) from SELECT")
+
+		if len(c.first) == 0 && len(c.last) > 0 {
+			logger.Println("c.first: copying from c.last (initial setup)")
+			c.updateFirstFromLast()
+		}
+
+		// check for reload initial characteristics
+		if c.first.needsRefresh(c.last, columns) {
+			logger.Println("c.first: copying from c.last (data needs refreshing)")
+			c.updateFirstFromLast()
+			c.ResetSnapshots()
+		}
+		c.AddSnapshot(c.last)
+
+		c.makeResults()
+	}); err != nil {
+		return err
+	}
+	logger.Println("Custom.Collect() END, took:", time.Duration(time.Since(start)).String())
+
+	return nil
+}
+
+// makeResults subtracts the baseline from the counters, gauges keep
+// their current values, and only keeps the rows with a value.
+func (c *Custom) makeResults() {
+	results := c.last.clone()
+	if c.WantWindowStats() {
+		if baseline, ok := c.WindowBaseline(); ok {
+			results.subtract(baseline.(Rows), c.definition.Columns)
+		}
+	} else if c.WantRelativeStats() {
+		results.subtract(c.first, c.definition.Columns)
+	}
+
+	c.Results = results.withData()
+	c.Totals = c.Results.totals(c.definition.Columns)
+}
+
+// SetFirstFromLast resets the statistics to current values
+func (c *Custom) SetFirstFromLast() {
+	c.updateFirstFromLast()
+	c.makeResults()
+}
+
+// HaveRelativeStats is true for this object
+func (c Custom) HaveRelativeStats() bool {
+	return true
+}
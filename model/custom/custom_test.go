@@ -0,0 +1,132 @@
+package custom
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+func testDefinition(t *testing.T) Definition {
+	d, err := NewDefinition("io", "SELECT file, count_read, sum_timer_wait, current_bytes FROM t;",
+		[]string{"file"}, []string{"count_read", "sum_timer_wait"}, []string{"current_bytes"},
+		map[string]string{"sum_timer_wait": Time, "current_bytes": Bytes})
+	if err != nil {
+		t.Fatalf("NewDefinition(): unexpected error: %v", err)
+	}
+	return d
+}
+
+func TestNewDefinition(t *testing.T) {
+	d := testDefinition(t)
+	expected := Definition{
+		Name:  "io",
+		Query: "SELECT file, count_read, sum_timer_wait, current_bytes FROM t",
+		Keys:  []string{"file"},
+		Columns: []Column{
+			{Name: "count_read", Unit: Count},
+			{Name: "sum_timer_wait", Unit: Time},
+			{Name: "current_bytes", Gauge: true, Unit: Bytes},
+		},
+	}
+	if !reflect.DeepEqual(d, expected) {
+		t.Errorf("NewDefinition(): expected %+v, actual %+v", expected, d)
+	}
+	for _, query := range []string{"SELECT 1;", " SELECT 1 ; ", "SELECT 1;;\n"} {
+		if got := trimQuery(query); got != "SELECT 1" {
+			t.Errorf("trimQuery(%q): expected %q, got %q", query, "SELECT 1", got)
+		}
+	}
+
+	var errors = []struct {
+		name, query    string
+		keys, counters []string
+		units          map[string]string
+	}{
+		{"", "SELECT 1", []string{"a"}, []string{"b"}, nil},
+		{"bad-name", "SELECT 1", []string{"a"}, []string{"b"}, nil},
+		{"v", " ", []string{"a"}, []string{"b"}, nil},
+		{"v", "SELECT 1", nil, []string{"b"}, nil},
+		{"v", "SELECT 1", []string{"a"}, nil, nil},
+		{"v", "SELECT 1", []string{"a"}, []string{"b"}, map[string]string{"c": Time}},
+		{"v", "SELECT 1", []string{"a"}, []string{"b"}, map[string]string{"b": "furlongs"}},
+	}
+	for _, test := range errors {
+		if _, err := NewDefinition(test.name, test.query, test.keys, test.counters, nil, test.units); err == nil {
+			t.Errorf("NewDefinition(%q, %q, %v, %v, %v): expected an error", test.name, test.query, test.keys, test.counters, test.units)
+		}
+	}
+}
+
+func TestColumnIndexes(t *testing.T) {
+	d := testDefinition(t)
+
+	keys, values, err := columnIndexes([]string{"CURRENT_BYTES", "file", "other", "sum_timer_wait", "count_read"}, d)
+	if err != nil {
+		t.Fatalf("columnIndexes(): unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []int{1}) || !reflect.DeepEqual(values, []int{4, 3, 0}) {
+		t.Errorf("columnIndexes(): expected [1] [4 3 0], actual %v %v", keys, values)
+	}
+	if _, _, err := columnIndexes([]string{"file", "count_read"}, d); err == nil {
+		t.Errorf("columnIndexes(): expected an error for missing columns")
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	for value, expected := range map[string]uint64{
+		"":      0,
+		"12":    12,
+		"12.7":  12,
+		"-3":    0,
+		"hello": 0,
+	} {
+		if got := parseValue(value); got != expected {
+			t.Errorf("parseValue(%q): expected %d, actual %d", value, expected, got)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	ctx.SetWantRelativeStats(true)
+	source := &MemorySource{Rows: Rows{{"a", []uint64{10, 100, 5}}, {"b", []uint64{3, 30, 0}}}}
+	c := NewCustomWithSource(ctx, source, testDefinition(t))
+
+	c.Collect()
+	source.Rows = Rows{{"a", []uint64{15, 150, 7}}, {"b", []uint64{3, 30, 0}}}
+	c.Collect()
+
+	// the unchanged b is not shown and gauges are not subtracted
+	expected := Rows{{"a", []uint64{5, 50, 7}}}
+	if !reflect.DeepEqual(c.Results, expected) {
+		t.Errorf("Collect(): expected %v, actual %v", expected, c.Results)
+	}
+	if totals := (Row{"Totals", []uint64{5, 50, 7}}); !reflect.DeepEqual(c.Totals, totals) {
+		t.Errorf("Collect(): expected totals %v, actual %v", totals, c.Totals)
+	}
+
+	// the counters are reset, and the values collected before are kept
+	source.Rows = Rows{{"a", []uint64{1, 10, 8}}, {"b", []uint64{3, 30, 0}}}
+	c.Collect()
+	expected = Rows{{"a", []uint64{6, 60, 8}}}
+	if !reflect.DeepEqual(c.Results, expected) {
+		t.Errorf("Collect() after a reset: expected %v, actual %v", expected, c.Results)
+	}
+	if c.LastTruncation().IsZero() {
+		t.Errorf("Collect() after a reset: expected the truncation to be seen")
+	}
+
+	// the data collected is not changed by making the results
+	if c.last[0].Values[0] != 16 {
+		t.Errorf("Collect(): expected the last collected value to be 16, actual %d", c.last[0].Values[0])
+	}
+
+	c.SetFirstFromLast()
+	if len(c.Results) != 1 || c.Results[0].Values[2] != 8 {
+		t.Errorf("SetFirstFromLast(): expected only the gauge to be left, actual %v", c.Results)
+	}
+}
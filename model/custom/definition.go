@@ -0,0 +1,120 @@
+// Package custom contains the library routines for managing the views
+// defined by the user with their own SQL in ~/.pstoprc.
+package custom
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// validName matches the names a view may have, so it can be given on
+// the command line and used in the names of metrics
+var validName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// Units a column may be shown in
+const (
+	Count = "count" // a plain number, the default
+	Bytes = "bytes" // a number of bytes
+	Time  = "time"  // a time in picoseconds
+)
+
+// Column describes a numeric column of a custom view
+type Column struct {
+	Name  string
+	Gauge bool   // is the value a current level rather than a counter?
+	Unit  string // Count, Bytes or Time
+}
+
+// Definition describes a custom view: the query to run, the columns
+// identifying each row and the numeric columns to show.
+type Definition struct {
+	Name    string
+	Query   string
+	Keys    []string // columns identifying a row
+	Columns []Column // counters followed by gauges
+}
+
+// NewDefinition returns the definition of a custom view given the names
+// of its key, counter and gauge columns and the units of the columns
+// which are not a Count.
+func NewDefinition(name, query string, keys, counters, gauges []string, units map[string]string) (Definition, error) {
+	d := Definition{Name: name, Query: trimQuery(query), Keys: keys}
+
+	switch {
+	case d.Name == "":
+		return Definition{}, errors.New("custom view has no name")
+	case !validName.MatchString(d.Name):
+		return Definition{}, fmt.Errorf("custom view %q: the name may only contain letters, digits and underscores", name)
+	case d.Query == "":
+		return Definition{}, fmt.Errorf("custom view %s: no query", name)
+	case len(keys) == 0:
+		return Definition{}, fmt.Errorf("custom view %s: no key columns", name)
+	case len(counters)+len(gauges) == 0:
+		return Definition{}, fmt.Errorf("custom view %s: no counter or gauge columns", name)
+	}
+
+	add := func(columns []string, gauge bool) {
+		for _, column := range columns {
+			d.Columns = append(d.Columns, Column{Name: column, Gauge: gauge, Unit: Count})
+		}
+	}
+	add(counters, false)
+	add(gauges, true)
+
+	for column, unit := range units {
+		i := d.column(column)
+		if i < 0 {
+			return Definition{}, fmt.Errorf("custom view %s: unit given for unknown column %q", name, column)
+		}
+		switch unit {
+		case Count, Bytes, Time:
+			d.Columns[i].Unit = unit
+		default:
+			return Definition{}, fmt.Errorf("custom view %s: unknown unit %q for column %q. Try one of: %s %s %s", name, unit, column, Count, Bytes, Time)
+		}
+	}
+
+	return d, nil
+}
+
+// column returns the index of the named column or -1 if not found
+func (d Definition) column(name string) int {
+	for i := range d.Columns {
+		if d.Columns[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Check returns an error if the query fails or does not return the
+// columns we need. The query is run as it is, so SHOW statements work
+// too, and only its columns are looked at. The caller's context limits
+// how long the query may take.
+func (d Definition) Check(ctx context.Context, dbh *sql.DB) error {
+	rows, err := dbh.QueryContext(ctx, d.Query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if _, _, err := columnIndexes(names, d); err != nil {
+		return err
+	}
+
+	return rows.Close()
+}
+
+// trimQuery removes the space and any semicolons from the end of the
+// query as the driver does not accept them.
+func trimQuery(query string) string {
+	return strings.TrimRight(strings.TrimSpace(query), "; \t\r\n")
+}
@@ -0,0 +1,80 @@
+package custom
+
+import (
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Row contains the values of the numeric columns of a row collected
+// by a custom view, in the order of the definition's columns
+type Row struct {
+	Name   string // the values of the key columns
+	Values []uint64
+}
+
+// clone returns a copy of the row which does not share its values
+func (row Row) clone() Row {
+	values := make([]uint64, len(row.Values))
+	copy(values, row.Values)
+
+	return Row{Name: row.Name, Values: values}
+}
+
+// add the values of another row
+func (row *Row) add(other Row) {
+	for i := range row.Values {
+		if i < len(other.Values) {
+			row.Values[i] += other.Values[i]
+		}
+	}
+}
+
+// subtract the counter values of another row, gauges are left alone
+func (row *Row) subtract(other Row, columns []Column) {
+	for i := range row.Values {
+		if columns[i].Gauge || i >= len(other.Values) {
+			continue
+		}
+		// check for issues here (we have a bug) and log it
+		// - this situation should not happen so there's a logic bug somewhere else
+		if row.Values[i] >= other.Values[i] {
+			row.Values[i] -= other.Values[i]
+		} else {
+			logger.Println("WARNING: Row.subtract() - subtraction problem! (not subtracting)")
+			logger.Println("row=", row)
+			logger.Println("other=", other)
+		}
+	}
+}
+
+// counters returns the sum of the counter values
+func (row Row) counters(columns []Column) uint64 {
+	var sum uint64
+	for i := range row.Values {
+		if !columns[i].Gauge {
+			sum += row.Values[i]
+		}
+	}
+
+	return sum
+}
+
+// hasData returns true if any of the values are non-zero
+func (row Row) hasData() bool {
+	for i := range row.Values {
+		if row.Values[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// decreased returns true if a counter has gone down since the
+// previous value was collected so it has been reset.
+func (row Row) decreased(previous Row, columns []Column) bool {
+	for i := range row.Values {
+		if !columns[i].Gauge && i < len(previous.Values) && row.Values[i] < previous.Values[i] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package custom
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Rows contains a slice of Row
+type Rows []Row
+
+// clone returns a copy of the rows which does not share their values
+func (rows Rows) clone() Rows {
+	t := make(Rows, len(rows))
+	for i := range rows {
+		t[i] = rows[i].clone()
+	}
+
+	return t
+}
+
+// totals returns the sum of the values of all the rows
+func (rows Rows) totals(columns []Column) Row {
+	totals := Row{Name: "Totals", Values: make([]uint64, len(columns))}
+
+	for i := range rows {
+		totals.add(rows[i])
+	}
+
+	return totals
+}
+
+// collect runs the query of the definition and returns a row for each
+// row returned, taking the columns needed by name.
+func collect(ctx context.Context, dbh *sql.DB, d Definition) (Rows, error) {
+	var t Rows
+
+	logger.Println("Querying db:", d.Query)
+	rows, err := dbh.QueryContext(ctx, d.Query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	keys, values, err := columnIndexes(names, d)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]sql.NullString, len(names))
+	dest := make([]interface{}, len(names))
+	for i := range raw {
+		dest[i] = &raw[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		r := Row{Values: make([]uint64, len(values))}
+		var name []string
+		for _, i := range keys {
+			name = append(name, raw[i].String)
+		}
+		r.Name = strings.Join(name, " ")
+		for j, i := range values {
+			r.Values[j] = parseValue(raw[i].String)
+		}
+		t = append(t, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// columnIndexes returns the positions in the query's columns of the key
+// columns and the definition's numeric columns, ignoring case.
+func columnIndexes(names []string, d Definition) ([]int, []int, error) {
+	find := func(name string) (int, error) {
+		for i := range names {
+			if strings.EqualFold(names[i], name) {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("custom view %s: the query has no column %q", d.Name, name)
+	}
+
+	keys := make([]int, 0, len(d.Keys))
+	for _, key := range d.Keys {
+		i, err := find(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, i)
+	}
+	values := make([]int, 0, len(d.Columns))
+	for _, column := range d.Columns {
+		i, err := find(column.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		values = append(values, i)
+	}
+
+	return keys, values, nil
+}
+
+// parseValue returns the value of a numeric column. Decimals are
+// truncated and NULL, negative and non-numeric values are taken as 0.
+func parseValue(value string) uint64 {
+	if v, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return v
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil && f > 0 && f < math.MaxUint64 {
+		return uint64(f)
+	}
+	return 0
+}
+
+// withData returns the rows with a non-zero value
+func (rows Rows) withData() Rows {
+	var t Rows
+	for i := range rows {
+		if rows[i].hasData() {
+			t = append(t, rows[i])
+		}
+	}
+
+	return t
+}
+
+// remove the initial values from those rows where there's a match
+// - if we find a row we can't match ignore it
+func (rows *Rows) subtract(initial Rows, columns []Column) {
+	initialByName := make(map[string]int)
+
+	// iterate over rows by name
+	for i := range initial {
+		initialByName[initial[i].Name] = i
+	}
+
+	for i := range *rows {
+		name := (*rows)[i].Name
+		if initialIndex, ok := initialByName[name]; ok {
+			(*rows)[i].subtract(initial[initialIndex], columns)
+		}
+	}
+}
+
+// if the data in t2 is "newer", "has more values" than t then it needs refreshing.
+// check this by comparing the totals of the counters.
+func (rows Rows) needsRefresh(otherRows Rows, columns []Column) bool {
+	return rows.totals(columns).counters(columns) > otherRows.totals(columns).counters(columns)
+}
+
+// counterRows gives baseobject.Compensate access to the rows by name
+type counterRows struct {
+	rows    *Rows
+	columns []Column
+}
+
+func (c counterRows) Len() int              { return len(*c.rows) }
+func (c counterRows) Key(i int) string      { return (*c.rows)[i].Name }
+func (c counterRows) Row(i int) interface{} { return (*c.rows)[i].clone() }
+func (c counterRows) Decreased(i int, previous interface{}) bool {
+	return (*c.rows)[i].decreased(previous.(Row), c.columns)
+}
+func (c counterRows) Add(i int, offset interface{}) {
+	(*c.rows)[i].add(counters(offset.(Row), c.columns))
+}
+func (c counterRows) Append(row interface{}) { *c.rows = append(*c.rows, row.(Row).clone()) }
+
+// counters returns a copy of the row with the gauges set to 0 so
+// adding it leaves the gauges alone
+func counters(row Row, columns []Column) Row {
+	c := row.clone()
+	for i := range c.Values {
+		if columns[i].Gauge {
+			c.Values[i] = 0
+		}
+	}
+
+	return c
+}
@@ -0,0 +1,116 @@
+// Package digest_latency contains the library routines for managing the
+// events_statements_summary_by_digest table
+package digest_latency
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sjmudd/ps-top/baseobject"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// DigestLatency contains performance_schema.events_statements_summary_by_digest data
+type DigestLatency struct {
+	baseobject.BaseObject      // embedded
+	first                 Rows // initial data for relative values
+	last                  Rows // last loaded values
+	Results               Rows // results (maybe with subtraction)
+	Totals                Row  // totals of results
+	source                Source
+}
+
+// NewDigestLatency returns a DigestLatency collecting data from MySQL using the given db handle
+func NewDigestLatency(ctx *context.Context, db *sql.DB) *DigestLatency {
+	return NewDigestLatencyWithSource(ctx, NewMySQLSource(db))
+}
+
+// NewDigestLatencyWithSource returns a DigestLatency collecting data from the given source
+func NewDigestLatencyWithSource(ctx *context.Context, source Source) *DigestLatency {
+	logger.Println("NewDigestLatency()")
+	dl := &DigestLatency{
+		source: source,
+	}
+	dl.SetContext(ctx)
+
+	return dl
+}
+
+// SetFirstFromLast resets the statistics to current values
+func (dl *DigestLatency) SetFirstFromLast() {
+	dl.updateFirstFromLast()
+	dl.makeResults()
+}
+
+func (dl *DigestLatency) updateFirstFromLast() {
+	dl.first = make(Rows, len(dl.last))
+	copy(dl.first, dl.last)
+	dl.SetFirstCollectTime(dl.LastCollectTime())
+}
+
+// Collect collects data from the db, updating initial values
+// if needed, and then subtracting initial values if we want relative
+// values, after which it stores totals.
+func (dl *DigestLatency) Collect() error {
+	start := time.Now()
+	var collected Rows
+	if err := dl.CollectFrom("digest_latency", &collected, func() (err error) {
+		collected, err = dl.source.Collect(dl.QueryContext(), dl.DatabaseFilter())
+		return err
+	}, func() {
+		if dl.Compensate(counterRows{&collected}) {
+			logger.Println("DigestLatency.Collect() truncation detected")
+			dl.SetTruncated(dl.LastCollectTime())
+		}
+		dl.last = collected
+		logger.Println("dl.last collected", len(dl.last), "row(s) from SELECT")
+
+		if len(dl.first) == 0 && len(dl.last) > 0 {
+			logger.Println("dl.first: copying from dl.last (initial setup)")
+			dl.updateFirstFromLast()
+		}
+
+		// check for reload initial characteristics
+		if dl.first.needsRefresh(dl.last) {
+			logger.Println("dl.first: copying from dl.last (data needs refreshing)")
+			dl.updateFirstFromLast()
+			dl.ResetSnapshots()
+		}
+		dl.AddSnapshot(dl.last)
+
+		dl.makeResults()
+
+		logger.Println("dl.first.totals():", dl.first.totals())
+		logger.Println("dl.last.totals():", dl.last.totals())
+	}); err != nil {
+		return err
+	}
+	logger.Println("DigestLatency.Collect() END, took:", time.Duration(time.Since(start)).String())
+
+	return nil
+}
+
+func (dl *DigestLatency) makeResults() {
+	dl.Results = make(Rows, len(dl.last))
+	copy(dl.Results, dl.last)
+	if dl.WantWindowStats() {
+		if baseline, ok := dl.WindowBaseline(); ok {
+			dl.Results.subtract(baseline.(Rows))
+		}
+	} else if dl.WantRelativeStats() {
+		dl.Results.subtract(dl.first)
+	}
+
+	dl.Totals = dl.Results.totals()
+}
+
+// Len returns the length of the result set
+func (dl DigestLatency) Len() int {
+	return len(dl.Results)
+}
+
+// HaveRelativeStats is true for this object
+func (dl DigestLatency) HaveRelativeStats() bool {
+	return true
+}
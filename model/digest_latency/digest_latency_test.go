@@ -0,0 +1,100 @@
+package digest_latency
+
+import (
+	"testing"
+
+	"github.com/sjmudd/anonymiser"
+
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+func TestRowsSubtract(t *testing.T) {
+	// the same digest in different schemas is kept apart
+	rows := Rows{
+		{Name: "SELECT ?", Schema: "db1", Digest: "abc", CountStar: 10, SumTimerWait: 100, MaxTimerWait: 30},
+		{Name: "SELECT ?", Schema: "db2", Digest: "abc", CountStar: 4, SumTimerWait: 40, MaxTimerWait: 20},
+	}
+	initial := Rows{
+		{Name: "SELECT ?", Schema: "db2", Digest: "abc", CountStar: 1, SumTimerWait: 10, MaxTimerWait: 10},
+		{Name: "SELECT ?", Schema: "db1", Digest: "abc", CountStar: 6, SumTimerWait: 60, MaxTimerWait: 30},
+	}
+	expected := Rows{
+		{Name: "SELECT ?", Schema: "db1", Digest: "abc", CountStar: 4, SumTimerWait: 40, MaxTimerWait: 30},
+		{Name: "SELECT ?", Schema: "db2", Digest: "abc", CountStar: 3, SumTimerWait: 30, MaxTimerWait: 20},
+	}
+
+	rows.subtract(initial)
+	for i := range rows {
+		if rows[i] != expected[i] {
+			t.Errorf("subtract(): expected %v, actual %v", expected[i], rows[i])
+		}
+	}
+}
+
+func TestTotals(t *testing.T) {
+	rows := Rows{
+		{Name: "SELECT ?", Digest: "abc", CountStar: 4, SumTimerWait: 40, MaxTimerWait: 30, SumNoIndexUsed: 1},
+		{Name: "UPDATE `t` SET `a` = ?", Digest: "def", CountStar: 2, SumTimerWait: 10, MaxTimerWait: 8, SumCreatedTmpDiskTables: 2},
+	}
+	expected := Row{Name: "Totals", CountStar: 6, SumTimerWait: 50, MaxTimerWait: 30, SumCreatedTmpDiskTables: 2, SumNoIndexUsed: 1}
+
+	totals := rows.totals()
+	if totals != expected {
+		t.Errorf("totals(): expected %v, actual %v", expected, totals)
+	}
+	if totals.AvgTimerWait() != 8 {
+		t.Errorf("AvgTimerWait(): expected 8, actual %d", totals.AvgTimerWait())
+	}
+}
+
+func TestAnonymiseDigestText(t *testing.T) {
+	defer anonymiser.Enable(anonymiser.Enabled())
+
+	text := "SELECT `name` FROM `customers` WHERE `id` = ?"
+	anonymiser.Enable(false)
+	if got := anonymiseDigestText(text); got != text {
+		t.Errorf("anonymiseDigestText(%q): expected no change when disabled, actual %q", text, got)
+	}
+
+	anonymiser.Clear()
+	anonymiser.Enable(true)
+	expected := "SELECT `identifier1` FROM `identifier2` WHERE `identifier3` = ?"
+	if got := anonymiseDigestText(text); got != expected {
+		t.Errorf("anonymiseDigestText(%q): expected %q, actual %q", text, expected, got)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	ctx.SetWantRelativeStats(true)
+	source := &MemorySource{Rows: Rows{{Name: "SELECT ?", Digest: "abc", CountStar: 2, SumTimerWait: 20, MaxTimerWait: 15}}}
+	dl := NewDigestLatencyWithSource(ctx, source)
+
+	dl.Collect()
+	source.Rows = Rows{
+		{Name: "SELECT ?", Digest: "abc", CountStar: 5, SumTimerWait: 50, MaxTimerWait: 15},
+		{Name: "COMMIT", Digest: "def", CountStar: 1, SumTimerWait: 3, MaxTimerWait: 3},
+	}
+	dl.Collect()
+
+	if expected := (Row{Name: "Totals", CountStar: 4, SumTimerWait: 33, MaxTimerWait: 15}); dl.Totals != expected {
+		t.Errorf("Collect(): expected totals %v, actual %v", expected, dl.Totals)
+	}
+
+	// the table is truncated
+	source.Rows = Rows{{Name: "SELECT ?", Digest: "abc", CountStar: 1, SumTimerWait: 10, MaxTimerWait: 10}}
+	dl.Collect()
+	if dl.Results[0].CountStar != 4 || dl.LastTruncation().IsZero() {
+		t.Errorf("Collect(): expected the truncation to be compensated for, actual %v", dl.Results[0])
+	}
+}
+
+func TestExtraSQLFor(t *testing.T) {
+	f := filter.NewDatabaseFilter("db1,db2")
+	if got, expected := f.ExtraSQLFor("SCHEMA_NAME"), " AND SCHEMA_NAME IN (?,?)"; got != expected {
+		t.Errorf("ExtraSQLFor(): expected %q, actual %q", expected, got)
+	}
+}
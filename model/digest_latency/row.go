@@ -0,0 +1,69 @@
+// Package digest_latency contains the library routines for managing the
+// events_statements_summary_by_digest table
+package digest_latency
+
+// Row contains a row from performance_schema.events_statements_summary_by_digest
+type Row struct {
+	Name   string // normalized digest text
+	Schema string // default database when the statement ran
+	Digest string // digest (hash) of the statement
+
+	CountStar               uint64
+	SumTimerWait            uint64
+	MaxTimerWait            uint64 // the largest value, not a counter
+	SumRowsExamined         uint64
+	SumRowsSent             uint64
+	SumCreatedTmpDiskTables uint64
+	SumNoIndexUsed          uint64
+	SumNoGoodIndexUsed      uint64
+}
+
+// key returns the value identifying the row, a digest per schema
+func (row Row) key() string {
+	return row.Schema + "/" + row.Digest
+}
+
+// add the values from another row to this one
+func (row *Row) add(other Row) {
+	row.CountStar += other.CountStar
+	row.SumTimerWait += other.SumTimerWait
+	if other.MaxTimerWait > row.MaxTimerWait {
+		row.MaxTimerWait = other.MaxTimerWait
+	}
+	row.SumRowsExamined += other.SumRowsExamined
+	row.SumRowsSent += other.SumRowsSent
+	row.SumCreatedTmpDiskTables += other.SumCreatedTmpDiskTables
+	row.SumNoIndexUsed += other.SumNoIndexUsed
+	row.SumNoGoodIndexUsed += other.SumNoGoodIndexUsed
+}
+
+// subtract the countable values in one row from another.
+// MaxTimerWait is kept as there's no way to know the maximum of the interval.
+func (row *Row) subtract(other Row) {
+	row.CountStar -= other.CountStar
+	row.SumTimerWait -= other.SumTimerWait
+	row.SumRowsExamined -= other.SumRowsExamined
+	row.SumRowsSent -= other.SumRowsSent
+	row.SumCreatedTmpDiskTables -= other.SumCreatedTmpDiskTables
+	row.SumNoIndexUsed -= other.SumNoIndexUsed
+	row.SumNoGoodIndexUsed -= other.SumNoGoodIndexUsed
+}
+
+// HasData indicates if there is data in the row (for counting valid rows)
+func (row *Row) HasData() bool {
+	return row != nil && row.SumTimerWait > 0
+}
+
+// AvgTimerWait returns the average latency of the statements
+func (row Row) AvgTimerWait() uint64 {
+	if row.CountStar == 0 {
+		return 0
+	}
+	return row.SumTimerWait / row.CountStar
+}
+
+// decreased returns true if the counters have gone down since the
+// previous values were collected so the row has been truncated.
+func (row Row) decreased(previous Row) bool {
+	return row.SumTimerWait < previous.SumTimerWait || row.CountStar < previous.CountStar
+}
@@ -0,0 +1,133 @@
+// Package digest_latency contains the library routines for managing the
+// events_statements_summary_by_digest table
+package digest_latency
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/logger"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+// Rows contains a set of rows
+type Rows []Row
+
+// identifier matches a quoted identifier in the digest text
+var identifier = regexp.MustCompile("`[^`]*`")
+
+// anonymiseDigestText returns the digest text with the quoted identifiers
+// anonymised. Literal values have already been replaced by MySQL.
+func anonymiseDigestText(text string) string {
+	if !lib.AnonymiseEnabled() {
+		return text
+	}
+	return identifier.ReplaceAllStringFunc(text, func(quoted string) string {
+		return "`" + lib.Anonymise("identifier", quoted[1:len(quoted)-1]) + "`"
+	})
+}
+
+func (rows Rows) totals() Row {
+	var totals Row
+	totals.Name = "Totals"
+
+	for i := range rows {
+		totals.add(rows[i])
+	}
+
+	return totals
+}
+
+func collect(ctx context.Context, dbh *sql.DB, databaseFilter *filter.DatabaseFilter) (Rows, error) {
+	var t Rows
+
+	logger.Printf("collect(?,%q)\n", databaseFilter)
+
+	// the row with a NULL digest counts the statements which did not fit in the table
+	sql := `SELECT IFNULL(SCHEMA_NAME, ''), IFNULL(DIGEST, ''), IFNULL(DIGEST_TEXT, ''), COUNT_STAR, SUM_TIMER_WAIT, MAX_TIMER_WAIT, SUM_ROWS_EXAMINED, SUM_ROWS_SENT, SUM_CREATED_TMP_DISK_TABLES, SUM_NO_INDEX_USED, SUM_NO_GOOD_INDEX_USED FROM events_statements_summary_by_digest WHERE SUM_TIMER_WAIT > 0`
+	args := []interface{}{}
+
+	// Apply the filter if provided and seems good.
+	if len(databaseFilter.Args()) > 0 {
+		sql = sql + databaseFilter.ExtraSQLFor("SCHEMA_NAME")
+		for _, v := range databaseFilter.Args() {
+			args = append(args, v)
+		}
+		logger.Printf("apply databaseFilter: sql: %q, args: %+v\n", sql, args)
+	}
+
+	rows, err := dbh.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r Row
+		if err := rows.Scan(
+			&r.Schema,
+			&r.Digest,
+			&r.Name,
+			&r.CountStar,
+			&r.SumTimerWait,
+			&r.MaxTimerWait,
+			&r.SumRowsExamined,
+			&r.SumRowsSent,
+			&r.SumCreatedTmpDiskTables,
+			&r.SumNoIndexUsed,
+			&r.SumNoGoodIndexUsed); err != nil {
+			return nil, err
+		}
+		r.Schema = lib.Anonymise("schema", r.Schema)
+		r.Name = anonymiseDigestText(r.Name)
+
+		t = append(t, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// remove the initial values from those rows where there's a match
+// - if we find a row we can't match ignore it
+func (rows *Rows) subtract(initial Rows) {
+	initialByKey := make(map[string]int)
+
+	// iterate over rows by key
+	for i := range initial {
+		initialByKey[initial[i].key()] = i
+	}
+
+	for i := range *rows {
+		if initialIndex, ok := initialByKey[(*rows)[i].key()]; ok {
+			(*rows)[i].subtract(initial[initialIndex])
+		}
+	}
+}
+
+// if the data in t2 is "newer", "has more values" than t then it needs refreshing.
+// check this by comparing totals.
+func (rows Rows) needsRefresh(otherRows Rows) bool {
+	myTotals := rows.totals()
+	otherTotals := otherRows.totals()
+
+	return myTotals.SumTimerWait > otherTotals.SumTimerWait
+}
+
+// counterRows gives baseobject.Compensate access to the rows by key
+type counterRows struct {
+	rows *Rows
+}
+
+func (c counterRows) Len() int              { return len(*c.rows) }
+func (c counterRows) Key(i int) string      { return (*c.rows)[i].key() }
+func (c counterRows) Row(i int) interface{} { return (*c.rows)[i] }
+func (c counterRows) Decreased(i int, previous interface{}) bool {
+	return (*c.rows)[i].decreased(previous.(Row))
+}
+func (c counterRows) Add(i int, offset interface{}) { (*c.rows)[i].add(offset.(Row)) }
+func (c counterRows) Append(row interface{})        { *c.rows = append(*c.rows, row.(Row)) }
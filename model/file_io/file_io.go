@@ -17,15 +17,20 @@ type FileIoLatency struct {
 	last                  Rows
 	Results               Rows
 	Totals                Row
-	db                    *sql.DB
+	source                Source
 }
 
-// NewFileSummaryByInstance creates a new structure and include various variable values:
+// NewFileSummaryByInstance returns a FileIoLatency collecting data from MySQL using the given db handle
+func NewFileSummaryByInstance(ctx *context.Context, db *sql.DB) *FileIoLatency {
+	return NewFileSummaryByInstanceWithSource(ctx, NewMySQLSource(db))
+}
+
+// NewFileSummaryByInstanceWithSource creates a new structure and include various variable values:
 // - datadir, relay_log
 // There's no checking that these are actually provided!
-func NewFileSummaryByInstance(ctx *context.Context, db *sql.DB) *FileIoLatency {
+func NewFileSummaryByInstanceWithSource(ctx *context.Context, source Source) *FileIoLatency {
 	fiol := &FileIoLatency{
-		db: db,
+		source: source,
 	}
 	fiol.SetContext(ctx)
 
@@ -45,32 +50,51 @@ func (fiol *FileIoLatency) updateFirstFromLast() {
 }
 
 // Collect data from the db, then merge it in.
-func (fiol *FileIoLatency) Collect() {
+func (fiol *FileIoLatency) Collect() error {
 	start := time.Now()
-	fiol.last = collect(fiol.db).mergeByName(fiol.Variables())
-	fiol.SetLastCollectTime(time.Now())
-
-	// copy in first data if it was not there
-	if len(fiol.first) == 0 && len(fiol.last) > 0 {
-		fiol.updateFirstFromLast()
+	var collected Rows
+	if err := fiol.CollectFrom("file_io", &collected, func() (err error) {
+		collected, err = fiol.source.Collect(fiol.QueryContext())
+		return err
+	}, func() {
+		if fiol.Compensate(counterRows{&collected}) {
+			logger.Println("FileIoLatency.Collect() truncation detected")
+			fiol.SetTruncated(fiol.LastCollectTime())
+		}
+		fiol.last = collected.mergeByName(fiol.Variables())
+
+		// copy in first data if it was not there
+		if len(fiol.first) == 0 && len(fiol.last) > 0 {
+			fiol.updateFirstFromLast()
+		}
+
+		// check for reload initial characteristics
+		if fiol.first.needsRefresh(fiol.last) {
+			fiol.updateFirstFromLast()
+			fiol.ResetSnapshots()
+		}
+		fiol.AddSnapshot(fiol.last)
+
+		fiol.makeResults()
+
+		logger.Println("fiol.first.totals():", fiol.first.totals())
+		logger.Println("fiol.last.totals():", fiol.last.totals())
+	}); err != nil {
+		return err
 	}
-
-	// check for reload initial characteristics
-	if fiol.first.needsRefresh(fiol.last) {
-		fiol.updateFirstFromLast()
-	}
-
-	fiol.makeResults()
-
-	logger.Println("fiol.first.totals():", fiol.first.totals())
-	logger.Println("fiol.last.totals():", fiol.last.totals())
 	logger.Println("FileIoLatency.Collect() took:", time.Duration(time.Since(start)).String())
+
+	return nil
 }
 
 func (fiol *FileIoLatency) makeResults() {
 	fiol.Results = make([]Row, len(fiol.last))
 	copy(fiol.Results, fiol.last)
-	if fiol.WantRelativeStats() {
+	if fiol.WantWindowStats() {
+		if baseline, ok := fiol.WindowBaseline(); ok {
+			fiol.Results.subtract(baseline.(Rows))
+		}
+	} else if fiol.WantRelativeStats() {
 		fiol.Results.subtract(fiol.first)
 	}
 
@@ -10,9 +10,9 @@ import (
 	"github.com/sjmudd/ps-top/rc"
 )
 
-//     foo/../bar --> foo/bar   perl: $new =~ s{[^/]+/\.\./}{/};
-//     /./        --> /         perl: $new =~ s{/\./}{};
-//     //         --> /         perl: $new =~ s{//}{/};
+// foo/../bar --> foo/bar   perl: $new =~ s{[^/]+/\.\./}{/};
+// /./        --> /         perl: $new =~ s{/\./}{};
+// //         --> /         perl: $new =~ s{//}{/};
 const (
 	reEncoded = `@(\d{4})` // FIXME - add me to catch @0024 --> $ for example
 )
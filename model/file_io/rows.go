@@ -3,8 +3,8 @@
 package file_io
 
 import (
+	"context"
 	"database/sql"
-	"log"
 	"regexp"
 	"time"
 
@@ -94,7 +94,7 @@ func match(text string, searchFor string) bool {
 }
 
 // Select the raw data from the database into Rows
-func collect(dbh *sql.DB) Rows {
+func collect(ctx context.Context, dbh *sql.DB) (Rows, error) {
 	logger.Println("collect() starts")
 	var t Rows
 	start := time.Now()
@@ -115,9 +115,9 @@ FROM	file_summary_by_instance
 WHERE	SUM_TIMER_WAIT > 0
 `
 
-	rows, err := dbh.Query(sql)
+	rows, err := dbh.QueryContext(ctx, sql)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -136,19 +136,19 @@ WHERE	SUM_TIMER_WAIT > 0
 			&r.CountRead,
 			&r.CountWrite,
 			&r.CountMisc); err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 		t = append(t, r)
 	}
 	if err := rows.Err(); err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	if !t.Valid() {
 		logger.Println("WARNING: collect(): t is invalid")
 	}
 	logger.Println("collect() took:", time.Duration(time.Since(start)).String(), "and returned", len(t), "rows")
 
-	return t
+	return t, nil
 }
 
 // subtract compares 2 slices of rows by name and removes the initial values
@@ -205,3 +205,17 @@ func (rows Rows) needsRefresh(t2 Rows) bool {
 
 	return (myTotals.SumTimerWait > otherTotals.SumTimerWait) || (myTotals.CountStar > otherTotals.CountStar)
 }
+
+// counterRows gives baseobject.Compensate access to the rows by name
+type counterRows struct {
+	rows *Rows
+}
+
+func (c counterRows) Len() int              { return len(*c.rows) }
+func (c counterRows) Key(i int) string      { return (*c.rows)[i].Name }
+func (c counterRows) Row(i int) interface{} { return (*c.rows)[i] }
+func (c counterRows) Decreased(i int, previous interface{}) bool {
+	return (*c.rows)[i].decreased(previous.(Row))
+}
+func (c counterRows) Add(i int, offset interface{}) { (*c.rows)[i] = add((*c.rows)[i], offset.(Row)) }
+func (c counterRows) Append(row interface{})        { *c.rows = append(*c.rows, row.(Row)) }
@@ -45,9 +45,15 @@ func placeholders(names []string) []string {
 
 // ExtraSQL returns the extra string to apply to the base SQL statement (placeholders)
 func (f *DatabaseFilter) ExtraSQL() string {
+	return f.ExtraSQLFor("OBJECT_SCHEMA")
+}
+
+// ExtraSQLFor returns the extra string to apply to the base SQL statement
+// (placeholders) where the database name is held in the given column
+func (f *DatabaseFilter) ExtraSQLFor(column string) string {
 	if len(f.filteredInput) == 0 {
 		return ""
 	}
 
-	return ` AND OBJECT_SCHEMA IN (` + strings.Join(placeholders(f.filteredInput), `,`) + `)`
+	return ` AND ` + column + ` IN (` + strings.Join(placeholders(f.filteredInput), `,`) + `)`
 }
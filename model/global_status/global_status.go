@@ -0,0 +1,146 @@
+// Package global_status provides library routines for ps-top
+// for managing the global_status table.
+package global_status
+
+import (
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sjmudd/ps-top/baseobject"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// GlobalStatus holds a table of rows
+type GlobalStatus struct {
+	baseobject.BaseObject                 // embedded
+	first                 Rows            // initial data for relative values
+	last                  Rows            // last loaded values
+	Results               Rows            // results (maybe with subtraction)
+	Totals                Row             // totals of results
+	prefixes              []string        // only show the variables starting with these prefixes
+	collected             Rows            // rows last collected, before any compensation
+	gauges                map[string]bool // variables seen to go down without a flush
+	source                Source
+}
+
+// ParsePrefixes returns the variable name prefixes given as a comma-separated list
+func ParsePrefixes(list string) []string {
+	var prefixes []string
+	for _, prefix := range strings.Split(list, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	return prefixes
+}
+
+// NewGlobalStatus returns a GlobalStatus collecting data from MySQL using the given db handle
+func NewGlobalStatus(ctx *context.Context, db *sql.DB, prefixes []string) *GlobalStatus {
+	return NewGlobalStatusWithSource(ctx, NewMySQLSource(db), prefixes)
+}
+
+// NewGlobalStatusWithSource returns a global status object using the
+// given context and source, showing the variables starting with the
+// given prefixes or all variables if there are none.
+func NewGlobalStatusWithSource(ctx *context.Context, source Source, prefixes []string) *GlobalStatus {
+	logger.Println("NewGlobalStatus()")
+	if ctx == nil {
+		log.Println("NewGlobalStatus() ctx == nil!")
+	}
+	gs := &GlobalStatus{
+		prefixes: prefixes,
+		source:   source,
+		gauges:   make(map[string]bool),
+	}
+	gs.SetContext(ctx)
+
+	return gs
+}
+
+func (gs *GlobalStatus) updateFirstFromLast() {
+	gs.first = make(Rows, len(gs.last))
+	gs.SetFirstCollectTime(gs.LastCollectTime())
+	copy(gs.first, gs.last)
+}
+
+// Collect collects data from the db, updating first
+// values if needed, and then subtracting first values if we want
+// relative values, after which it stores totals.
+func (gs *GlobalStatus) Collect() error {
+	start := time.Now()
+	var collected Rows
+	if err := gs.CollectFrom("global_status", &collected, func() (err error) {
+		collected, err = gs.source.Collect(gs.QueryContext())
+		return err
+	}, func() {
+		flushed := collected.flushed(gs.collected)
+		previous := gs.collected
+		if flushed {
+			previous = nil // the counters went down as they were reset
+		}
+		gs.collected = make(Rows, len(collected))
+		copy(gs.collected, collected)
+		collected = collected.filter(gs.prefixes)
+		collected.markGauges(previous, gs.gauges)
+		gs.first.markGauges(nil, gs.gauges)
+		if gs.Compensate(counterRows{rows: &collected, flushed: flushed}) {
+			logger.Println("GlobalStatus.Collect() status reset detected")
+			gs.SetTruncated(gs.LastCollectTime())
+		}
+		gs.last = collected
+
+		logger.Println("t.current collected", len(gs.last), "row(s) from SELECT")
+
+		if len(gs.first) == 0 && len(gs.last) > 0 {
+			logger.Println("gs.first: copying from gs.last (initial setup)")
+			gs.updateFirstFromLast()
+		}
+
+		// check for reload initial characteristics
+		if gs.first.needsRefresh(gs.last) {
+			logger.Println("gs.first: copying from gs.last (data needs refreshing)")
+			gs.updateFirstFromLast()
+			gs.ResetSnapshots()
+		}
+		gs.AddSnapshot(gs.last)
+
+		gs.makeResults()
+	}); err != nil {
+		return err
+	}
+	logger.Println("GlobalStatus.Collect() END, took:", time.Duration(time.Since(start)).String())
+
+	return nil
+}
+
+// makeResults subtracts the baseline from the counters, gauges keep
+// their current values, and only keeps the variables with a value.
+func (gs *GlobalStatus) makeResults() {
+	results := make(Rows, len(gs.last))
+	copy(results, gs.last)
+	if gs.WantWindowStats() {
+		if baseline, ok := gs.WindowBaseline(); ok {
+			results.subtract(baseline.(Rows))
+		}
+	} else if gs.WantRelativeStats() {
+		results.subtract(gs.first)
+	}
+
+	gs.Results = results.withValues()
+	gs.Totals = gs.Results.totals()
+}
+
+// SetFirstFromLast resets the statistics to current values
+func (gs *GlobalStatus) SetFirstFromLast() {
+	gs.updateFirstFromLast()
+	gs.makeResults()
+}
+
+// HaveRelativeStats is true for this object
+func (gs GlobalStatus) HaveRelativeStats() bool {
+	return true
+}
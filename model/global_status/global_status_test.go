@@ -0,0 +1,120 @@
+package global_status
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+func TestNewRow(t *testing.T) {
+	var tests = []struct {
+		name, value string
+		expected    Row
+		ok          bool
+	}{
+		{"Com_select", "12", Row{"Com_select", 12, false}, true},
+		{"Threads_running", "3", Row{"Threads_running", 3, true}, true},
+		{"Threads_created", "3", Row{"Threads_created", 3, false}, true},
+		{"Innodb_data_pending_reads", "1", Row{"Innodb_data_pending_reads", 1, true}, true},
+		{"Ssl_cipher", "", Row{}, false},
+		{"Innodb_buffer_pool_load_status", "Buffer pool(s) load completed", Row{}, false},
+	}
+
+	for _, test := range tests {
+		row, ok := newRow(test.name, test.value)
+		if row != test.expected || ok != test.ok {
+			t.Errorf("newRow(%q, %q): expected %v, %v, actual %v, %v", test.name, test.value, test.expected, test.ok, row, ok)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Com_select":         "Com",
+		"Innodb_rows_read":   "Innodb",
+		"Questions":          "Questions",
+		"Handler_read_first": "Handler",
+	} {
+		if got := (Row{Name: name}).Group(); got != expected {
+			t.Errorf("Row{%q}.Group(): expected %q, actual %q", name, expected, got)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	rows := Rows{{"Com_select", 1, false}, {"Handler_write", 2, false}, {"Threads_running", 3, true}}
+
+	if got := rows.filter(nil); !reflect.DeepEqual(got, rows) {
+		t.Errorf("filter(nil): expected %v, actual %v", rows, got)
+	}
+	expected := Rows{{"Com_select", 1, false}, {"Threads_running", 3, true}}
+	if got := rows.filter(ParsePrefixes("com_, Threads")); !reflect.DeepEqual(got, expected) {
+		t.Errorf("filter(com_, Threads): expected %v, actual %v", expected, got)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	ctx.SetWantRelativeStats(true)
+	source := &MemorySource{Rows: Rows{{"Com_select", 10, false}, {"Com_insert", 5, false}, {"Threads_running", 2, true}, {"Uptime_since_flush_status", 100, true}}}
+	gs := NewGlobalStatusWithSource(ctx, source, ParsePrefixes("Com_,Threads_"))
+
+	gs.Collect()
+	source.Rows = Rows{{"Com_select", 15, false}, {"Com_insert", 5, false}, {"Threads_running", 4, true}, {"Uptime_since_flush_status", 110, true}}
+	gs.Collect()
+
+	// the unchanged Com_insert is not shown and gauges are not subtracted
+	expected := Rows{{"Com_select", 5, false}, {"Threads_running", 4, true}}
+	if !reflect.DeepEqual(gs.Results, expected) {
+		t.Errorf("Collect(): expected %v, actual %v", expected, gs.Results)
+	}
+
+	// FLUSH STATUS resets the counters and Uptime_since_flush_status
+	source.Rows = Rows{{"Com_select", 1, false}, {"Com_insert", 0, false}, {"Threads_running", 4, true}, {"Uptime_since_flush_status", 5, true}}
+	gs.Collect()
+	expected = Rows{{"Com_select", 6, false}, {"Threads_running", 4, true}}
+	if !reflect.DeepEqual(gs.Results, expected) {
+		t.Errorf("Collect() after reset: expected %v, actual %v", expected, gs.Results)
+	}
+	if gs.LastTruncation().IsZero() {
+		t.Errorf("Collect() after reset: expected the reset to be seen")
+	}
+
+	ctx.SetWantRelativeStats(false)
+	gs.SetFirstFromLast()
+	expected = Rows{{"Com_select", 16, false}, {"Com_insert", 5, false}, {"Threads_running", 4, true}}
+	if !reflect.DeepEqual(gs.Results, expected) {
+		t.Errorf("absolute values: expected %v, actual %v", expected, gs.Results)
+	}
+}
+
+func TestCollectWithoutFlush(t *testing.T) {
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	ctx.SetWantRelativeStats(true)
+	source := &MemorySource{Rows: Rows{{"Com_select", 10, false}, {"Threads_unknown", 7, false}, {"Uptime_since_flush_status", 100, true}}}
+	gs := NewGlobalStatusWithSource(ctx, source, nil)
+
+	gs.Collect()
+	source.Rows = Rows{{"Com_select", 12, false}, {"Threads_unknown", 3, false}, {"Uptime_since_flush_status", 110, true}}
+	gs.Collect()
+
+	// a variable going down without a flush is a gauge and shows its value
+	expected := Rows{{"Com_select", 2, false}, {"Threads_unknown", 3, true}, {"Uptime_since_flush_status", 110, true}}
+	if !reflect.DeepEqual(gs.Results, expected) {
+		t.Errorf("Collect(): expected %v, actual %v", expected, gs.Results)
+	}
+	if !gs.LastTruncation().IsZero() {
+		t.Errorf("Collect(): unexpected reset seen")
+	}
+
+	source.Rows = Rows{{"Com_select", 13, false}, {"Threads_unknown", 9, false}, {"Uptime_since_flush_status", 120, true}}
+	gs.Collect()
+	expected = Rows{{"Com_select", 3, false}, {"Threads_unknown", 9, true}, {"Uptime_since_flush_status", 120, true}}
+	if !reflect.DeepEqual(gs.Results, expected) {
+		t.Errorf("Collect() after going up again: expected %v, actual %v", expected, gs.Results)
+	}
+}
@@ -0,0 +1,102 @@
+// Package global_status contains the library routines for managing
+// the performance_schema.global_status table
+package global_status
+
+import (
+	"strings"
+
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// gauges holds the lower-cased names of the status variables known to
+// be a current level rather than an ever increasing counter. Other
+// variables are treated as gauges once they are seen to go down when
+// the status has not been flushed.
+var gauges = map[string]bool{
+	"innodb_buffer_pool_bytes_data":  true,
+	"innodb_buffer_pool_bytes_dirty": true,
+	"innodb_buffer_pool_pages_data":  true,
+	"innodb_buffer_pool_pages_dirty": true,
+	"innodb_buffer_pool_pages_free":  true,
+	"innodb_buffer_pool_pages_misc":  true,
+	"innodb_buffer_pool_pages_total": true,
+	"innodb_num_open_files":          true,
+	"innodb_page_size":               true,
+	"innodb_row_lock_current_waits":  true,
+	"innodb_row_lock_time_avg":       true,
+	"innodb_row_lock_time_max":       true,
+	"key_blocks_not_flushed":         true,
+	"key_blocks_unused":              true,
+	"key_blocks_used":                true,
+	"max_used_connections":           true,
+	"open_files":                     true,
+	"open_streams":                   true,
+	"open_table_definitions":         true,
+	"open_tables":                    true,
+	"prepared_stmt_count":            true,
+	"qcache_free_blocks":             true,
+	"qcache_free_memory":             true,
+	"qcache_queries_in_cache":        true,
+	"qcache_total_blocks":            true,
+	"replica_open_temp_tables":       true,
+	"slave_open_temp_tables":         true,
+	"threads_cached":                 true,
+	"threads_connected":              true,
+	"threads_running":                true,
+	"uptime":                         true,
+	"uptime_since_flush_status":      true,
+}
+
+// isGauge returns true if the named status variable is a gauge.
+// Besides the known gauges anything pending or current is a gauge.
+func isGauge(name string) bool {
+	name = strings.ToLower(name)
+
+	return gauges[name] || strings.Contains(name, "_pending_") || strings.Contains(name, "_current_")
+}
+
+// Row contains a numeric status variable from performance_schema.global_status
+type Row struct {
+	Name  string
+	Value uint64
+	Gauge bool // is the value a current level rather than a counter?
+}
+
+// Group returns the prefix of the variable name which groups related
+// variables together, e.g. Com, Handler or Innodb.
+func (row Row) Group() string {
+	if i := strings.Index(row.Name, "_"); i > 0 {
+		return row.Name[:i]
+	}
+	return row.Name
+}
+
+// add the counter values of another row, gauges are left alone
+func (row *Row) add(other Row) {
+	if !row.Gauge {
+		row.Value += other.Value
+	}
+}
+
+// subtract the counter values of another row, gauges are left alone
+func (row *Row) subtract(other Row) {
+	if row.Gauge {
+		return
+	}
+	// check for issues here (we have a bug) and log it
+	// - this situation should not happen so there's a logic bug somewhere else
+	if row.Value >= other.Value {
+		row.Value -= other.Value
+	} else {
+		logger.Println("WARNING: Row.subtract() - subtraction problem! (not subtracting)")
+		logger.Println("row=", row)
+		logger.Println("other=", other)
+	}
+}
+
+// decreased returns true if a counter has gone down since the
+// previous value was collected. This is only a reset if the status
+// has been flushed.
+func (row Row) decreased(previous Row) bool {
+	return !row.Gauge && row.Value < previous.Value
+}
@@ -0,0 +1,184 @@
+package global_status
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Rows contains a slice of Row
+type Rows []Row
+
+// totals returns the sum of the counters which is only useful to
+// see if the counters have gone backwards
+func (rows Rows) totals() Row {
+	totals := Row{Name: "Totals"}
+
+	for i := range rows {
+		if !rows[i].Gauge {
+			totals.add(rows[i])
+		}
+	}
+
+	return totals
+}
+
+func collect(ctx context.Context, dbh *sql.DB) (Rows, error) {
+	var t Rows
+
+	sql := "SELECT VARIABLE_NAME, VARIABLE_VALUE FROM performance_schema.global_status"
+
+	rows, err := dbh.QueryContext(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		if r, ok := newRow(name, value); ok {
+			t = append(t, r)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// newRow returns a row for the given status variable, or false if the
+// value is not numeric (e.g. Ssl_cipher or Innodb_buffer_pool_load_status).
+func newRow(name, value string) (Row, bool) {
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return Row{}, false
+	}
+
+	return Row{Name: name, Value: v, Gauge: isGauge(name)}, true
+}
+
+// filter returns the rows whose names start with one of the given
+// prefixes, ignoring case.  No prefixes return all the rows.
+func (rows Rows) filter(prefixes []string) Rows {
+	if len(prefixes) == 0 {
+		return rows
+	}
+	var filtered Rows
+	for i := range rows {
+		name := strings.ToLower(rows[i].Name)
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, strings.ToLower(prefix)) {
+				filtered = append(filtered, rows[i])
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
+// withValues returns the rows with a non-zero value so unchanged
+// counters are not shown
+func (rows Rows) withValues() Rows {
+	var t Rows
+	for i := range rows {
+		if rows[i].Value > 0 {
+			t = append(t, rows[i])
+		}
+	}
+
+	return t
+}
+
+// flushed returns true if the status has been flushed, or the server
+// restarted, since the previous rows were collected. Only then do the
+// counters go down: Uptime or Uptime_since_flush_status goes down too.
+func (rows Rows) flushed(previous Rows) bool {
+	values := make(map[string]uint64)
+	for i := range previous {
+		values[strings.ToLower(previous[i].Name)] = previous[i].Value
+	}
+
+	for i := range rows {
+		name := strings.ToLower(rows[i].Name)
+		if name != "uptime" && name != "uptime_since_flush_status" {
+			continue
+		}
+		if value, ok := values[name]; ok && rows[i].Value < value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// markGauges marks the rows named in gauges as gauges, first adding the
+// names of the rows which went down since the previous rows were
+// collected. previous should be nil if the status has been flushed.
+func (rows Rows) markGauges(previous Rows, gauges map[string]bool) {
+	values := make(map[string]uint64)
+	for i := range previous {
+		values[previous[i].Name] = previous[i].Value
+	}
+
+	for i := range rows {
+		name := rows[i].Name
+		if value, ok := values[name]; ok && rows[i].Value < value && !rows[i].Gauge {
+			logger.Println("global_status: treating", name, "as a gauge as it went down from", value, "to", rows[i].Value)
+			gauges[name] = true
+		}
+		if gauges[name] {
+			rows[i].Gauge = true
+		}
+	}
+}
+
+// remove the initial values from those rows where there's a match
+// - if we find a row we can't match ignore it
+func (rows *Rows) subtract(initial Rows) {
+	initialByName := make(map[string]int)
+
+	// iterate over rows by name
+	for i := range initial {
+		initialByName[initial[i].Name] = i
+	}
+
+	for i := range *rows {
+		name := (*rows)[i].Name
+		if _, ok := initialByName[name]; ok {
+			initialIndex := initialByName[name]
+			(*rows)[i].subtract(initial[initialIndex])
+		}
+	}
+}
+
+// if the data in t2 is "newer", "has more values" than t then it needs refreshing.
+// check this by comparing totals.
+func (rows Rows) needsRefresh(otherRows Rows) bool {
+	totals := rows.totals()
+	otherTotals := otherRows.totals()
+
+	return totals.Value > otherTotals.Value
+}
+
+// counterRows gives baseobject.Compensate access to the rows by name
+type counterRows struct {
+	rows    *Rows
+	flushed bool // the counters may only have been reset if the status was flushed
+}
+
+func (c counterRows) Len() int              { return len(*c.rows) }
+func (c counterRows) Key(i int) string      { return (*c.rows)[i].Name }
+func (c counterRows) Row(i int) interface{} { return (*c.rows)[i] }
+func (c counterRows) Decreased(i int, previous interface{}) bool {
+	return c.flushed && (*c.rows)[i].decreased(previous.(Row))
+}
+func (c counterRows) Add(i int, offset interface{}) { (*c.rows)[i].add(offset.(Row)) }
+func (c counterRows) Append(row interface{})        { *c.rows = append(*c.rows, row.(Row)) }
@@ -0,0 +1,44 @@
+package global_status
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Source provides the rows collected from global_status
+type Source interface {
+	Collect(ctx context.Context) (Rows, error)
+}
+
+// MySQLSource is the default Source which collects the rows from MySQL
+type MySQLSource struct {
+	db *sql.DB
+}
+
+// NewMySQLSource returns a Source collecting the rows using the given db handle
+func NewMySQLSource(db *sql.DB) *MySQLSource {
+	return &MySQLSource{db: db}
+}
+
+// Collect returns the rows collected from MySQL
+func (s *MySQLSource) Collect(ctx context.Context) (Rows, error) {
+	return collect(ctx, s.db)
+}
+
+// MemorySource is a Source holding the rows to return in memory which
+// is useful for testing. If Err is set it is returned instead of the rows.
+type MemorySource struct {
+	Rows Rows
+	Err  error
+}
+
+// Collect returns a copy of the rows held in memory.
+func (s *MemorySource) Collect(ctx context.Context) (Rows, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	rows := make(Rows, len(s.Rows))
+	copy(rows, s.Rows)
+
+	return rows, nil
+}
@@ -0,0 +1,123 @@
+// Package index_usage contains the library routines for managing the
+// table_io_waits_summary_by_index_usage table
+package index_usage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sjmudd/ps-top/baseobject"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// IndexUsage contains performance_schema.table_io_waits_summary_by_index_usage data
+type IndexUsage struct {
+	baseobject.BaseObject      // embedded
+	first                 Rows // initial data for relative values
+	last                  Rows // last loaded values
+	Results               Rows // results (maybe with subtraction)
+	Totals                Row  // totals of results
+	source                Source
+}
+
+// NewIndexUsage returns an IndexUsage collecting data from MySQL using the given db handle
+func NewIndexUsage(ctx *context.Context, db *sql.DB) *IndexUsage {
+	return NewIndexUsageWithSource(ctx, NewMySQLSource(db))
+}
+
+// NewIndexUsageWithSource returns an IndexUsage collecting data from the given source
+func NewIndexUsageWithSource(ctx *context.Context, source Source) *IndexUsage {
+	logger.Println("NewIndexUsage()")
+	iu := &IndexUsage{
+		source: source,
+	}
+	iu.SetContext(ctx)
+
+	return iu
+}
+
+// SetFirstFromLast resets the statistics to current values
+func (iu *IndexUsage) SetFirstFromLast() {
+	iu.updateFirstFromLast()
+	iu.makeResults()
+}
+
+func (iu *IndexUsage) updateFirstFromLast() {
+	iu.first = make(Rows, len(iu.last))
+	copy(iu.first, iu.last)
+	iu.SetFirstCollectTime(iu.LastCollectTime())
+}
+
+// Collect collects data from the db, updating initial values
+// if needed, and then subtracting initial values if we want relative
+// values, after which it stores totals.
+func (iu *IndexUsage) Collect() error {
+	start := time.Now()
+	var collected Rows
+	if err := iu.CollectFrom("index_usage", &collected, func() (err error) {
+		collected, err = iu.source.Collect(iu.QueryContext(), iu.DatabaseFilter())
+		return err
+	}, func() {
+		if iu.Compensate(counterRows{&collected}) {
+			logger.Println("IndexUsage.Collect() truncation detected")
+			iu.SetTruncated(iu.LastCollectTime())
+		}
+		iu.last = collected
+		logger.Println("iu.last collected", len(iu.last), "row(s) from SELECT")
+
+		if len(iu.first) == 0 && len(iu.last) > 0 {
+			logger.Println("iu.first: copying from iu.last (initial setup)")
+			iu.updateFirstFromLast()
+		}
+
+		// check for reload initial characteristics
+		if iu.first.needsRefresh(iu.last) {
+			logger.Println("iu.first: copying from iu.last (data needs refreshing)")
+			iu.updateFirstFromLast()
+			iu.ResetSnapshots()
+		}
+		iu.AddSnapshot(iu.last)
+
+		iu.makeResults()
+
+		logger.Println("iu.first.totals():", iu.first.totals())
+		logger.Println("iu.last.totals():", iu.last.totals())
+	}); err != nil {
+		return err
+	}
+	logger.Println("IndexUsage.Collect() END, took:", time.Duration(time.Since(start)).String())
+
+	return nil
+}
+
+func (iu *IndexUsage) makeResults() {
+	iu.Results = make(Rows, len(iu.last))
+	copy(iu.Results, iu.last)
+	if iu.WantWindowStats() {
+		if baseline, ok := iu.WindowBaseline(); ok {
+			iu.Results.subtract(baseline.(Rows))
+		}
+	} else if iu.WantRelativeStats() {
+		iu.Results.subtract(iu.first)
+	}
+
+	iu.Totals = iu.Results.totals()
+}
+
+// Len returns the length of the result set
+func (iu IndexUsage) Len() int {
+	return len(iu.Results)
+}
+
+// HaveRelativeStats is true for this object
+func (iu IndexUsage) HaveRelativeStats() bool {
+	return true
+}
+
+// Unused returns the number of indexes which have not been used
+// since the statistics were reset, or during the window, if we want
+// relative statistics.
+func (iu IndexUsage) Unused() int {
+	return iu.Results.unused()
+}
@@ -0,0 +1,90 @@
+package index_usage
+
+import (
+	"testing"
+
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+func TestFullScanAndUnused(t *testing.T) {
+	var tests = []struct {
+		row      Row
+		fullScan bool
+		unused   bool
+	}{
+		{Row{Name: "db.t1", Index: "", CountStar: 5}, true, false},
+		{Row{Name: "db.t1", Index: "", CountStar: 0}, true, false},
+		{Row{Name: "db.t1", Index: "idx_a", CountStar: 0}, false, true},
+		{Row{Name: "db.t1", Index: "idx_a", CountStar: 3}, false, false},
+		{Row{Name: "db.t1", Index: "PRIMARY", CountStar: 0}, false, false},
+		{Row{Name: "Totals"}, false, false},
+	}
+
+	for _, test := range tests {
+		if got := test.row.FullScan(); got != test.fullScan {
+			t.Errorf("%v.FullScan(): expected %v, actual %v", test.row, test.fullScan, got)
+		}
+		if got := test.row.Unused(); got != test.unused {
+			t.Errorf("%v.Unused(): expected %v, actual %v", test.row, test.unused, got)
+		}
+	}
+}
+
+func TestMergeByKey(t *testing.T) {
+	// partitions munged to the same table name are combined
+	rows := Rows{
+		{Name: "db.t_YYYYMM", Index: "PRIMARY", SumTimerWait: 10, CountStar: 2},
+		{Name: "db.t_YYYYMM", Index: "", SumTimerWait: 5, CountStar: 1},
+		{Name: "db.t_YYYYMM", Index: "PRIMARY", SumTimerWait: 20, CountStar: 3},
+	}
+	expected := Rows{
+		{Name: "db.t_YYYYMM", Index: "PRIMARY", SumTimerWait: 30, CountStar: 5},
+		{Name: "db.t_YYYYMM", Index: "", SumTimerWait: 5, CountStar: 1},
+	}
+
+	merged := rows.mergeByKey()
+	if len(merged) != len(expected) {
+		t.Fatalf("mergeByKey(): expected %v, actual %v", expected, merged)
+	}
+	for i := range merged {
+		if merged[i] != expected[i] {
+			t.Errorf("mergeByKey(): expected %v, actual %v", expected[i], merged[i])
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	ctx.SetWantRelativeStats(true)
+	source := &MemorySource{Rows: Rows{
+		{Name: "db.t1", Index: "PRIMARY", SumTimerWait: 10, CountStar: 2},
+		{Name: "db.t1", Index: "idx_a", SumTimerWait: 4, CountStar: 1},
+		{Name: "db.t1", Index: "", SumTimerWait: 50, CountStar: 1},
+	}}
+	iu := NewIndexUsageWithSource(ctx, source)
+
+	iu.Collect()
+	source.Rows = Rows{
+		{Name: "db.t1", Index: "PRIMARY", SumTimerWait: 15, CountStar: 3},
+		{Name: "db.t1", Index: "idx_a", SumTimerWait: 4, CountStar: 1},
+		{Name: "db.t1", Index: "", SumTimerWait: 90, CountStar: 2},
+	}
+	iu.Collect()
+
+	// idx_a was used before we started watching but not since
+	if unused := iu.Unused(); unused != 1 {
+		t.Errorf("Unused(): expected 1 unused index, actual %d", unused)
+	}
+	if expected := (Row{Name: "Totals", SumTimerWait: 45, CountStar: 2}); iu.Totals != expected {
+		t.Errorf("Collect(): expected totals %v, actual %v", expected, iu.Totals)
+	}
+
+	ctx.SetWantRelativeStats(false)
+	iu.Collect()
+	if unused := iu.Unused(); unused != 0 {
+		t.Errorf("Unused(): expected no unused indexes since MySQL started, actual %d", unused)
+	}
+}
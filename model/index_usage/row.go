@@ -0,0 +1,79 @@
+// Package index_usage contains the routines for managing
+// performance_schema.table_io_waits_summary_by_index_usage.
+package index_usage
+
+// Row contains a row from table_io_waits_summary_by_index_usage
+type Row struct {
+	Name  string // the generated table name, munged if needed
+	Index string // the index name, empty if no index was used (a full table scan)
+
+	SumTimerWait   uint64
+	SumTimerFetch  uint64
+	SumTimerInsert uint64
+	SumTimerUpdate uint64
+	SumTimerDelete uint64
+
+	CountStar   uint64
+	CountFetch  uint64
+	CountInsert uint64
+	CountUpdate uint64
+	CountDelete uint64
+}
+
+// key returns the value identifying the row, the table and index
+func (row Row) key() string {
+	return row.Name + " " + row.Index
+}
+
+// add the values from another row to this one
+func (row *Row) add(other Row) {
+	row.SumTimerWait += other.SumTimerWait
+	row.SumTimerFetch += other.SumTimerFetch
+	row.SumTimerInsert += other.SumTimerInsert
+	row.SumTimerUpdate += other.SumTimerUpdate
+	row.SumTimerDelete += other.SumTimerDelete
+
+	row.CountStar += other.CountStar
+	row.CountFetch += other.CountFetch
+	row.CountInsert += other.CountInsert
+	row.CountUpdate += other.CountUpdate
+	row.CountDelete += other.CountDelete
+}
+
+// subtract the countable values in one row from another
+func (row *Row) subtract(other Row) {
+	row.SumTimerWait -= other.SumTimerWait
+	row.SumTimerFetch -= other.SumTimerFetch
+	row.SumTimerInsert -= other.SumTimerInsert
+	row.SumTimerUpdate -= other.SumTimerUpdate
+	row.SumTimerDelete -= other.SumTimerDelete
+
+	row.CountStar -= other.CountStar
+	row.CountFetch -= other.CountFetch
+	row.CountInsert -= other.CountInsert
+	row.CountUpdate -= other.CountUpdate
+	row.CountDelete -= other.CountDelete
+}
+
+// HasData indicates if there is data in the row (for counting valid rows)
+func (row *Row) HasData() bool {
+	return row != nil && row.SumTimerWait > 0
+}
+
+// FullScan returns true if the row counts the accesses to the
+// table made without using an index
+func (row Row) FullScan() bool {
+	return row.Index == "" && row.Name != "Totals"
+}
+
+// Unused returns true if the index has not been used so it may be a
+// candidate for removal. The primary key is never a candidate.
+func (row Row) Unused() bool {
+	return row.Index != "" && row.Index != "PRIMARY" && row.CountStar == 0
+}
+
+// decreased returns true if the counters have gone down since the
+// previous values were collected so the row has been truncated.
+func (row Row) decreased(previous Row) bool {
+	return row.SumTimerWait < previous.SumTimerWait || row.CountStar < previous.CountStar
+}
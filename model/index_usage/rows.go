@@ -0,0 +1,155 @@
+// Package index_usage contains the routines for managing
+// performance_schema.table_io_waits_summary_by_index_usage.
+package index_usage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/logger"
+	"github.com/sjmudd/ps-top/model/filter"
+	"github.com/sjmudd/ps-top/rc"
+)
+
+// Rows contains a set of rows
+type Rows []Row
+
+func (rows Rows) totals() Row {
+	var totals Row
+	totals.Name = "Totals"
+
+	for i := range rows {
+		totals.add(rows[i])
+	}
+
+	return totals
+}
+
+func collect(ctx context.Context, dbh *sql.DB, databaseFilter *filter.DatabaseFilter) (Rows, error) {
+	var t Rows
+
+	logger.Printf("collect(?,%q)\n", databaseFilter)
+
+	// indexes without activity are collected too as they may not be needed
+	query := `SELECT OBJECT_SCHEMA, OBJECT_NAME, INDEX_NAME, COUNT_STAR, SUM_TIMER_WAIT, COUNT_FETCH, SUM_TIMER_FETCH, COUNT_INSERT, SUM_TIMER_INSERT, COUNT_UPDATE, SUM_TIMER_UPDATE, COUNT_DELETE, SUM_TIMER_DELETE FROM table_io_waits_summary_by_index_usage WHERE OBJECT_SCHEMA NOT IN ('mysql', 'performance_schema', 'information_schema', 'sys')`
+	args := []interface{}{}
+
+	// Apply the filter if provided and seems good.
+	if len(databaseFilter.Args()) > 0 {
+		query = query + databaseFilter.ExtraSQL()
+		for _, v := range databaseFilter.Args() {
+			args = append(args, v)
+		}
+		logger.Printf("apply databaseFilter: sql: %q, args: %+v\n", query, args)
+	}
+
+	rows, err := dbh.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schema, table string
+		var index sql.NullString
+		var r Row
+		if err := rows.Scan(
+			&schema,
+			&table,
+			&index,
+			&r.CountStar,
+			&r.SumTimerWait,
+			&r.CountFetch,
+			&r.SumTimerFetch,
+			&r.CountInsert,
+			&r.SumTimerInsert,
+			&r.CountUpdate,
+			&r.SumTimerUpdate,
+			&r.CountDelete,
+			&r.SumTimerDelete); err != nil {
+			return nil, err
+		}
+		r.Name = rc.Munge(lib.TableName(schema, table))
+		r.Index = index.String // NULL if no index was used
+
+		t = append(t, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return t.mergeByKey(), nil
+}
+
+// mergeByKey combines the rows which have the same table and index
+// which happens if the table names are munged, e.g. for partitions.
+func (rows Rows) mergeByKey() Rows {
+	var merged Rows
+	byKey := make(map[string]int)
+
+	for i := range rows {
+		key := rows[i].key()
+		if j, ok := byKey[key]; ok {
+			merged[j].add(rows[i])
+			continue
+		}
+		byKey[key] = len(merged)
+		merged = append(merged, rows[i])
+	}
+
+	return merged
+}
+
+// remove the initial values from those rows where there's a match
+// - if we find a row we can't match ignore it
+func (rows *Rows) subtract(initial Rows) {
+	initialByKey := make(map[string]int)
+
+	// iterate over rows by key
+	for i := range initial {
+		initialByKey[initial[i].key()] = i
+	}
+
+	for i := range *rows {
+		if initialIndex, ok := initialByKey[(*rows)[i].key()]; ok {
+			(*rows)[i].subtract(initial[initialIndex])
+		}
+	}
+}
+
+// if the data in t2 is "newer", "has more values" than t then it needs refreshing.
+// check this by comparing totals.
+func (rows Rows) needsRefresh(otherRows Rows) bool {
+	myTotals := rows.totals()
+	otherTotals := otherRows.totals()
+
+	return myTotals.SumTimerWait > otherTotals.SumTimerWait
+}
+
+// counterRows gives baseobject.Compensate access to the rows by key
+type counterRows struct {
+	rows *Rows
+}
+
+func (c counterRows) Len() int              { return len(*c.rows) }
+func (c counterRows) Key(i int) string      { return (*c.rows)[i].key() }
+func (c counterRows) Row(i int) interface{} { return (*c.rows)[i] }
+func (c counterRows) Decreased(i int, previous interface{}) bool {
+	return (*c.rows)[i].decreased(previous.(Row))
+}
+func (c counterRows) Add(i int, offset interface{}) { (*c.rows)[i].add(offset.(Row)) }
+func (c counterRows) Append(row interface{})        { *c.rows = append(*c.rows, row.(Row)) }
+
+// unused returns the number of indexes which have not been used
+func (rows Rows) unused() int {
+	var count int
+
+	for i := range rows {
+		if rows[i].Unused() {
+			count++
+		}
+	}
+
+	return count
+}
@@ -4,12 +4,12 @@ package memory_usage
 
 import (
 	"database/sql"
-	"time"
 
 	_ "github.com/go-sql-driver/mysql" // keep golint happy
 
 	"github.com/sjmudd/ps-top/baseobject"
 	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/logger"
 )
 
@@ -19,13 +19,19 @@ type MemoryUsage struct {
 	last                  Rows // last loaded values
 	Results               Rows // results (maybe with subtraction)
 	Totals                Row  // totals of results
-	db                    *sql.DB
+	source                Source
 }
 
+// NewMemoryUsage returns a MemoryUsage collecting data from MySQL using the given db handle
 func NewMemoryUsage(ctx *context.Context, db *sql.DB) *MemoryUsage {
+	return NewMemoryUsageWithSource(ctx, NewMySQLSource(db))
+}
+
+// NewMemoryUsageWithSource returns a MemoryUsage collecting data from the given source
+func NewMemoryUsageWithSource(ctx *context.Context, source Source) *MemoryUsage {
 	logger.Println("NewMemoryUsage()")
 	mu := &MemoryUsage{
-		db: db,
+		source: source,
 	}
 	mu.SetContext(ctx)
 
@@ -33,11 +39,20 @@ func NewMemoryUsage(ctx *context.Context, db *sql.DB) *MemoryUsage {
 }
 
 // Collect data from the db, no merging needed
-func (mu *MemoryUsage) Collect() {
-	mu.last = collect(mu.db)
-	mu.SetLastCollectTime(time.Now())
+func (mu *MemoryUsage) Collect() error {
+	var collected Rows
+	if err := mu.CollectFrom("memory_usage", &collected, func() (err error) {
+		collected, err = mu.source.Collect(mu.QueryContext())
+		return err
+	}, func() {
+		mu.last = collected
+
+		mu.makeResults()
+	}); err != nil {
+		return err
+	}
 
-	mu.makeResults()
+	return nil
 }
 
 // SetFirstFromLast resets the statistics to current values
@@ -67,6 +82,17 @@ func (mu MemoryUsage) Len() int {
 	return len(mu.Results)
 }
 
+// Rate returns the value per second since the server started if we want to
+// see rates, otherwise the value is returned unchanged.  Values are never
+// relative so the time the server has been running is used.
+func (mu MemoryUsage) Rate(value int64) int64 {
+	if !mu.WantRates() || value < 0 {
+		return value
+	}
+	return int64(lib.PerSecond(uint64(value), mu.ServerElapsed()))
+}
+
+// HaveRelativeStats is false for this object
 func (mu MemoryUsage) HaveRelativeStats() bool {
 	return false
 }
@@ -3,10 +3,9 @@
 package memory_usage
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
 	_ "github.com/go-sql-driver/mysql" // keep glint happy
-	"log"
 
 	"github.com/sjmudd/ps-top/logger"
 )
@@ -26,28 +25,9 @@ func (t Rows) totals() Row {
 	return totals
 }
 
-// catch a SELECT error - specifically this one.
-// Error 1146: Table 'performance_schema.memory_summary_global_by_event_name' doesn't exist
-func sqlErrorHandler(err error) bool {
-	var ignore bool
-
-	logger.Println("- SELECT gave an error:", err.Error())
-	if err.Error()[0:11] != "Error 1146:" {
-		fmt.Println(fmt.Sprintf("XXX'%s'XXX", err.Error()[0:11]))
-		log.Fatal("Unexpected error", fmt.Sprintf("XXX'%s'XXX", err.Error()[0:11]))
-		// log.Fatal("Unexpected error:", err.Error())
-	} else {
-		logger.Println("- expected error, so ignoring")
-		ignore = true
-	}
-
-	return ignore
-}
-
 // Select the raw data from the database
-func collect(dbh *sql.DB) Rows {
+func collect(ctx context.Context, dbh *sql.DB) (Rows, error) {
 	var t Rows
-	var skip bool
 
 	sql := `-- memory_usage
 SELECT	EVENT_NAME                                           AS eventName,
@@ -61,38 +41,31 @@ FROM	memory_summary_global_by_event_name
 WHERE	HIGH_COUNT_USED > 0`
 
 	logger.Println("Querying db:", sql)
-	rows, err := dbh.Query(sql)
+	rows, err := dbh.QueryContext(ctx, sql)
 	if err != nil {
-		// FIXME - This should be caught by the validateViews() upstream but isn't for initial
-		// FIXME   table collection. I'm waiting to clean up by splitting views and models but
-		// FIXME   that has not been done yet so for now work aruond the initial app.CollectAll()
-		// FIXME   by simply ignoring a request if the table does not exist.
-		skip = sqlErrorHandler(err) // temporarily catch a SELECT error. // should not be necessary now
+		return nil, err
 	}
-
-	if !skip {
-		defer rows.Close()
-
-		for rows.Next() {
-			var r Row
-			if err := rows.Scan(
-				&r.Name,
-				&r.CurrentCountUsed,
-				&r.HighCountUsed,
-				&r.CurrentBytesUsed,
-				&r.HighBytesUsed,
-				&r.TotalMemoryOps,
-				&r.TotalBytesManaged); err != nil {
-				log.Fatal(err)
-			}
-			t = append(t, r)
-		}
-		if err := rows.Err(); err != nil {
-			log.Fatal(err)
+	defer rows.Close()
+
+	for rows.Next() {
+		var r Row
+		if err := rows.Scan(
+			&r.Name,
+			&r.CurrentCountUsed,
+			&r.HighCountUsed,
+			&r.CurrentBytesUsed,
+			&r.HighBytesUsed,
+			&r.TotalMemoryOps,
+			&r.TotalBytesManaged); err != nil {
+			return nil, err
 		}
+		t = append(t, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return t
+	return t, nil
 }
 
 // remove the initial values from those rows where there's a match
@@ -19,17 +19,22 @@ type MutexLatency struct {
 	last                  Rows // last loaded values
 	Results               Rows // results (maybe with subtraction)
 	Totals                Row  // totals of results
-	db                    *sql.DB
+	source                Source
 }
 
-// NewMutexLatency returns a mutex latency object using given context and db
+// NewMutexLatency returns a MutexLatency collecting data from MySQL using the given db handle
 func NewMutexLatency(ctx *context.Context, db *sql.DB) *MutexLatency {
+	return NewMutexLatencyWithSource(ctx, NewMySQLSource(db))
+}
+
+// NewMutexLatencyWithSource returns a mutex latency object using given context and db
+func NewMutexLatencyWithSource(ctx *context.Context, source Source) *MutexLatency {
 	logger.Println("NewMutexLatency()")
 	if ctx == nil {
 		log.Println("NewMutexLatency() ctx == nil!")
 	}
 	ml := &MutexLatency{
-		db: db,
+		source: source,
 	}
 	ml.SetContext(ctx)
 
@@ -45,41 +50,60 @@ func (ml *MutexLatency) updateFirstFromLast() {
 // Collect collects data from the db, updating first
 // values if needed, and then subtracting first values if we want
 // relative values, after which it stores totals.
-func (ml *MutexLatency) Collect() {
+func (ml *MutexLatency) Collect() error {
 	start := time.Now()
 	// logger.Println("MutexLatency.Collect() BEGIN")
-	ml.last = collect(ml.db)
-	ml.SetLastCollectTime(time.Now())
-
-	logger.Println("t.current collected", len(ml.last), "row(s) from SELECT")
-
-	if len(ml.first) == 0 && len(ml.last) > 0 {
-		logger.Println("ml.first: copying from ml.last (initial setup)")
-		ml.updateFirstFromLast()
-	}
-
-	// check for reload initial characteristics
-	if ml.first.needsRefresh(ml.last) {
-		logger.Println("ml.first: copying from ml.last (data needs refreshing)")
-		ml.updateFirstFromLast()
+	var collected Rows
+	if err := ml.CollectFrom("mutex_latency", &collected, func() (err error) {
+		collected, err = ml.source.Collect(ml.QueryContext())
+		return err
+	}, func() {
+		if ml.Compensate(counterRows{&collected}) {
+			logger.Println("MutexLatency.Collect() truncation detected")
+			ml.SetTruncated(ml.LastCollectTime())
+		}
+		ml.last = collected
+
+		logger.Println("t.current collected", len(ml.last), "row(s) from SELECT")
+
+		if len(ml.first) == 0 && len(ml.last) > 0 {
+			logger.Println("ml.first: copying from ml.last (initial setup)")
+			ml.updateFirstFromLast()
+		}
+
+		// check for reload initial characteristics
+		if ml.first.needsRefresh(ml.last) {
+			logger.Println("ml.first: copying from ml.last (data needs refreshing)")
+			ml.updateFirstFromLast()
+			ml.ResetSnapshots()
+		}
+		ml.AddSnapshot(ml.last)
+
+		ml.makeResults()
+
+		// logger.Println( "t.initial:", t.initial )
+		// logger.Println( "t.current:", t.current )
+		logger.Println("t.initial.totals():", ml.first.totals())
+		logger.Println("t.current.totals():", ml.last.totals())
+		// logger.Println("t.results:", ml.Results)
+		// logger.Println("t.totals:", ml.Totals)
+	}); err != nil {
+		return err
 	}
-
-	ml.makeResults()
-
-	// logger.Println( "t.initial:", t.initial )
-	// logger.Println( "t.current:", t.current )
-	logger.Println("t.initial.totals():", ml.first.totals())
-	logger.Println("t.current.totals():", ml.last.totals())
-	// logger.Println("t.results:", ml.Results)
-	// logger.Println("t.totals:", ml.Totals)
 	logger.Println("MutexLatency.Collect() END, took:", time.Duration(time.Since(start)).String())
+
+	return nil
 }
 
 func (ml *MutexLatency) makeResults() {
 	// logger.Println( "- t.results set from t.current" )
 	ml.Results = make(Rows, len(ml.last))
 	copy(ml.Results, ml.last)
-	if ml.WantRelativeStats() {
+	if ml.WantWindowStats() {
+		if baseline, ok := ml.WindowBaseline(); ok {
+			ml.Results.subtract(baseline.(Rows))
+		}
+	} else if ml.WantRelativeStats() {
 		// logger.Println( "- subtracting t.initial from t.results as WantRelativeStats()" )
 		ml.Results.subtract(ml.first)
 	}
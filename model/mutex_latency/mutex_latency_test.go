@@ -0,0 +1,115 @@
+package mutex_latency
+
+import (
+	gocontext "context"
+	"testing"
+	"time"
+
+	"github.com/sjmudd/ps-top/baseobject"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+func TestRowsSubtract(t *testing.T) {
+	var tests = []struct {
+		rows     Rows
+		initial  Rows
+		expected Rows
+	}{
+		{
+			Rows{{"trx_mutex", 10, 5}, {"lock_mutex", 8, 4}},
+			Rows{{"lock_mutex", 2, 1}, {"trx_mutex", 4, 2}},
+			Rows{{"trx_mutex", 6, 3}, {"lock_mutex", 6, 3}},
+		},
+		{
+			// unmatched rows are left alone
+			Rows{{"trx_mutex", 10, 5}},
+			Rows{{"other_mutex", 4, 2}},
+			Rows{{"trx_mutex", 10, 5}},
+		},
+		{
+			// a row going backwards is not subtracted
+			Rows{{"trx_mutex", 3, 5}},
+			Rows{{"trx_mutex", 4, 2}},
+			Rows{{"trx_mutex", 3, 5}},
+		},
+	}
+
+	for _, test := range tests {
+		rows := make(Rows, len(test.rows))
+		copy(rows, test.rows)
+		rows.subtract(test.initial)
+		for i := range rows {
+			if rows[i] != test.expected[i] {
+				t.Errorf("%v.subtract(%v): expected %v, actual %v", test.rows, test.initial, test.expected, rows)
+			}
+		}
+	}
+}
+
+func TestTotalsAndNeedsRefresh(t *testing.T) {
+	var tests = []struct {
+		first        Rows
+		last         Rows
+		totals       Row // of last
+		needsRefresh bool
+	}{
+		{nil, nil, Row{"Totals", 0, 0}, false},
+		{Rows{{"a", 1, 1}}, Rows{{"a", 3, 2}, {"b", 4, 1}}, Row{"Totals", 7, 3}, false},
+		{Rows{{"a", 10, 1}}, Rows{{"a", 3, 2}, {"b", 4, 1}}, Row{"Totals", 7, 3}, true},
+	}
+
+	for _, test := range tests {
+		if got := test.last.totals(); got != test.totals {
+			t.Errorf("%v.totals(): expected %v, actual %v", test.last, test.totals, got)
+		}
+		if got := test.first.needsRefresh(test.last); got != test.needsRefresh {
+			t.Errorf("%v.needsRefresh(%v): expected %v, actual %v", test.first, test.last, test.needsRefresh, got)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	ctx.SetWantRelativeStats(true)
+	source := &MemorySource{Rows: Rows{{"trx_mutex", 10, 5}}}
+	ml := NewMutexLatencyWithSource(ctx, source)
+
+	ml.Collect()
+	source.Rows = Rows{{"trx_mutex", 15, 6}, {"lock_mutex", 2, 1}}
+	ml.Collect()
+
+	if expected := (Row{"Totals", 7, 2}); ml.Totals != expected {
+		t.Errorf("Collect(): expected totals %v, actual %v", expected, ml.Totals)
+	}
+
+	ml.SetFirstFromLast()
+	if expected := (Row{"Totals", 0, 0}); ml.Totals != expected {
+		t.Errorf("SetFirstFromLast(): expected totals %v, actual %v", expected, ml.Totals)
+	}
+}
+
+// hangingSource is a Source whose collections only finish when cancelled
+type hangingSource struct{}
+
+func (hangingSource) Collect(ctx gocontext.Context) (Rows, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCollectTimeout(t *testing.T) {
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	ctx.SetQueryTimeout(10 * time.Millisecond)
+	ml := NewMutexLatencyWithSource(ctx, hangingSource{})
+
+	err := ml.Collect()
+	expected := baseobject.TimeoutError{Name: "mutex_latency", Timeout: 10 * time.Millisecond}
+	if err != expected {
+		t.Errorf("Collect(): expected error %v, actual %v", expected, err)
+	}
+	if !ml.LastCollectTime().IsZero() {
+		t.Errorf("Collect(): expected no collection time after timing out, actual %v", ml.LastCollectTime())
+	}
+}
@@ -31,3 +31,9 @@ func (row *Row) subtract(other Row) {
 		logger.Println("other=", other)
 	}
 }
+
+// decreased returns true if the counters have gone down since the
+// previous values were collected so the row has been truncated.
+func (row Row) decreased(previous Row) bool {
+	return row.SumTimerWait < previous.SumTimerWait || row.CountStar < previous.CountStar
+}
@@ -3,8 +3,8 @@
 package mutex_latency
 
 import (
+	"context"
 	"database/sql"
-	"log"
 )
 
 // Rows contains a slice of Row
@@ -21,15 +21,15 @@ func (rows Rows) totals() Row {
 	return totals
 }
 
-func collect(dbh *sql.DB) Rows {
+func collect(ctx context.Context, dbh *sql.DB) (Rows, error) {
 	var t Rows
 
 	// we collect all information even if it's mainly empty as we may reference it later
 	sql := "SELECT EVENT_NAME, SUM_TIMER_WAIT, COUNT_STAR FROM events_waits_summary_global_by_event_name WHERE SUM_TIMER_WAIT > 0 AND EVENT_NAME LIKE 'wait/synch/mutex/innodb/%'"
 
-	rows, err := dbh.Query(sql)
+	rows, err := dbh.QueryContext(ctx, sql)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -39,7 +39,7 @@ func collect(dbh *sql.DB) Rows {
 			&r.Name,
 			&r.SumTimerWait,
 			&r.CountStar); err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
 		// trim off the leading 'wait/synch/mutex/innodb/'
@@ -51,10 +51,10 @@ func collect(dbh *sql.DB) Rows {
 		t = append(t, r)
 	}
 	if err := rows.Err(); err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	return t
+	return t, nil
 }
 
 // remove the initial values from those rows where there's a match
@@ -84,3 +84,17 @@ func (rows Rows) needsRefresh(otherRows Rows) bool {
 
 	return totals.SumTimerWait > otherTotals.SumTimerWait
 }
+
+// counterRows gives baseobject.Compensate access to the rows by name
+type counterRows struct {
+	rows *Rows
+}
+
+func (c counterRows) Len() int              { return len(*c.rows) }
+func (c counterRows) Key(i int) string      { return (*c.rows)[i].Name }
+func (c counterRows) Row(i int) interface{} { return (*c.rows)[i] }
+func (c counterRows) Decreased(i int, previous interface{}) bool {
+	return (*c.rows)[i].decreased(previous.(Row))
+}
+func (c counterRows) Add(i int, offset interface{}) { (*c.rows)[i].add(offset.(Row)) }
+func (c counterRows) Append(row interface{})        { *c.rows = append(*c.rows, row.(Row)) }
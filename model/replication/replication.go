@@ -0,0 +1,111 @@
+// Package replication contains the library routines for managing the
+// replication_applier_status_by_worker, replication_applier_status_by_coordinator
+// and replication_connection_status tables.
+package replication
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sjmudd/ps-top/baseobject"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Replication holds the status of the replication threads
+type Replication struct {
+	baseobject.BaseObject      // embedded
+	first                 Rows // initial data for relative values
+	last                  Rows // last loaded values
+	Results               Rows // results (maybe with subtraction)
+	Totals                Row  // totals of results
+	source                Source
+}
+
+// NewReplication returns a Replication collecting data from MySQL using the given db handle
+func NewReplication(ctx *context.Context, db *sql.DB) *Replication {
+	return NewReplicationWithSource(ctx, NewMySQLSource(db, ctx.Capabilities()))
+}
+
+// NewReplicationWithSource returns a Replication collecting data from the given source
+func NewReplicationWithSource(ctx *context.Context, source Source) *Replication {
+	logger.Println("NewReplication()")
+	r := &Replication{
+		source: source,
+	}
+	r.SetContext(ctx)
+
+	return r
+}
+
+func (r *Replication) updateFirstFromLast() {
+	r.first = make(Rows, len(r.last))
+	r.SetFirstCollectTime(r.LastCollectTime())
+	copy(r.first, r.last)
+}
+
+// Collect collects data from the db, updating first
+// values if needed, and then subtracting first values if we want
+// relative values, after which it stores totals.
+func (r *Replication) Collect() error {
+	start := time.Now()
+	var collected Rows
+	if err := r.CollectFrom("replication", &collected, func() (err error) {
+		collected, err = r.source.Collect(r.QueryContext())
+		return err
+	}, func() {
+		if r.Compensate(counterRows{&collected}) {
+			logger.Println("Replication.Collect() thread restart or truncation detected")
+			r.SetTruncated(r.LastCollectTime())
+		}
+		r.last = collected
+		logger.Println("r.last collected", len(r.last), "row(s) from SELECT")
+
+		if len(r.first) == 0 && len(r.last) > 0 {
+			logger.Println("r.first: copying from r.last (initial setup)")
+			r.updateFirstFromLast()
+		}
+
+		// check for reload initial characteristics
+		if r.first.needsRefresh(r.last) {
+			logger.Println("r.first: copying from r.last (data needs refreshing)")
+			r.updateFirstFromLast()
+			r.ResetSnapshots()
+		}
+		r.AddSnapshot(r.last)
+
+		r.makeResults()
+	}); err != nil {
+		return err
+	}
+	logger.Println("Replication.Collect() END, took:", time.Duration(time.Since(start)).String())
+
+	return nil
+}
+
+// makeResults generates the results, only the transactions applied
+// are subtracted as the other values show the current state.
+func (r *Replication) makeResults() {
+	r.Results = make(Rows, len(r.last))
+	copy(r.Results, r.last)
+	if r.WantWindowStats() {
+		if baseline, ok := r.WindowBaseline(); ok {
+			r.Results.subtract(baseline.(Rows))
+		}
+	} else if r.WantRelativeStats() {
+		r.Results.subtract(r.first)
+	}
+
+	r.Totals = r.Results.totals()
+}
+
+// SetFirstFromLast resets the statistics to current values
+func (r *Replication) SetFirstFromLast() {
+	r.updateFirstFromLast()
+	r.makeResults()
+}
+
+// HaveRelativeStats is true for this object
+func (r Replication) HaveRelativeStats() bool {
+	return true
+}
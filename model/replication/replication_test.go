@@ -0,0 +1,100 @@
+package replication
+
+import (
+	"testing"
+
+	"github.com/sjmudd/ps-top/capabilities"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+// worker returns a running worker row of the default channel
+func worker(id, applied, lag uint64) Row {
+	return Row{
+		Name:            name("", false, id),
+		WorkerID:        id,
+		State:           "ON",
+		ConnectionState: "ON",
+		ApplyLag:        lag,
+		Applied:         applied,
+	}
+}
+
+func TestName(t *testing.T) {
+	var tests = []struct {
+		channel     string
+		coordinator bool
+		workerID    uint64
+		expected    string
+	}{
+		{"", false, 1, "(default) worker 1"},
+		{"", true, 0, "(default) coordinator"},
+		{"source2", false, 3, "source2 worker 3"},
+	}
+
+	for _, test := range tests {
+		if got := name(test.channel, test.coordinator, test.workerID); got != test.expected {
+			t.Errorf("name(%q, %v, %d): expected %q, actual %q", test.channel, test.coordinator, test.workerID, test.expected, got)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	ctx.SetWantRelativeStats(true)
+	source := &MemorySource{Rows: Rows{worker(1, 10, 0), worker(2, 20, 0)}}
+	r := NewReplicationWithSource(ctx, source)
+
+	r.Collect()
+	source.Rows = Rows{worker(1, 15, 3e12), worker(2, 27, 5e12)}
+	r.Collect()
+
+	// only the transactions applied are relative
+	for i, expected := range []uint64{5, 7} {
+		if r.Results[i].Applied != expected {
+			t.Errorf("Collect(): expected %s to have applied %d, actual %d", r.Results[i].Name, expected, r.Results[i].Applied)
+		}
+	}
+	if r.Results[1].ApplyLag != 5e12 || r.Results[1].State != "ON" {
+		t.Errorf("Collect(): expected the current state to be kept, actual %+v", r.Results[1])
+	}
+	if r.Totals.Applied != 12 || r.Totals.ApplyLag != 5e12 {
+		t.Errorf("Collect(): expected totals of 12 applied with the largest lag, actual %+v", r.Totals)
+	}
+
+	// a restarted worker's counter is compensated for
+	source.Rows = Rows{worker(1, 2, 0), worker(2, 30, 0)}
+	r.Collect()
+	if r.Results[0].Applied != 7 {
+		t.Errorf("Collect(): expected the restarted worker to have applied 7, actual %d", r.Results[0].Applied)
+	}
+	if r.LastTruncation().IsZero() {
+		t.Errorf("Collect(): expected the worker restart to be noticed")
+	}
+
+	r.SetFirstFromLast()
+	if r.Totals.Applied != 0 {
+		t.Errorf("SetFirstFromLast(): expected no transactions applied, actual %d", r.Totals.Applied)
+	}
+}
+
+func TestNewMySQLSource(t *testing.T) {
+	var tests = []struct {
+		version  string
+		comment  string
+		expected bool
+	}{
+		{"5.7.32-35-log", "Percona Server (GPL), Release 35", true},
+		{"8.0.22", "MySQL Community Server - GPL", false},
+		{"8.0.22-13", "Percona Server (GPL), Release 13", false},
+		{"10.5.8-MariaDB", "mariadb.org binary distribution", true},
+	}
+
+	for _, test := range tests {
+		if got := NewMySQLSource(nil, capabilities.New(test.version, test.comment)).mysql57; got != test.expected {
+			t.Errorf("NewMySQLSource(%q): expected mysql57 %v, actual %v", test.version, test.expected, got)
+		}
+	}
+}
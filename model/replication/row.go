@@ -0,0 +1,60 @@
+// Package replication contains the library routines for managing the
+// replication_applier_status_by_worker, replication_applier_status_by_coordinator
+// and replication_connection_status tables.
+package replication
+
+import (
+	"fmt"
+
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Row contains the status of a replication coordinator or worker thread
+type Row struct {
+	Name            string // channel and thread, e.g. "(default) worker 1"
+	Channel         string // replication channel name
+	Coordinator     bool   // true for the coordinator, false for a worker
+	WorkerID        uint64 // worker id (0 for the coordinator)
+	ThreadID        uint64 // performance_schema thread id (0 if not running)
+	State           string // applier service state: ON or OFF
+	ConnectionState string // connection (I/O thread) state: ON, OFF or CONNECTING
+	LastTransaction string // last transaction applied (or seen with 5.7)
+	ApplyLag        uint64 // picoseconds between the transaction's original commit and being applied
+	ErrorNumber     uint64 // last error number (0 if none)
+	ErrorMessage    string // last error message
+	Applied         uint64 // number of transactions applied by the thread
+}
+
+// name returns the name used to identify the row
+func name(channel string, coordinator bool, workerID uint64) string {
+	if channel == "" {
+		channel = "(default)"
+	}
+	if coordinator {
+		return channel + " coordinator"
+	}
+	return fmt.Sprintf("%s worker %d", channel, workerID)
+}
+
+func (row *Row) add(other Row) {
+	row.Applied += other.Applied
+}
+
+// subtract the countable values in one row from another
+func (row *Row) subtract(other Row) {
+	// check for issues here (we have a bug) and log it
+	// - this situation should not happen so there's a logic bug somewhere else
+	if row.Applied >= other.Applied {
+		row.Applied -= other.Applied
+	} else {
+		logger.Println("WARNING: Row.subtract() - subtraction problem! (not subtracting)")
+		logger.Println("row=", row)
+		logger.Println("other=", other)
+	}
+}
+
+// decreased returns true if the counters have gone down since the
+// previous values were collected, for example if the thread restarted.
+func (row Row) decreased(previous Row) bool {
+	return row.Applied < previous.Applied
+}
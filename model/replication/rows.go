@@ -0,0 +1,187 @@
+// Package replication contains the library routines for managing the
+// replication_applier_status_by_worker, replication_applier_status_by_coordinator
+// and replication_connection_status tables.
+package replication
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Rows contains a slice of Row
+type Rows []Row
+
+// lag returns the SQL giving the lag in picoseconds between a transaction's
+// original commit and the given time, or 0 if the transaction is not known.
+func lag(originalCommit, applied string) string {
+	return `CAST(GREATEST(0, CASE WHEN UNIX_TIMESTAMP(` + originalCommit + `) > 0
+		THEN UNIX_TIMESTAMP(` + applied + `) - UNIX_TIMESTAMP(` + originalCommit + `)
+		ELSE 0 END) * 1000000000000 AS UNSIGNED)`
+}
+
+// The worker and coordinator are collected together.  The apply lag is
+// taken from the transaction being applied if there is one, otherwise
+// from the last transaction applied.  Transactions applied are counted
+// from events_transactions_summary_by_thread_by_event_name.
+var query = `-- replication
+SELECT	w.CHANNEL_NAME,
+	0,
+	w.WORKER_ID,
+	IFNULL(w.THREAD_ID, 0),
+	w.SERVICE_STATE,
+	IFNULL(c.SERVICE_STATE, ''),
+	w.LAST_APPLIED_TRANSACTION,
+	CASE WHEN w.APPLYING_TRANSACTION <> ''
+		THEN ` + lag("w.APPLYING_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP", "NOW(6)") + `
+		ELSE ` + lag("w.LAST_APPLIED_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP", "w.LAST_APPLIED_TRANSACTION_END_APPLY_TIMESTAMP") + `
+	END,
+	w.LAST_ERROR_NUMBER,
+	w.LAST_ERROR_MESSAGE,
+	IFNULL(t.COUNT_STAR, 0)
+FROM	replication_applier_status_by_worker w
+LEFT JOIN replication_connection_status c ON c.CHANNEL_NAME = w.CHANNEL_NAME
+LEFT JOIN events_transactions_summary_by_thread_by_event_name t ON t.THREAD_ID = w.THREAD_ID AND t.EVENT_NAME = 'transaction'
+UNION ALL
+SELECT	co.CHANNEL_NAME,
+	1,
+	0,
+	IFNULL(co.THREAD_ID, 0),
+	co.SERVICE_STATE,
+	IFNULL(c.SERVICE_STATE, ''),
+	co.LAST_PROCESSED_TRANSACTION,
+	CASE WHEN co.PROCESSING_TRANSACTION <> ''
+		THEN ` + lag("co.PROCESSING_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP", "NOW(6)") + `
+		ELSE ` + lag("co.LAST_PROCESSED_TRANSACTION_ORIGINAL_COMMIT_TIMESTAMP", "co.LAST_PROCESSED_TRANSACTION_END_BUFFER_TIMESTAMP") + `
+	END,
+	co.LAST_ERROR_NUMBER,
+	co.LAST_ERROR_MESSAGE,
+	0
+FROM	replication_applier_status_by_coordinator co
+LEFT JOIN replication_connection_status c ON c.CHANNEL_NAME = co.CHANNEL_NAME`
+
+// MySQL 5.7 has no transaction timestamps so the lag can not be determined
+// and the coordinator does not show the last transaction.
+var query57 = `-- replication (5.7)
+SELECT	w.CHANNEL_NAME,
+	0,
+	w.WORKER_ID,
+	IFNULL(w.THREAD_ID, 0),
+	w.SERVICE_STATE,
+	IFNULL(c.SERVICE_STATE, ''),
+	w.LAST_SEEN_TRANSACTION,
+	0,
+	w.LAST_ERROR_NUMBER,
+	w.LAST_ERROR_MESSAGE,
+	IFNULL(t.COUNT_STAR, 0)
+FROM	replication_applier_status_by_worker w
+LEFT JOIN replication_connection_status c ON c.CHANNEL_NAME = w.CHANNEL_NAME
+LEFT JOIN events_transactions_summary_by_thread_by_event_name t ON t.THREAD_ID = w.THREAD_ID AND t.EVENT_NAME = 'transaction'
+UNION ALL
+SELECT	co.CHANNEL_NAME,
+	1,
+	0,
+	IFNULL(co.THREAD_ID, 0),
+	co.SERVICE_STATE,
+	IFNULL(c.SERVICE_STATE, ''),
+	'',
+	0,
+	co.LAST_ERROR_NUMBER,
+	co.LAST_ERROR_MESSAGE,
+	0
+FROM	replication_applier_status_by_coordinator co
+LEFT JOIN replication_connection_status c ON c.CHANNEL_NAME = co.CHANNEL_NAME`
+
+// totals returns the transactions applied by all threads and the largest lag
+func (rows Rows) totals() Row {
+	var totals Row
+	totals.Name = "Totals"
+
+	for i := range rows {
+		totals.add(rows[i])
+		if rows[i].ApplyLag > totals.ApplyLag {
+			totals.ApplyLag = rows[i].ApplyLag
+		}
+	}
+
+	return totals
+}
+
+func collect(ctx context.Context, dbh *sql.DB, query string) (Rows, error) {
+	var t Rows
+
+	logger.Println("Querying db:", query)
+	rows, err := dbh.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r Row
+		if err := rows.Scan(
+			&r.Channel,
+			&r.Coordinator,
+			&r.WorkerID,
+			&r.ThreadID,
+			&r.State,
+			&r.ConnectionState,
+			&r.LastTransaction,
+			&r.ApplyLag,
+			&r.ErrorNumber,
+			&r.ErrorMessage,
+			&r.Applied); err != nil {
+			return nil, err
+		}
+		r.Name = name(r.Channel, r.Coordinator, r.WorkerID)
+		t = append(t, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// remove the initial values from those rows where there's a match
+// - if we find a row we can't match ignore it
+func (rows *Rows) subtract(initial Rows) {
+	initialByName := make(map[string]int)
+
+	// iterate over rows by name
+	for i := range initial {
+		initialByName[initial[i].Name] = i
+	}
+
+	for i := range *rows {
+		name := (*rows)[i].Name
+		if _, ok := initialByName[name]; ok {
+			initialIndex := initialByName[name]
+			(*rows)[i].subtract(initial[initialIndex])
+		}
+	}
+}
+
+// if the data in t2 is "newer", "has more values" than t then it needs refreshing.
+// check this by comparing totals.
+func (rows Rows) needsRefresh(otherRows Rows) bool {
+	totals := rows.totals()
+	otherTotals := otherRows.totals()
+
+	return totals.Applied > otherTotals.Applied
+}
+
+// counterRows gives baseobject.Compensate access to the rows by name
+type counterRows struct {
+	rows *Rows
+}
+
+func (c counterRows) Len() int              { return len(*c.rows) }
+func (c counterRows) Key(i int) string      { return (*c.rows)[i].Name }
+func (c counterRows) Row(i int) interface{} { return (*c.rows)[i] }
+func (c counterRows) Decreased(i int, previous interface{}) bool {
+	return (*c.rows)[i].decreased(previous.(Row))
+}
+func (c counterRows) Add(i int, offset interface{}) { (*c.rows)[i].add(offset.(Row)) }
+func (c counterRows) Append(row interface{})        { *c.rows = append(*c.rows, row.(Row)) }
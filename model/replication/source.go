@@ -0,0 +1,52 @@
+package replication
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sjmudd/ps-top/capabilities"
+)
+
+// Source provides the rows collected from the replication tables
+type Source interface {
+	Collect(ctx context.Context) (Rows, error)
+}
+
+// MySQLSource is the default Source which collects the rows from MySQL
+type MySQLSource struct {
+	db      *sql.DB
+	mysql57 bool // true if the MySQL 5.7 query must be used
+}
+
+// NewMySQLSource returns a Source collecting the rows using the given db
+// handle, using the MySQL 5.7 query if the server does not have the
+// columns added in MySQL 8.0.
+func NewMySQLSource(db *sql.DB, caps capabilities.Capabilities) *MySQLSource {
+	return &MySQLSource{db: db, mysql57: !caps.Has(capabilities.ReplicationTimestamps)}
+}
+
+// Collect returns the rows collected from MySQL.
+func (s *MySQLSource) Collect(ctx context.Context) (Rows, error) {
+	if s.mysql57 {
+		return collect(ctx, s.db, query57)
+	}
+	return collect(ctx, s.db, query)
+}
+
+// MemorySource is a Source holding the rows to return in memory which
+// is useful for testing. If Err is set it is returned instead of the rows.
+type MemorySource struct {
+	Rows Rows
+	Err  error
+}
+
+// Collect returns a copy of the rows held in memory.
+func (s *MemorySource) Collect(ctx context.Context) (Rows, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	rows := make(Rows, len(s.Rows))
+	copy(rows, s.Rows)
+
+	return rows, nil
+}
@@ -1,8 +1,8 @@
 package stages_latency
 
 import (
+	"context"
 	"database/sql"
-	"log"
 
 	"github.com/sjmudd/ps-top/logger"
 )
@@ -11,15 +11,15 @@ import (
 type Rows []Row
 
 // select the rows into table
-func collect(dbh *sql.DB) Rows {
+func collect(ctx context.Context, dbh *sql.DB) (Rows, error) {
 	var t Rows
 
 	logger.Println("events_stages_summary_global_by_event_name.collect()")
 	sql := "SELECT EVENT_NAME, COUNT_STAR, SUM_TIMER_WAIT FROM events_stages_summary_global_by_event_name WHERE SUM_TIMER_WAIT > 0"
 
-	rows, err := dbh.Query(sql)
+	rows, err := dbh.QueryContext(ctx, sql)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -29,7 +29,7 @@ func collect(dbh *sql.DB) Rows {
 			&r.Name,
 			&r.CountStar,
 			&r.SumTimerWait); err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
 		// convert the stage name, removing any leading stage/sql/
@@ -40,12 +40,12 @@ func collect(dbh *sql.DB) Rows {
 		t = append(t, r)
 	}
 	if err := rows.Err(); err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	logger.Println("recovered", len(t), "row(s):")
 	logger.Println(t)
 
-	return t
+	return t, nil
 }
 
 // if the data in t2 is "newer", "has more values" than t then it needs refreshing.
@@ -87,3 +87,17 @@ func (rows *Rows) subtract(initial Rows) {
 		}
 	}
 }
+
+// counterRows gives baseobject.Compensate access to the rows by name
+type counterRows struct {
+	rows *Rows
+}
+
+func (c counterRows) Len() int              { return len(*c.rows) }
+func (c counterRows) Key(i int) string      { return (*c.rows)[i].Name }
+func (c counterRows) Row(i int) interface{} { return (*c.rows)[i] }
+func (c counterRows) Decreased(i int, previous interface{}) bool {
+	return (*c.rows)[i].decreased(previous.(Row))
+}
+func (c counterRows) Add(i int, offset interface{}) { (*c.rows)[i].add(offset.(Row)) }
+func (c counterRows) Append(row interface{})        { *c.rows = append(*c.rows, row.(Row)) }
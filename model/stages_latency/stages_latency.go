@@ -50,7 +50,7 @@ type StagesLatency struct {
 	last                  Rows // last loaded values
 	Results               Rows // results (maybe with subtraction)
 	Totals                Row  // totals of results
-	db                    *sql.DB
+	source                Source
 }
 
 func (sl *StagesLatency) updateFirstFromLast() {
@@ -59,11 +59,16 @@ func (sl *StagesLatency) updateFirstFromLast() {
 	copy(sl.first, sl.last)
 }
 
-// NewStagesLatency returns a stages_latency StagesLatency
+// NewStagesLatency returns a StagesLatency collecting data from MySQL using the given db handle
 func NewStagesLatency(ctx *context.Context, db *sql.DB) *StagesLatency {
+	return NewStagesLatencyWithSource(ctx, NewMySQLSource(db))
+}
+
+// NewStagesLatencyWithSource returns a stages_latency StagesLatency
+func NewStagesLatencyWithSource(ctx *context.Context, source Source) *StagesLatency {
 	logger.Println("NewStagesLatency()")
 	sl := &StagesLatency{
-		db: db,
+		source: source,
 	}
 	sl.SetContext(ctx)
 
@@ -73,32 +78,47 @@ func NewStagesLatency(ctx *context.Context, db *sql.DB) *StagesLatency {
 // Collect collects data from the db, updating initial
 // values if needed, and then subtracting initial values if we want
 // relative values, after which it stores totals.
-func (sl *StagesLatency) Collect() {
+func (sl *StagesLatency) Collect() error {
 	start := time.Now()
-	sl.last = collect(sl.db)
-	sl.SetLastCollectTime(time.Now())
-	logger.Println("t.current collected", len(sl.last), "row(s) from SELECT")
-
-	if len(sl.first) == 0 && len(sl.last) > 0 {
-		logger.Println("t.initial: copying from t.current (initial setup)")
-		sl.updateFirstFromLast()
-	}
-
-	// check for reload initial characteristics
-	if sl.first.needsRefresh(sl.last) {
-		logger.Println("t.initial: copying from t.current (data needs refreshing)")
-		sl.updateFirstFromLast()
+	var collected Rows
+	if err := sl.CollectFrom("stages_latency", &collected, func() (err error) {
+		collected, err = sl.source.Collect(sl.QueryContext())
+		return err
+	}, func() {
+		if sl.Compensate(counterRows{&collected}) {
+			logger.Println("StagesLatency.Collect() truncation detected")
+			sl.SetTruncated(sl.LastCollectTime())
+		}
+		sl.last = collected
+		logger.Println("t.current collected", len(sl.last), "row(s) from SELECT")
+
+		if len(sl.first) == 0 && len(sl.last) > 0 {
+			logger.Println("t.initial: copying from t.current (initial setup)")
+			sl.updateFirstFromLast()
+		}
+
+		// check for reload initial characteristics
+		if sl.first.needsRefresh(sl.last) {
+			logger.Println("t.initial: copying from t.current (data needs refreshing)")
+			sl.updateFirstFromLast()
+			sl.ResetSnapshots()
+		}
+		sl.AddSnapshot(sl.last)
+
+		sl.makeResults()
+
+		// logger.Println( "t.initial:", t.initial )
+		// logger.Println( "t.current:", t.current )
+		logger.Println("t.initial.totals():", sl.first.totals())
+		logger.Println("t.current.totals():", sl.last.totals())
+		// logger.Println("t.results:", sl.Results)
+		// logger.Println("t.totals:", sl.Totals)
+	}); err != nil {
+		return err
 	}
-
-	sl.makeResults()
-
-	// logger.Println( "t.initial:", t.initial )
-	// logger.Println( "t.current:", t.current )
-	logger.Println("t.initial.totals():", sl.first.totals())
-	logger.Println("t.current.totals():", sl.last.totals())
-	// logger.Println("t.results:", sl.Results)
-	// logger.Println("t.totals:", sl.Totals)
 	logger.Println("Table_io_waits_summary_by_table.Collect() END, took:", time.Duration(time.Since(start)).String())
+
+	return nil
 }
 
 // SetFirstFromLast  resets the statistics to current values
@@ -112,7 +132,11 @@ func (sl *StagesLatency) makeResults() {
 	// logger.Println( "- t.results set from t.current" )
 	sl.Results = make(Rows, len(sl.last))
 	copy(sl.Results, sl.last)
-	if sl.WantRelativeStats() {
+	if sl.WantWindowStats() {
+		if baseline, ok := sl.WindowBaseline(); ok {
+			sl.Results.subtract(baseline.(Rows))
+		}
+	} else if sl.WantRelativeStats() {
 		sl.Results.subtract(sl.first)
 	}
 	sl.Totals = sl.Results.totals()
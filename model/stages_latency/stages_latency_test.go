@@ -0,0 +1,60 @@
+package stages_latency
+
+import (
+	"testing"
+
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+func TestCollect(t *testing.T) {
+	var tests = []struct {
+		relative bool
+		first    Rows
+		second   Rows
+		totals   Row
+	}{
+		{
+			false,
+			Rows{{"stage/sql/init", 1, 10}},
+			Rows{{"stage/sql/init", 3, 40}, {"stage/sql/end", 2, 5}},
+			Row{"Totals", 5, 45},
+		},
+		{
+			true,
+			Rows{{"stage/sql/init", 1, 10}},
+			Rows{{"stage/sql/init", 3, 40}, {"stage/sql/end", 2, 5}},
+			Row{"Totals", 4, 35},
+		},
+		{
+			// the table has been truncated so the values collected before are added
+			true,
+			Rows{{"stage/sql/init", 5, 100}},
+			Rows{{"stage/sql/init", 1, 10}},
+			Row{"Totals", 1, 10},
+		},
+		{
+			false,
+			Rows{{"stage/sql/init", 5, 100}},
+			Rows{{"stage/sql/init", 1, 10}},
+			Row{"Totals", 6, 110},
+		},
+	}
+
+	for _, test := range tests {
+		ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+		ctx.SetWantRelativeStats(test.relative)
+		source := &MemorySource{Rows: test.first}
+		sl := NewStagesLatencyWithSource(ctx, source)
+
+		sl.Collect()
+		source.Rows = test.second
+		sl.Collect()
+
+		if sl.Totals != test.totals {
+			t.Errorf("Collect() relative: %v, %v then %v: expected totals %v, actual %v", test.relative, test.first, test.second, test.totals, sl.Totals)
+		}
+	}
+}
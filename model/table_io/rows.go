@@ -3,8 +3,8 @@
 package table_io
 
 import (
+	"context"
 	"database/sql"
-	"log"
 
 	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/logger"
@@ -25,7 +25,7 @@ func (rows Rows) totals() Row {
 	return totals
 }
 
-func collect(dbh *sql.DB, databaseFilter *filter.DatabaseFilter) Rows {
+func collect(ctx context.Context, dbh *sql.DB, databaseFilter *filter.DatabaseFilter) (Rows, error) {
 	var t Rows
 
 	logger.Printf("collect(?,%q)\n", databaseFilter)
@@ -43,9 +43,9 @@ func collect(dbh *sql.DB, databaseFilter *filter.DatabaseFilter) Rows {
 		logger.Printf("apply databaseFilter: sql: %q, args: %+v\n", sql, args)
 	}
 
-	rows, err := dbh.Query(sql, args...)
+	rows, err := dbh.QueryContext(ctx, sql, args...)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -69,7 +69,7 @@ func collect(dbh *sql.DB, databaseFilter *filter.DatabaseFilter) Rows {
 			&r.SumTimerUpdate,
 			&r.CountDelete,
 			&r.SumTimerDelete); err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 		r.Name = lib.TableName(schema, table)
 
@@ -77,10 +77,10 @@ func collect(dbh *sql.DB, databaseFilter *filter.DatabaseFilter) Rows {
 		t = append(t, r)
 	}
 	if err := rows.Err(); err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	return t
+	return t, nil
 }
 
 // remove the initial values from those rows where there's a match
@@ -110,3 +110,17 @@ func (rows Rows) needsRefresh(otherRows Rows) bool {
 
 	return myTotals.SumTimerWait > otherTotals.SumTimerWait
 }
+
+// counterRows gives baseobject.Compensate access to the rows by name
+type counterRows struct {
+	rows *Rows
+}
+
+func (c counterRows) Len() int              { return len(*c.rows) }
+func (c counterRows) Key(i int) string      { return (*c.rows)[i].Name }
+func (c counterRows) Row(i int) interface{} { return (*c.rows)[i] }
+func (c counterRows) Decreased(i int, previous interface{}) bool {
+	return (*c.rows)[i].decreased(previous.(Row))
+}
+func (c counterRows) Add(i int, offset interface{}) { (*c.rows)[i].add(offset.(Row)) }
+func (c counterRows) Append(row interface{})        { *c.rows = append(*c.rows, row.(Row)) }
@@ -18,13 +18,18 @@ type TableIo struct {
 	last        Rows // last loaded values
 	Results     Rows // results (maybe with subtraction)
 	Totals      Row  // totals of results
-	db          *sql.DB
+	source      Source
 }
 
-// NewTableIo returns an i/o latency object with context and db handle
+// NewTableIo returns a TableIo collecting data from MySQL using the given db handle
 func NewTableIo(ctx *context.Context, db *sql.DB) *TableIo {
+	return NewTableIoWithSource(ctx, NewMySQLSource(db))
+}
+
+// NewTableIoWithSource returns an i/o latency object with context and db handle
+func NewTableIoWithSource(ctx *context.Context, source Source) *TableIo {
 	tiol := &TableIo{
-		db: db,
+		source: source,
 	}
 	tiol.SetContext(ctx)
 
@@ -46,35 +51,54 @@ func (tiol *TableIo) updateFirstFromLast() {
 // Collect collects data from the db, updating initial values
 // if needed, and then subtracting initial values if we want relative
 // values, after which it stores totals.
-func (tiol *TableIo) Collect() {
+func (tiol *TableIo) Collect() error {
 	start := time.Now()
 	// logger.Println("TableIo.Collect() BEGIN")
-	tiol.last = collect(tiol.db, tiol.DatabaseFilter())
-	tiol.SetLastCollectTime(time.Now())
-	logger.Println("t.current collected", len(tiol.last), "row(s) from SELECT")
-
-	if len(tiol.first) == 0 && len(tiol.last) > 0 {
-		logger.Println("tiol.first: copying from tiol.last (initial setup)")
-		tiol.updateFirstFromLast()
-	}
-
-	// check for reload initial characteristics
-	if tiol.first.needsRefresh(tiol.last) {
-		logger.Println("tiol.first: copying from t.current (data needs refreshing)")
-		tiol.updateFirstFromLast()
+	var collected Rows
+	if err := tiol.CollectFrom("table_io", &collected, func() (err error) {
+		collected, err = tiol.source.Collect(tiol.QueryContext(), tiol.DatabaseFilter())
+		return err
+	}, func() {
+		if tiol.Compensate(counterRows{&collected}) {
+			logger.Println("TableIo.Collect() truncation detected")
+			tiol.SetTruncated(tiol.LastCollectTime())
+		}
+		tiol.last = collected
+		logger.Println("t.current collected", len(tiol.last), "row(s) from SELECT")
+
+		if len(tiol.first) == 0 && len(tiol.last) > 0 {
+			logger.Println("tiol.first: copying from tiol.last (initial setup)")
+			tiol.updateFirstFromLast()
+		}
+
+		// check for reload initial characteristics
+		if tiol.first.needsRefresh(tiol.last) {
+			logger.Println("tiol.first: copying from t.current (data needs refreshing)")
+			tiol.updateFirstFromLast()
+			tiol.ResetSnapshots()
+		}
+		tiol.AddSnapshot(tiol.last)
+
+		tiol.makeResults()
+
+		logger.Println("tiol.first.totals():", tiol.first.totals())
+		logger.Println("tiol.last.totals():", tiol.last.totals())
+	}); err != nil {
+		return err
 	}
-
-	tiol.makeResults()
-
-	logger.Println("tiol.first.totals():", tiol.first.totals())
-	logger.Println("tiol.last.totals():", tiol.last.totals())
 	logger.Println("TableIo.Collect() END, took:", time.Duration(time.Since(start)).String())
+
+	return nil
 }
 
 func (tiol *TableIo) makeResults() {
 	tiol.Results = make([]Row, len(tiol.last))
 	copy(tiol.Results, tiol.last)
-	if tiol.WantRelativeStats() {
+	if tiol.WantWindowStats() {
+		if baseline, ok := tiol.WindowBaseline(); ok {
+			tiol.Results.subtract(baseline.(Rows))
+		}
+	} else if tiol.WantRelativeStats() {
 		tiol.Results.subtract(tiol.first)
 	}
 
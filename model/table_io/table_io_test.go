@@ -0,0 +1,359 @@
+package table_io
+
+import (
+	gocontext "context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+func newTestContext() *context.Context {
+	return context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+}
+
+func TestTotals(t *testing.T) {
+	var tests = []struct {
+		rows   Rows
+		totals Row
+	}{
+		{nil, Row{Name: "Totals"}},
+		{
+			Rows{
+				{Name: "db.t1", SumTimerWait: 10, SumTimerFetch: 4, CountStar: 2, CountFetch: 1},
+				{Name: "db.t2", SumTimerWait: 20, SumTimerInsert: 5, CountStar: 3, CountInsert: 2},
+			},
+			Row{Name: "Totals", SumTimerWait: 30, SumTimerFetch: 4, SumTimerInsert: 5, CountStar: 5, CountFetch: 1, CountInsert: 2},
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.rows.totals(); got != test.totals {
+			t.Errorf("%v.totals(): expected %v, actual %v", test.rows, test.totals, got)
+		}
+	}
+}
+
+func TestRowsSubtract(t *testing.T) {
+	var tests = []struct {
+		rows     Rows
+		initial  Rows
+		expected Rows
+	}{
+		{
+			Rows{{Name: "db.t1", SumTimerWait: 10, CountStar: 5}},
+			nil,
+			Rows{{Name: "db.t1", SumTimerWait: 10, CountStar: 5}},
+		},
+		{
+			Rows{{Name: "db.t1", SumTimerWait: 10, CountStar: 5}, {Name: "db.t2", SumTimerWait: 7, CountStar: 1}},
+			Rows{{Name: "db.t2", SumTimerWait: 2, CountStar: 1}, {Name: "db.t1", SumTimerWait: 4, CountStar: 2}},
+			Rows{{Name: "db.t1", SumTimerWait: 6, CountStar: 3}, {Name: "db.t2", SumTimerWait: 5, CountStar: 0}},
+		},
+		{
+			Rows{{Name: "db.new", SumTimerWait: 3, CountStar: 1}},
+			Rows{{Name: "db.old", SumTimerWait: 2, CountStar: 1}},
+			Rows{{Name: "db.new", SumTimerWait: 3, CountStar: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		rows := make(Rows, len(test.rows))
+		copy(rows, test.rows)
+		rows.subtract(test.initial)
+		if len(rows) != len(test.expected) {
+			t.Fatalf("%v.subtract(%v): expected %v, actual %v", test.rows, test.initial, test.expected, rows)
+		}
+		for i := range rows {
+			if rows[i] != test.expected[i] {
+				t.Errorf("%v.subtract(%v): expected %v, actual %v", test.rows, test.initial, test.expected, rows)
+			}
+		}
+	}
+}
+
+func TestNeedsRefresh(t *testing.T) {
+	var tests = []struct {
+		first    Rows
+		last     Rows
+		expected bool
+	}{
+		{nil, nil, false},
+		{Rows{{Name: "db.t1", SumTimerWait: 10}}, Rows{{Name: "db.t1", SumTimerWait: 10}}, false},
+		{Rows{{Name: "db.t1", SumTimerWait: 10}}, Rows{{Name: "db.t1", SumTimerWait: 20}}, false},
+		{Rows{{Name: "db.t1", SumTimerWait: 20}}, Rows{{Name: "db.t1", SumTimerWait: 10}}, true},
+	}
+
+	for _, test := range tests {
+		if got := test.first.needsRefresh(test.last); got != test.expected {
+			t.Errorf("%v.needsRefresh(%v): expected %v, actual %v", test.first, test.last, test.expected, got)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	var tests = []struct {
+		relative bool
+		first    Rows
+		second   Rows
+		expected Row // totals after the second collection
+	}{
+		{
+			false,
+			Rows{{Name: "db.t1", SumTimerWait: 10, CountStar: 1}},
+			Rows{{Name: "db.t1", SumTimerWait: 25, CountStar: 3}},
+			Row{Name: "Totals", SumTimerWait: 25, CountStar: 3},
+		},
+		{
+			true,
+			Rows{{Name: "db.t1", SumTimerWait: 10, CountStar: 1}},
+			Rows{{Name: "db.t1", SumTimerWait: 25, CountStar: 3}},
+			Row{Name: "Totals", SumTimerWait: 15, CountStar: 2},
+		},
+		{
+			// values going backwards after TRUNCATE are compensated for
+			true,
+			Rows{{Name: "db.t1", SumTimerWait: 30, CountStar: 4}},
+			Rows{{Name: "db.t1", SumTimerWait: 5, CountStar: 1}},
+			Row{Name: "Totals", SumTimerWait: 5, CountStar: 1},
+		},
+		{
+			false,
+			Rows{{Name: "db.t1", SumTimerWait: 30, CountStar: 4}},
+			Rows{{Name: "db.t1", SumTimerWait: 5, CountStar: 1}},
+			Row{Name: "Totals", SumTimerWait: 35, CountStar: 5},
+		},
+	}
+
+	for _, test := range tests {
+		ctx := newTestContext()
+		ctx.SetWantRelativeStats(test.relative)
+		source := &MemorySource{Rows: test.first}
+		tiol := NewTableIoWithSource(ctx, source)
+
+		tiol.Collect()
+		source.Rows = test.second
+		tiol.Collect()
+
+		if tiol.Totals != test.expected {
+			t.Errorf("Collect() relative: %v, %v then %v: expected totals %v, actual %v", test.relative, test.first, test.second, test.expected, tiol.Totals)
+		}
+	}
+}
+
+func TestMemorySource(t *testing.T) {
+	rows := Rows{{Name: "db.t1", SumTimerWait: 10}}
+	source := &MemorySource{Rows: rows}
+
+	got, err := source.Collect(gocontext.Background(), nil)
+	if err != nil {
+		t.Fatalf("Collect(): unexpected error: %v", err)
+	}
+	got[0].SumTimerWait = 20
+	if rows[0].SumTimerWait != 10 {
+		t.Errorf("Collect(): modifying the returned rows changed the source")
+	}
+
+	source.Err = errors.New("collection failed")
+	if _, err := source.Collect(gocontext.Background(), nil); err != source.Err {
+		t.Errorf("Collect(): expected error %v, actual %v", source.Err, err)
+	}
+}
+
+func TestCollectErrorKeepsLastData(t *testing.T) {
+	ctx := newTestContext()
+	source := &MemorySource{Rows: Rows{{Name: "db.t1", SumTimerWait: 10, CountStar: 1}}}
+	tiol := NewTableIoWithSource(ctx, source)
+	if err := tiol.Collect(); err != nil {
+		t.Fatalf("Collect(): unexpected error: %v", err)
+	}
+	collected := tiol.LastCollectTime()
+
+	source.Err = errors.New("lost connection")
+	if err := tiol.Collect(); err != source.Err {
+		t.Errorf("Collect(): expected error %v, actual %v", source.Err, err)
+	}
+	if expected := (Row{Name: "Totals", SumTimerWait: 10, CountStar: 1}); tiol.Totals != expected {
+		t.Errorf("Collect() failed: expected the last totals %v to be kept, actual %v", expected, tiol.Totals)
+	}
+	if !tiol.LastCollectTime().Equal(collected) {
+		t.Errorf("Collect() failed: expected the collection time %v to be kept, actual %v", collected, tiol.LastCollectTime())
+	}
+}
+
+// lockedSource checks the lock set with SetLocker is not held while querying
+type lockedSource struct {
+	MemorySource
+	lock   *sync.Mutex
+	locked bool // was the lock held during the query?
+}
+
+func (s *lockedSource) Collect(ctx gocontext.Context, databaseFilter *filter.DatabaseFilter) (Rows, error) {
+	acquired := make(chan struct{})
+	go func() {
+		s.lock.Lock()
+		s.lock.Unlock()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		s.locked = true
+	}
+	return s.MemorySource.Collect(ctx, databaseFilter)
+}
+
+func TestCollectHoldsLockOnlyToApply(t *testing.T) {
+	var lock sync.Mutex
+	source := &lockedSource{MemorySource: MemorySource{Rows: Rows{{Name: "db.t1", SumTimerWait: 10, CountStar: 1}}}, lock: &lock}
+	tiol := NewTableIoWithSource(newTestContext(), source)
+	tiol.SetLocker(&lock)
+
+	if err := tiol.Collect(); err != nil {
+		t.Fatalf("Collect(): unexpected error: %v", err)
+	}
+	if source.locked {
+		t.Errorf("Collect(): the lock was held while querying")
+	}
+
+	// the data is only changed holding the lock
+	lock.Lock()
+	source.Rows = Rows{{Name: "db.t1", SumTimerWait: 20, CountStar: 2}}
+	done := make(chan error)
+	go func() { done <- tiol.Collect() }()
+	select {
+	case <-done:
+		t.Fatalf("Collect(): changed the data without holding the lock")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if tiol.Totals.SumTimerWait != 10 {
+		t.Errorf("Collect(): expected the last totals while locked, actual %v", tiol.Totals)
+	}
+	lock.Unlock()
+	if err := <-done; err != nil {
+		t.Fatalf("Collect(): unexpected error: %v", err)
+	}
+	if tiol.Totals.SumTimerWait != 20 {
+		t.Errorf("Collect(): expected the new totals after unlocking, actual %v", tiol.Totals)
+	}
+}
+
+func TestCompensate(t *testing.T) {
+	// each collection in turn with the expected results
+	var tests = []struct {
+		collected Rows
+		expected  Rows
+		truncated bool
+	}{
+		{
+			Rows{{Name: "db.t1", SumTimerWait: 10, CountStar: 2}, {Name: "db.t2", SumTimerWait: 5, CountStar: 1}},
+			Rows{{Name: "db.t1", SumTimerWait: 10, CountStar: 2}, {Name: "db.t2", SumTimerWait: 5, CountStar: 1}},
+			false,
+		},
+		{
+			// db.t1 truncated
+			Rows{{Name: "db.t1", SumTimerWait: 3, CountStar: 1}, {Name: "db.t2", SumTimerWait: 6, CountStar: 2}},
+			Rows{{Name: "db.t1", SumTimerWait: 13, CountStar: 3}, {Name: "db.t2", SumTimerWait: 6, CountStar: 2}},
+			true,
+		},
+		{
+			// db.t1 truncated again with db.t2 not used since it was truncated too so its values are kept
+			Rows{{Name: "db.t1", SumTimerWait: 2, CountStar: 1}},
+			Rows{{Name: "db.t1", SumTimerWait: 15, CountStar: 4}, {Name: "db.t2", SumTimerWait: 6, CountStar: 2}},
+			true,
+		},
+		{
+			// db.t2 used again
+			Rows{{Name: "db.t1", SumTimerWait: 3, CountStar: 2}, {Name: "db.t2", SumTimerWait: 1, CountStar: 1}},
+			Rows{{Name: "db.t1", SumTimerWait: 16, CountStar: 5}, {Name: "db.t2", SumTimerWait: 7, CountStar: 3}},
+			true,
+		},
+		{
+			// db.t2 dropped without a truncation so it goes away
+			Rows{{Name: "db.t1", SumTimerWait: 4, CountStar: 3}},
+			Rows{{Name: "db.t1", SumTimerWait: 17, CountStar: 6}},
+			false,
+		},
+		{
+			// db.t2 created again starts from zero
+			Rows{{Name: "db.t1", SumTimerWait: 4, CountStar: 3}, {Name: "db.t2", SumTimerWait: 2, CountStar: 1}},
+			Rows{{Name: "db.t1", SumTimerWait: 17, CountStar: 6}, {Name: "db.t2", SumTimerWait: 2, CountStar: 1}},
+			false,
+		},
+	}
+
+	tiol := NewTableIoWithSource(newTestContext(), &MemorySource{})
+	for i, test := range tests {
+		rows := make(Rows, len(test.collected))
+		copy(rows, test.collected)
+		if truncated := tiol.Compensate(counterRows{&rows}); truncated != test.truncated {
+			t.Errorf("collection %d: Compensate(): expected truncated %v, actual %v", i+1, test.truncated, truncated)
+		}
+		if len(rows) != len(test.expected) {
+			t.Errorf("collection %d: Compensate(): expected %v, actual %v", i+1, test.expected, rows)
+			continue
+		}
+		for j := range rows {
+			if rows[j] != test.expected[j] {
+				t.Errorf("collection %d: Compensate(): expected %v, actual %v", i+1, test.expected, rows)
+			}
+		}
+	}
+}
+
+func TestCollectTruncationKeepsTotals(t *testing.T) {
+	// each collection in turn with the expected absolute and relative totals
+	var tests = []struct {
+		collected Rows
+		absolute  uint64
+		relative  uint64
+	}{
+		{Rows{{Name: "db.t1", SumTimerWait: 10}, {Name: "db.t2", SumTimerWait: 50}}, 60, 0},
+		{Rows{{Name: "db.t1", SumTimerWait: 20}, {Name: "db.t2", SumTimerWait: 50}}, 70, 10},
+		// truncated with only db.t1 used since
+		{Rows{{Name: "db.t1", SumTimerWait: 1}}, 71, 11},
+		// db.t2 dropped without a truncation so it no longer counts and
+		// the relative values start again as the total went down
+		{Rows{{Name: "db.t1", SumTimerWait: 2}}, 22, 0},
+	}
+
+	for _, wantRelative := range []bool{false, true} {
+		ctx := newTestContext()
+		ctx.SetWantRelativeStats(wantRelative)
+		source := &MemorySource{}
+		tiol := NewTableIoWithSource(ctx, source)
+		for i, test := range tests {
+			source.Rows = test.collected
+			if err := tiol.Collect(); err != nil {
+				t.Fatalf("Collect(): unexpected error: %v", err)
+			}
+			expected := test.absolute
+			if wantRelative {
+				expected = test.relative
+			}
+			if tiol.Totals.SumTimerWait != expected {
+				t.Errorf("collection %d (relative: %v): expected total %d, actual %d", i+1, wantRelative, expected, tiol.Totals.SumTimerWait)
+			}
+		}
+	}
+}
+
+func TestLastTruncation(t *testing.T) {
+	source := &MemorySource{Rows: Rows{{Name: "db.t1", SumTimerWait: 10, CountStar: 2}}}
+	tiol := NewTableIoWithSource(newTestContext(), source)
+
+	tiol.Collect()
+	if !tiol.LastTruncation().IsZero() {
+		t.Errorf("LastTruncation(): expected no truncation, got %v", tiol.LastTruncation())
+	}
+	source.Rows = Rows{{Name: "db.t1", SumTimerWait: 1, CountStar: 1}}
+	tiol.Collect()
+	if !tiol.LastTruncation().Equal(tiol.LastCollectTime()) {
+		t.Errorf("LastTruncation(): expected %v, got %v", tiol.LastCollectTime(), tiol.LastTruncation())
+	}
+}
@@ -131,3 +131,9 @@ func (r *Row) subtract(other Row) {
 func (r *Row) HasData() bool {
 	return r != nil && r.SumTimerWait > 0
 }
+
+// decreased returns true if the counters have gone down since the
+// previous values were collected so the row has been truncated.
+func (r Row) decreased(previous Row) bool {
+	return r.SumTimerWait < previous.SumTimerWait
+}
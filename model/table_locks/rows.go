@@ -3,9 +3,9 @@
 package table_locks
 
 import (
+	"context"
 	"database/sql"
 	_ "github.com/go-sql-driver/mysql" // keep glint happy
-	"log"
 
 	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/logger"
@@ -31,7 +31,7 @@ func (t Rows) totals() Row {
 // - filter out empty values
 // - merge rows with the same name into a single row
 // - change FILE_NAME into a more descriptive value.
-func collect(dbh *sql.DB, databaseFilter *filter.DatabaseFilter) Rows {
+func collect(ctx context.Context, dbh *sql.DB, databaseFilter *filter.DatabaseFilter) (Rows, error) {
 	var t Rows
 
 	sql := `
@@ -64,9 +64,9 @@ WHERE	COUNT_STAR > 0`
 		logger.Printf("apply databaseFilter: sql: %q, args: %+v\n", sql, args)
 	}
 
-	rows, err := dbh.Query(sql, args...)
+	rows, err := dbh.QueryContext(ctx, sql, args...)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -90,17 +90,17 @@ WHERE	COUNT_STAR > 0`
 			&r.SumTimerWriteLowPriority,
 			&r.SumTimerWriteNormal,
 			&r.SumTimerWriteExternal); err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 		r.Name = lib.TableName(schema, table)
 		// we collect all data as we may need it later
 		t = append(t, r)
 	}
 	if err := rows.Err(); err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	return t
+	return t, nil
 }
 
 // remove the initial values from those rows where there's a match
@@ -129,3 +129,17 @@ func (t Rows) needsRefresh(t2 Rows) bool {
 
 	return myTotals.SumTimerWait > otherTotals.SumTimerWait
 }
+
+// counterRows gives baseobject.Compensate access to the rows by name
+type counterRows struct {
+	rows *Rows
+}
+
+func (c counterRows) Len() int              { return len(*c.rows) }
+func (c counterRows) Key(i int) string      { return (*c.rows)[i].Name }
+func (c counterRows) Row(i int) interface{} { return (*c.rows)[i] }
+func (c counterRows) Decreased(i int, previous interface{}) bool {
+	return (*c.rows)[i].decreased(previous.(Row))
+}
+func (c counterRows) Add(i int, offset interface{}) { (*c.rows)[i].add(offset.(Row)) }
+func (c counterRows) Append(row interface{})        { *c.rows = append(*c.rows, row.(Row)) }
@@ -22,13 +22,18 @@ type TableLocks struct {
 	current Rows // last loaded values
 	Results Rows // results (maybe with subtraction)
 	Totals  Row  // totals of results
-	db      *sql.DB
+	source  Source
 }
 
-// NewTableLocks returns a pointer to an object of this type
+// NewTableLocks returns a TableLocks collecting data from MySQL using the given db handle
 func NewTableLocks(ctx *context.Context, db *sql.DB) *TableLocks {
+	return NewTableLocksWithSource(ctx, NewMySQLSource(db))
+}
+
+// NewTableLocksWithSource returns a pointer to an object of this type
+func NewTableLocksWithSource(ctx *context.Context, source Source) *TableLocks {
 	tll := &TableLocks{
-		db: db,
+		source: source,
 	}
 	tll.SetContext(ctx)
 
@@ -42,28 +47,47 @@ func (tll *TableLocks) copyCurrentToInitial() {
 }
 
 // Collect data from the db, then merge it in.
-func (tll *TableLocks) Collect() {
+func (tll *TableLocks) Collect() error {
 	start := time.Now()
-	tll.current = collect(tll.db, tll.DatabaseFilter())
-	tll.SetLastCollectTime(time.Now())
+	var collected Rows
+	if err := tll.CollectFrom("table_locks", &collected, func() (err error) {
+		collected, err = tll.source.Collect(tll.QueryContext(), tll.DatabaseFilter())
+		return err
+	}, func() {
+		if tll.Compensate(counterRows{&collected}) {
+			logger.Println("TableLocks.Collect() truncation detected")
+			tll.SetTruncated(tll.LastCollectTime())
+		}
+		tll.current = collected
 
-	if len(tll.initial) == 0 && len(tll.current) > 0 {
-		tll.copyCurrentToInitial()
-	}
+		if len(tll.initial) == 0 && len(tll.current) > 0 {
+			tll.copyCurrentToInitial()
+		}
 
-	// check for reload initial characteristics
-	if tll.initial.needsRefresh(tll.current) {
-		tll.copyCurrentToInitial()
-	}
+		// check for reload initial characteristics
+		if tll.initial.needsRefresh(tll.current) {
+			tll.copyCurrentToInitial()
+			tll.ResetSnapshots()
+		}
+		tll.AddSnapshot(tll.current)
 
-	tll.makeResults()
+		tll.makeResults()
+	}); err != nil {
+		return err
+	}
 	logger.Println("TableLocks.Collect() took:", time.Duration(time.Since(start)).String())
+
+	return nil
 }
 
 func (tll *TableLocks) makeResults() {
 	tll.Results = make(Rows, len(tll.current))
 	copy(tll.Results, tll.current)
-	if tll.WantRelativeStats() {
+	if tll.WantWindowStats() {
+		if baseline, ok := tll.WindowBaseline(); ok {
+			tll.Results.subtract(baseline.(Rows))
+		}
+	} else if tll.WantRelativeStats() {
 		tll.Results.subtract(tll.initial)
 	}
 	tll.Totals = tll.Results.totals()
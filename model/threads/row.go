@@ -0,0 +1,42 @@
+// Package threads contains the library routines for managing the
+// connections shown in performance_schema.threads.
+package threads
+
+// Tags given to threads which are not normal client connections
+const (
+	SystemTag      = "SYSTEM"
+	ReplicationTag = "REPL"
+)
+
+// Row contains a connection and what it is doing now
+type Row struct {
+	ID        uint64 // processlist id
+	ThreadID  uint64 // performance_schema thread id
+	User      string
+	Host      string
+	Db        string
+	Command   string
+	Time      uint64 // seconds in the current state
+	Stage     string // current stage, or the state if stages are not collected
+	Latency   uint64 // picoseconds the current statement has been running
+	Statement string // current statement
+	Tag       string // SystemTag, ReplicationTag or empty
+}
+
+// tag returns the tag of a thread like the processlist rows are
+// treated by user_latency: "system user" threads are system threads
+// and those dumping the binlog are replication threads.
+func tag(user, command string) string {
+	switch {
+	case user == "system user":
+		return SystemTag
+	case command == "Binlog Dump" || command == "Binlog Dump GTID":
+		return ReplicationTag
+	}
+	return ""
+}
+
+// Idle returns true if the connection is not doing anything
+func (row Row) Idle() bool {
+	return row.Command == "Sleep" || row.Command == "Daemon"
+}
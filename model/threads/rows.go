@@ -0,0 +1,117 @@
+// Package threads contains the library routines for managing the
+// connections shown in performance_schema.threads.
+package threads
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/logger"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+// Rows contains a slice of Row
+type Rows []Row
+
+// The current stage needs the events_stages_current consumer so the
+// processlist state is used if it is not enabled.  The latency is that
+// of the top level statement being run.
+const query = `-- threads
+SELECT	t.PROCESSLIST_ID,
+	t.THREAD_ID,
+	IFNULL(t.PROCESSLIST_USER, 'system user'),
+	IFNULL(t.PROCESSLIST_HOST, ''),
+	IFNULL(t.PROCESSLIST_DB, ''),
+	IFNULL(t.PROCESSLIST_COMMAND, ''),
+	IFNULL(t.PROCESSLIST_TIME, 0),
+	IFNULL(REPLACE(g.EVENT_NAME, 'stage/sql/', ''), IFNULL(t.PROCESSLIST_STATE, '')),
+	IFNULL(s.TIMER_WAIT, 0),
+	IFNULL(t.PROCESSLIST_INFO, '')
+FROM	performance_schema.threads t
+LEFT JOIN performance_schema.events_stages_current g ON g.THREAD_ID = t.THREAD_ID AND g.END_EVENT_ID IS NULL
+LEFT JOIN performance_schema.events_statements_current s ON s.THREAD_ID = t.THREAD_ID AND s.END_EVENT_ID IS NULL AND s.NESTING_EVENT_TYPE IS NULL
+WHERE	t.PROCESSLIST_ID IS NOT NULL`
+
+// totals returns the number of connections as the ID and the longest
+// running statement
+func (rows Rows) totals() Row {
+	var totals Row
+	totals.User = "Totals"
+
+	for i := range rows {
+		if rows[i].Latency > totals.Latency {
+			totals.Latency = rows[i].Latency
+		}
+		if rows[i].Time > totals.Time {
+			totals.Time = rows[i].Time
+		}
+	}
+
+	return totals
+}
+
+// withoutIdle returns the rows of the connections which are doing something
+func (rows Rows) withoutIdle() Rows {
+	var active Rows
+
+	for i := range rows {
+		if !rows[i].Idle() {
+			active = append(active, rows[i])
+		}
+	}
+
+	return active
+}
+
+func collect(ctx context.Context, dbh *sql.DB, databaseFilter *filter.DatabaseFilter) (Rows, error) {
+	var t Rows
+
+	logger.Printf("collect(?,%q)\n", databaseFilter)
+	sql := query
+	args := []interface{}{}
+
+	// Apply the filter if provided and seems good.
+	if len(databaseFilter.Args()) > 0 {
+		sql = sql + databaseFilter.ExtraSQLFor("t.PROCESSLIST_DB")
+		for _, v := range databaseFilter.Args() {
+			args = append(args, v)
+		}
+		logger.Printf("apply databaseFilter: sql: %q, args: %+v\n", sql, args)
+	}
+
+	rows, err := dbh.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r Row
+		if err := rows.Scan(
+			&r.ID,
+			&r.ThreadID,
+			&r.User,
+			&r.Host,
+			&r.Db,
+			&r.Command,
+			&r.Time,
+			&r.Stage,
+			&r.Latency,
+			&r.Statement); err != nil {
+			return nil, err
+		}
+		r.Tag = tag(r.User, r.Command)
+		r.User = lib.Anonymise("user", r.User)
+		r.Host = lib.Anonymise("host", r.Host)
+		r.Db = lib.Anonymise("schema", r.Db)
+		r.Statement = lib.AnonymiseStatement(strings.Join(strings.Fields(r.Statement), " "))
+		t = append(t, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
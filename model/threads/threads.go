@@ -0,0 +1,89 @@
+// Package threads contains the library routines for managing the
+// connections shown in performance_schema.threads.
+package threads
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sjmudd/ps-top/baseobject"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Threads holds the connections and what they are doing now
+type Threads struct {
+	baseobject.BaseObject
+	current  Rows // all connections collected
+	Results  Rows // connections shown
+	Totals   Row  // totals of results
+	showIdle bool // show the connections which are not doing anything?
+	source   Source
+}
+
+// NewThreads returns a Threads collecting data from MySQL using the given db handle
+func NewThreads(ctx *context.Context, db *sql.DB) *Threads {
+	return NewThreadsWithSource(ctx, NewMySQLSource(db))
+}
+
+// NewThreadsWithSource returns a Threads collecting data from the given source
+func NewThreadsWithSource(ctx *context.Context, source Source) *Threads {
+	logger.Println("NewThreads()")
+	t := &Threads{
+		showIdle: true,
+		source:   source,
+	}
+	t.SetContext(ctx)
+
+	return t
+}
+
+// Collect collects the connections from the db.  They show what is
+// happening now so there are no relative values.
+func (t *Threads) Collect() error {
+	start := time.Now()
+	var collected Rows
+	if err := t.CollectFrom("threads", &collected, func() (err error) {
+		collected, err = t.source.Collect(t.QueryContext(), t.DatabaseFilter())
+		return err
+	}, func() {
+		t.current = collected
+		t.makeResults()
+		logger.Println("Threads.Collect() collected", len(t.current), "thread(s), took:", time.Duration(time.Since(start)).String())
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (t *Threads) makeResults() {
+	if t.showIdle {
+		t.Results = make(Rows, len(t.current))
+		copy(t.Results, t.current)
+	} else {
+		t.Results = t.current.withoutIdle()
+	}
+	t.Totals = t.Results.totals()
+}
+
+// ShowIdle returns true if the connections which are not doing anything are shown
+func (t Threads) ShowIdle() bool {
+	return t.showIdle
+}
+
+// ToggleIdle changes between showing and hiding the connections which are not doing anything
+func (t *Threads) ToggleIdle() {
+	t.showIdle = !t.showIdle
+	t.makeResults()
+}
+
+// HaveRelativeStats returns if we have relative information
+func (t Threads) HaveRelativeStats() bool {
+	return false
+}
+
+// SetFirstFromLast - NOT IMPLEMENTED
+func (t *Threads) SetFirstFromLast() {
+	logger.Println("threads.Threads.SetFirstFromLast() NOT IMPLEMENTED")
+}
@@ -0,0 +1,59 @@
+package threads
+
+import (
+	"testing"
+
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+func TestTag(t *testing.T) {
+	var tests = []struct {
+		user     string
+		command  string
+		expected string
+	}{
+		{"app", "Query", ""},
+		{"system user", "Connect", SystemTag},
+		{"repl", "Binlog Dump", ReplicationTag},
+		{"repl", "Binlog Dump GTID", ReplicationTag},
+	}
+
+	for _, test := range tests {
+		if got := tag(test.user, test.command); got != test.expected {
+			t.Errorf("tag(%q, %q): expected %q, got %q", test.user, test.command, test.expected, got)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	source := &MemorySource{
+		Rows: Rows{
+			{ID: 1, User: "app", Command: "Query", Time: 3, Latency: 3000},
+			{ID: 2, User: "app", Command: "Sleep", Time: 10},
+			{ID: 3, User: "event_scheduler", Command: "Daemon", Time: 100},
+		},
+	}
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	th := NewThreadsWithSource(ctx, source)
+	if err := th.Collect(); err != nil {
+		t.Fatalf("Collect(): unexpected error: %v", err)
+	}
+
+	if len(th.Results) != 3 {
+		t.Errorf("Collect(): expected 3 rows, got %v", th.Results)
+	}
+	if expected := (Row{User: "Totals", Time: 100, Latency: 3000}); th.Totals != expected {
+		t.Errorf("Collect(): expected totals %v, got %v", expected, th.Totals)
+	}
+
+	th.ToggleIdle()
+	if len(th.Results) != 1 || th.Results[0].ID != 1 {
+		t.Errorf("ToggleIdle(): expected only connection 1, got %v", th.Results)
+	}
+	if th.ShowIdle() {
+		t.Errorf("ShowIdle(): expected false")
+	}
+}
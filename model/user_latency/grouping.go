@@ -0,0 +1,43 @@
+// Package user_latency contains library routines for ps-top related to the INFORMATION_SCHEMA.PROCESSLIST table.
+package user_latency
+
+// Grouping determines how the activity is grouped: by user, host or account
+type Grouping int
+
+// Grouping* constants are the ways the activity can be grouped
+const (
+	ByUser    Grouping = iota // group by user
+	ByHost    Grouping = iota // group by host
+	ByAccount Grouping = iota // group by account (user@host)
+)
+
+// String returns the name of the grouping
+func (g Grouping) String() string {
+	switch g {
+	case ByHost:
+		return "host"
+	case ByAccount:
+		return "account"
+	}
+	return "user"
+}
+
+// Next returns the grouping to use after this one
+func (g Grouping) Next() Grouping {
+	return (g + 1) % (ByAccount + 1)
+}
+
+// name returns the name of the user, host or account given the user
+// and hostname (without the port)
+func (g Grouping) name(user, host string) string {
+	switch g {
+	case ByHost:
+		return host
+	case ByAccount:
+		if user == "" && host == "" {
+			return ""
+		}
+		return user + "@" + host
+	}
+	return user
+}
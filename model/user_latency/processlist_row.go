@@ -5,11 +5,11 @@ package user_latency
 // Row contains a row from from information_schema.processlist
 type ProcesslistRow struct {
 	ID      uint64
-	user    string
-	host    string
-	db      string
-	command string
-	time    uint64
-	state   string
-	info    string
+	User    string
+	Host    string
+	Db      string
+	Command string
+	Time    uint64
+	State   string
+	Info    string
 }
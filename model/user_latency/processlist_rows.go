@@ -1,22 +1,29 @@
 // Package user_latency file contains the library routines for managing the
-// information_schema.processlist table.
+// connections from performance_schema.threads or information_schema.processlist.
 package user_latency
 
 import (
+	"context"
 	"database/sql"
-	"log"
 
-	"github.com/sjmudd/anonymiser"
+	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/logger"
 )
 
 // ProcesslistRows contains a slice of ProcesslistRow
 type ProcesslistRows []ProcesslistRow
 
-// get the output of I_S.PROCESSLIST - results only used internally
-func collect(dbh *sql.DB) ProcesslistRows {
+// threadsQuery returns the same columns as processlistQuery without
+// taking the global mutex I_S.PROCESSLIST needs in MySQL 5.7. Threads
+// without a user are shown as "system user" as in the processlist.
+const threadsQuery = "SELECT PROCESSLIST_ID, IFNULL(PROCESSLIST_USER, 'system user'), PROCESSLIST_HOST, PROCESSLIST_DB, PROCESSLIST_COMMAND, PROCESSLIST_TIME, PROCESSLIST_STATE, PROCESSLIST_INFO FROM performance_schema.threads WHERE PROCESSLIST_ID IS NOT NULL"
+
+// processlistQuery is used if performance_schema.threads can not be read
+const processlistQuery = "SELECT ID, USER, HOST, DB, COMMAND, TIME, STATE, INFO FROM INFORMATION_SCHEMA.PROCESSLIST"
+
+// get the connections using the given query - results only used internally
+func collect(ctx context.Context, dbh *sql.DB, query string) (ProcesslistRows, error) {
 	// we collect all information even if it's mainly empty as we may reference it later
-	const query = "SELECT ID, USER, HOST, DB, COMMAND, TIME, STATE, INFO FROM INFORMATION_SCHEMA.PROCESSLIST"
 
 	var (
 		t       ProcesslistRows
@@ -30,9 +37,9 @@ func collect(dbh *sql.DB) ProcesslistRows {
 		info    sql.NullString
 	)
 
-	rows, err := dbh.Query(query)
+	rows, err := dbh.QueryContext(ctx, query)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -47,30 +54,30 @@ func collect(dbh *sql.DB) ProcesslistRows {
 			&time,
 			&state,
 			&info); err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 		r.ID = uint64(id.Int64)
 
 		// be verbose for debugging.
 		u := user.String
-		a := anonymiser.Anonymise("user", user.String)
+		a := lib.Anonymise("user", user.String)
 		logger.Println("user:", u, ", anonymised:", a)
-		r.user = a
-		r.host = host.String
+		r.User = a
+		r.Host = host.String
 		if db.Valid {
-			r.db = db.String
+			r.Db = db.String
 		}
-		r.command = command.String
-		r.time = uint64(time.Int64)
+		r.Command = command.String
+		r.Time = uint64(time.Int64)
 		if state.Valid {
-			r.state = state.String
+			r.State = state.String
 		}
-		r.info = info.String
+		r.Info = info.String
 		t = append(t, r)
 	}
 	if err := rows.Err(); err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	return t
+	return t, nil
 }
@@ -1,4 +1,5 @@
 // Package user_latency manages the output from INFORMATION_SCHEMA.PROCESSLIST
+// and the statement summaries by user, host and account
 package user_latency
 
 /*
@@ -16,15 +17,17 @@ CREATE TEMPORARY TABLE `PROCESSLIST` (
 
 */
 
-// Row contains a summary row of information taken from information_schema.processlist
+// Row contains a summary row of the information taken from information_schema.processlist
+// and the statements run, by user, host or account
 type Row struct {
-	Username    string
+	Name        string // user, host or user@host
 	Runtime     uint64
 	Sleeptime   uint64
 	Connections uint64
 	Active      uint64
 	Hosts       uint64
 	Dbs         uint64
+	Latency     uint64 // time spent running statements
 	Selects     uint64
 	Inserts     uint64
 	Updates     uint64
@@ -36,3 +39,18 @@ type Row struct {
 func (r Row) TotalTime() uint64 {
 	return r.Runtime + r.Sleeptime
 }
+
+// Statements returns the number of statements run
+func (r Row) Statements() uint64 {
+	return r.Selects + r.Inserts + r.Updates + r.Deletes + r.Other
+}
+
+// addStatements adds the statements run to the row
+func (r *Row) addStatements(statements StatementRow) {
+	r.Latency += statements.SumTimerWait
+	r.Selects += statements.Selects
+	r.Inserts += statements.Inserts
+	r.Updates += statements.Updates
+	r.Deletes += statements.Deletes
+	r.Other += statements.Other()
+}
@@ -7,13 +7,14 @@ type Rows []Row
 // generate a row of totals from a table
 func (t Rows) totals() Row {
 	var totals Row
-	totals.Username = "Totals"
+	totals.Name = "Totals"
 
 	for i := range t {
 		totals.Runtime += t[i].Runtime
 		totals.Sleeptime += t[i].Sleeptime
 		totals.Connections += t[i].Connections
 		totals.Active += t[i].Active
+		totals.Latency += t[i].Latency
 		totals.Selects += t[i].Selects
 		totals.Inserts += t[i].Inserts
 		totals.Updates += t[i].Updates
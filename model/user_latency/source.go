@@ -0,0 +1,95 @@
+package user_latency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/sjmudd/ps-top/capabilities"
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Source provides the connections collected from performance_schema.threads
+// (or information_schema.processlist)
+// and the statement summaries by user, host and account
+type Source interface {
+	Collect(ctx context.Context) (ProcesslistRows, error)
+	CollectStatements(ctx context.Context) (StatementRows, error)
+}
+
+// MySQLSource is the default Source which collects the rows from MySQL
+type MySQLSource struct {
+	db          *sql.DB
+	processlist bool // true if information_schema.processlist must be used
+}
+
+// NewMySQLSource returns a Source collecting the rows using the given db
+// handle, using information_schema.processlist if the server does not have
+// performance_schema.threads.
+func NewMySQLSource(db *sql.DB, caps capabilities.Capabilities) *MySQLSource {
+	return &MySQLSource{db: db, processlist: !caps.Has(capabilities.Threads)}
+}
+
+// Collect returns the connections collected from performance_schema.threads,
+// using information_schema.processlist from then on if we may not read it.
+func (s *MySQLSource) Collect(ctx context.Context) (ProcesslistRows, error) {
+	if !s.processlist {
+		rows, err := collect(ctx, s.db, threadsQuery)
+		if !threadsUnavailable(err) {
+			return rows, err
+		}
+		logger.Println("user_latency.MySQLSource.Collect() falling back to information_schema.processlist:", err)
+		s.processlist = true
+	}
+
+	return collect(ctx, s.db, processlistQuery)
+}
+
+// CollectStatements returns the statements run collected from MySQL
+func (s *MySQLSource) CollectStatements(ctx context.Context) (StatementRows, error) {
+	return collectStatements(ctx, s.db)
+}
+
+// threadsUnavailable returns true if the error is because we may not
+// read performance_schema.threads. Whether the table exists is known
+// from the server's capabilities so only the privileges are checked.
+// Error 1142: SELECT command denied to user 'myuser'@'localhost' for table 'threads'
+func threadsUnavailable(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == tableAccessDenied
+}
+
+// tableAccessDenied is the number of the MySQL error ER_TABLEACCESS_DENIED_ERROR
+const tableAccessDenied = 1142
+
+// MemorySource is a Source holding the rows to return in memory which
+// is useful for testing. If Err is set it is returned instead of the rows.
+type MemorySource struct {
+	Rows       ProcesslistRows
+	Statements StatementRows
+	Err        error
+}
+
+// Collect returns a copy of the rows held in memory.
+func (s *MemorySource) Collect(ctx context.Context) (ProcesslistRows, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	rows := make(ProcesslistRows, len(s.Rows))
+	copy(rows, s.Rows)
+
+	return rows, nil
+}
+
+// CollectStatements returns a copy of the statements held in memory.
+func (s *MemorySource) CollectStatements(ctx context.Context) (StatementRows, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	rows := make(StatementRows, len(s.Statements))
+	copy(rows, s.Statements)
+
+	return rows, nil
+}
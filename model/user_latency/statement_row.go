@@ -0,0 +1,66 @@
+// Package user_latency contains the library routines for managing the
+// events_statements_summary_by_{user,host,account}_by_event_name tables.
+package user_latency
+
+import (
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// StatementRow contains the statements run by a user, host or account
+type StatementRow struct {
+	Grouping     Grouping // the table the row was collected from
+	Name         string   // user, host or user@host
+	SumTimerWait uint64   // time spent running statements
+	CountStar    uint64   // statements run
+	Selects      uint64
+	Inserts      uint64 // including REPLACE
+	Updates      uint64
+	Deletes      uint64
+}
+
+// key returns the key of the row which is unique between the groupings
+func (row StatementRow) key() string {
+	return row.Grouping.String() + "/" + row.Name
+}
+
+// Other returns the number of statements which are not a select, insert, update or delete
+func (row StatementRow) Other() uint64 {
+	known := row.Selects + row.Inserts + row.Updates + row.Deletes
+	if known > row.CountStar {
+		return 0
+	}
+	return row.CountStar - known
+}
+
+func (row *StatementRow) add(other StatementRow) {
+	row.SumTimerWait += other.SumTimerWait
+	row.CountStar += other.CountStar
+	row.Selects += other.Selects
+	row.Inserts += other.Inserts
+	row.Updates += other.Updates
+	row.Deletes += other.Deletes
+}
+
+// subtract the countable values in one row from another
+func (row *StatementRow) subtract(other StatementRow) {
+	// check for issues here (we have a bug) and log it
+	// - this situation should not happen so there's a logic bug somewhere else
+	if row.SumTimerWait >= other.SumTimerWait && row.CountStar >= other.CountStar {
+		row.SumTimerWait -= other.SumTimerWait
+		row.CountStar -= other.CountStar
+		row.Selects -= other.Selects
+		row.Inserts -= other.Inserts
+		row.Updates -= other.Updates
+		row.Deletes -= other.Deletes
+	} else {
+		logger.Println("WARNING: StatementRow.subtract() - subtraction problem! (not subtracting)")
+		logger.Println("row=", row)
+		logger.Println("other=", other)
+	}
+}
+
+// decreased returns true if the counters have gone down since the
+// previous values were collected so the row has been truncated.
+func (row StatementRow) decreased(previous StatementRow) bool {
+	return row.SumTimerWait < previous.SumTimerWait || row.CountStar < previous.CountStar
+}
@@ -0,0 +1,139 @@
+// Package user_latency contains the library routines for managing the
+// events_statements_summary_by_{user,host,account}_by_event_name tables.
+package user_latency
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// StatementRows contains a slice of StatementRow
+type StatementRows []StatementRow
+
+// statementColumns are the columns summing the statements of each type
+const statementColumns = `SUM(SUM_TIMER_WAIT),
+	SUM(COUNT_STAR),
+	SUM(IF(EVENT_NAME = 'statement/sql/select', COUNT_STAR, 0)),
+	SUM(IF(EVENT_NAME IN ('statement/sql/insert', 'statement/sql/insert_select', 'statement/sql/replace', 'statement/sql/replace_select'), COUNT_STAR, 0)),
+	SUM(IF(EVENT_NAME IN ('statement/sql/update', 'statement/sql/update_multi'), COUNT_STAR, 0)),
+	SUM(IF(EVENT_NAME IN ('statement/sql/delete', 'statement/sql/delete_multi'), COUNT_STAR, 0))`
+
+// The statements are collected by user, host and account together so
+// the grouping can be changed without losing the relative values.
+// Background threads have no user or host and are not collected.
+var statementsQuery = `-- user_latency
+SELECT	0, USER, NULL, ` + statementColumns + `
+FROM	performance_schema.events_statements_summary_by_user_by_event_name
+WHERE	USER IS NOT NULL
+GROUP BY USER
+UNION ALL
+SELECT	1, NULL, HOST, ` + statementColumns + `
+FROM	performance_schema.events_statements_summary_by_host_by_event_name
+WHERE	HOST IS NOT NULL
+GROUP BY HOST
+UNION ALL
+SELECT	2, USER, HOST, ` + statementColumns + `
+FROM	performance_schema.events_statements_summary_by_account_by_event_name
+WHERE	USER IS NOT NULL
+GROUP BY USER, HOST`
+
+func (rows StatementRows) totals() StatementRow {
+	var totals StatementRow
+	totals.Name = "Totals"
+
+	for i := range rows {
+		totals.add(rows[i])
+	}
+
+	return totals
+}
+
+// grouped returns the rows of the given grouping
+func (rows StatementRows) grouped(grouping Grouping) StatementRows {
+	var grouped StatementRows
+
+	for i := range rows {
+		if rows[i].Grouping == grouping {
+			grouped = append(grouped, rows[i])
+		}
+	}
+
+	return grouped
+}
+
+func collectStatements(ctx context.Context, dbh *sql.DB) (StatementRows, error) {
+	var t StatementRows
+
+	logger.Println("Querying db:", statementsQuery)
+	rows, err := dbh.QueryContext(ctx, statementsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user, host sql.NullString
+		var r StatementRow
+		if err := rows.Scan(
+			&r.Grouping,
+			&user,
+			&host,
+			&r.SumTimerWait,
+			&r.CountStar,
+			&r.Selects,
+			&r.Inserts,
+			&r.Updates,
+			&r.Deletes); err != nil {
+			return nil, err
+		}
+		r.Name = r.Grouping.name(lib.Anonymise("user", user.String), host.String)
+		t = append(t, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// remove the initial values from those rows where there's a match
+// - if we find a row we can't match ignore it
+func (rows *StatementRows) subtract(initial StatementRows) {
+	initialByKey := make(map[string]int)
+
+	for i := range initial {
+		initialByKey[initial[i].key()] = i
+	}
+
+	for i := range *rows {
+		if initialIndex, ok := initialByKey[(*rows)[i].key()]; ok {
+			(*rows)[i].subtract(initial[initialIndex])
+		}
+	}
+}
+
+// if the data in t2 is "newer", "has more values" than t then it needs refreshing.
+// check this by comparing totals.
+func (rows StatementRows) needsRefresh(otherRows StatementRows) bool {
+	totals := rows.totals()
+	otherTotals := otherRows.totals()
+
+	return totals.SumTimerWait > otherTotals.SumTimerWait
+}
+
+// counterRows gives baseobject.Compensate access to the rows by key
+type counterRows struct {
+	rows *StatementRows
+}
+
+func (c counterRows) Len() int              { return len(*c.rows) }
+func (c counterRows) Key(i int) string      { return (*c.rows)[i].key() }
+func (c counterRows) Row(i int) interface{} { return (*c.rows)[i] }
+func (c counterRows) Decreased(i int, previous interface{}) bool {
+	return (*c.rows)[i].decreased(previous.(StatementRow))
+}
+func (c counterRows) Add(i int, offset interface{}) { (*c.rows)[i].add(offset.(StatementRow)) }
+func (c counterRows) Append(row interface{})        { *c.rows = append(*c.rows, row.(StatementRow)) }
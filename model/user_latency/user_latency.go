@@ -1,4 +1,5 @@
-// Package user_latency contains library routines for ps-top related to the INFORMATION_SCHEMA.PROCESSLIST table.
+// Package user_latency contains library routines for ps-top related to the INFORMATION_SCHEMA.PROCESSLIST table
+// and the events_statements_summary_by_{user,host,account}_by_event_name tables.
 package user_latency
 
 import (
@@ -14,45 +15,111 @@ import (
 
 type mapStringInt map[string]int
 
-// UserLatency contains a table of rows
+// UserLatency contains the activity by user, host or account
 type UserLatency struct {
 	baseobject.BaseObject
-	current ProcesslistRows // processlist
-	Results Rows            // results by user
-	Totals  Row             // totals of results
-	db      *sql.DB
+	current  ProcesslistRows // processlist
+	first    StatementRows   // initial statements for relative values
+	last     StatementRows   // last statements collected
+	Results  Rows            // results by user, host or account
+	Totals   Row             // totals of results
+	grouping Grouping        // how the results are grouped
+	source   Source
 }
 
-// NewUserLatency returns a user latency object
+// NewUserLatency returns a UserLatency collecting data from MySQL using the given db handle
 func NewUserLatency(ctx *context.Context, db *sql.DB) *UserLatency {
+	return NewUserLatencyWithSource(ctx, NewMySQLSource(db, ctx.Capabilities()))
+}
+
+// NewUserLatencyWithSource returns a user latency object
+func NewUserLatencyWithSource(ctx *context.Context, source Source) *UserLatency {
 	logger.Println("NewUserLatency()")
 	ul := &UserLatency{
-		db: db,
+		source: source,
 	}
 	ul.SetContext(ctx)
 
 	return ul
 }
 
-// Collect collects data from the db, updating initial
-// values if needed, and then subtracting initial values if we want
-// relative values, after which it stores totals.
-func (ul *UserLatency) Collect() {
+func (ul *UserLatency) updateFirstFromLast() {
+	ul.first = make(StatementRows, len(ul.last))
+	ul.SetFirstCollectTime(ul.LastCollectTime())
+	copy(ul.first, ul.last)
+}
+
+// Collect collects the processlist and the statements run from the db,
+// updating initial values if needed, and then subtracting initial values
+// from the statements if we want relative values, after which it
+// combines them by user, host or account and stores totals.
+func (ul *UserLatency) Collect() error {
 	logger.Println("UserLatency.Collect() - starting collection of data")
 	start := time.Now()
 
-	ul.current = collect(ul.db)
-	logger.Println("t.current collected", len(ul.current), "row(s) from SELECT")
+	var collected ProcesslistRows
+	if err := ul.CollectFrom("processlist", &collected, func() (err error) {
+		collected, err = ul.source.Collect(ul.QueryContext())
+		return err
+	}, nil); err != nil {
+		return err
+	}
+	var statements StatementRows
+	if err := ul.CollectFrom("user_statements", &statements, func() (err error) {
+		statements, err = ul.source.CollectStatements(ul.QueryContext())
+		return err
+	}, func() {
+		ul.current = collected
+		logger.Println("t.current collected", len(ul.current), "row(s) from SELECT")
+
+		if ul.Compensate(counterRows{&statements}) {
+			logger.Println("UserLatency.Collect() truncation detected")
+			ul.SetTruncated(ul.LastCollectTime())
+		}
+		ul.last = statements
+
+		if len(ul.first) == 0 && len(ul.last) > 0 {
+			logger.Println("ul.first: copying from ul.last (initial setup)")
+			ul.updateFirstFromLast()
+		}
 
-	ul.processlist2byUser()
+		// check for reload initial characteristics
+		if ul.first.needsRefresh(ul.last) {
+			logger.Println("ul.first: copying from ul.last (data needs refreshing)")
+			ul.updateFirstFromLast()
+			ul.ResetSnapshots()
+		}
+		ul.AddSnapshot(ul.last)
 
+		ul.makeResults()
+	}); err != nil {
+		return err
+	}
 	logger.Println("UserLatency.Collect() END, took:", time.Duration(time.Since(start)).String())
+
+	return nil
+}
+
+// makeResults subtracts the initial statements if needed and combines
+// them with the processlist by the current grouping.
+func (ul *UserLatency) makeResults() {
+	statements := make(StatementRows, len(ul.last))
+	copy(statements, ul.last)
+	if ul.WantWindowStats() {
+		if baseline, ok := ul.WindowBaseline(); ok {
+			statements.subtract(baseline.(StatementRows))
+		}
+	} else if ul.WantRelativeStats() {
+		statements.subtract(ul.first)
+	}
+
+	ul.combine(statements.grouped(ul.grouping))
 }
 
 func (ul UserLatency) countRow() int {
 	var count int
 	for row := range ul.Results {
-		if ul.Results[row].Username != "" {
+		if ul.Results[row].Name != "" {
 			count++
 		}
 	}
@@ -68,24 +135,21 @@ func getHostname(hostPort string) string {
 	return hostPort // shouldn't happen !!!
 }
 
-// read in processlist and add the appropriate values into a new pl_by_user table
-func (ul *UserLatency) processlist2byUser() {
-	logger.Println("UserLatency.processlist2byUser() START")
+// combine reads in the processlist and the statements run and adds the
+// appropriate values into a new table by user, host or account
+func (ul *UserLatency) combine(statements StatementRows) {
+	logger.Println("UserLatency.combine() START")
 
 	reActiveReplMasterThread := regexp.MustCompile("Sending binlog event to slave")
-	reSelect := regexp.MustCompile(`(?i)SELECT`) // make case insensitive
-	reInsert := regexp.MustCompile(`(?i)INSERT`) // make case insensitive
-	reUpdate := regexp.MustCompile(`(?i)UPDATE`) // make case insensitive
-	reDelete := regexp.MustCompile(`(?i)DELETE`) // make case insensitive
 
 	var row Row
 	var myHosts mapStringInt
 	var myDB mapStringInt
 	var ok bool
 
-	rowByUser := make(map[string]Row)
-	hostsByUser := make(map[string]mapStringInt)
-	dbsByUser := make(map[string]mapStringInt)
+	rowByName := make(map[string]Row)
+	hostsByName := make(map[string]mapStringInt)
+	dbsByName := make(map[string]mapStringInt)
 
 	// global values for totals.
 	globalHosts := make(map[string]int)
@@ -94,12 +158,12 @@ func (ul *UserLatency) processlist2byUser() {
 	for i := range ul.current {
 		// munge the Username for special purposes (event scheduler, replication threads etc)
 		id := ul.current[i].ID
-		Username := ul.current[i].user // limit size for display
-		host := getHostname(ul.current[i].host)
-		command := ul.current[i].command
-		db := ul.current[i].db
-		info := ul.current[i].info
-		state := ul.current[i].state
+		Username := ul.current[i].User // limit size for display
+		host := getHostname(ul.current[i].Host)
+		command := ul.current[i].Command
+		db := ul.current[i].Db
+		state := ul.current[i].State
+		name := ul.grouping.name(Username, host)
 
 		logger.Println("- id/user/host:", id, Username, host)
 
@@ -111,24 +175,20 @@ func (ul *UserLatency) processlist2byUser() {
 			globalDbs[db] = 1
 		}
 
-		if oldRow, ok := rowByUser[Username]; ok {
-			logger.Println("- found old row in rowByUser")
+		if oldRow, ok := rowByName[name]; ok {
+			logger.Println("- found old row in rowByName")
 			row = oldRow // get old row
 		} else {
-			logger.Println("- NOT found old row in rowByUser")
-			// create new row - RESET THE VALUES !!!!
-			rowp := new(Row)
-			row = *rowp
-			row.Username = ul.current[i].user
-			rowByUser[Username] = row
+			logger.Println("- NOT found old row in rowByName")
+			row = Row{Name: name}
 		}
 		row.Connections++
 		// ignore system SQL threads (may be more to filter out)
 		if Username != "system user" && host != "" && command != "Binlog Dump" {
 			if command == "Sleep" {
-				row.Sleeptime += ul.current[i].time
+				row.Sleeptime += ul.current[i].Time
 			} else {
-				row.Runtime += ul.current[i].time
+				row.Runtime += ul.current[i].Time
 				row.Active++
 			}
 		}
@@ -138,58 +198,70 @@ func (ul *UserLatency) processlist2byUser() {
 
 		// add the host if not known already
 		if host != "" {
-			if myHosts, ok = hostsByUser[Username]; !ok {
+			if myHosts, ok = hostsByName[name]; !ok {
 				myHosts = make(mapStringInt)
 			}
 			myHosts[host] = 1 // whatever - value doesn't matter
-			hostsByUser[Username] = myHosts
+			hostsByName[name] = myHosts
 		}
-		row.Hosts = uint64(len(hostsByUser[Username]))
+		row.Hosts = uint64(len(hostsByName[name]))
 
 		// add the db count if not known already
 		if db != "" {
-			if myDB, ok = dbsByUser[Username]; !ok {
+			if myDB, ok = dbsByName[name]; !ok {
 				myDB = make(mapStringInt)
 			}
 			myDB[db] = 1 // whatever - value doesn't matter
-			dbsByUser[Username] = myDB
+			dbsByName[name] = myDB
 		}
-		row.Dbs = uint64(len(dbsByUser[Username]))
+		row.Dbs = uint64(len(dbsByName[name]))
 
-		if reSelect.MatchString(info) == true {
-			row.Selects++
-		}
-		if reInsert.MatchString(info) == true {
-			row.Inserts++
-		}
-		if reUpdate.MatchString(info) == true {
-			row.Updates++
-		}
-		if reDelete.MatchString(info) == true {
-			row.Deletes++
-		}
+		rowByName[name] = row
+	}
 
-		rowByUser[Username] = row
+	// add the statements run, which may be by users no longer connected
+	for i := range statements {
+		name := statements[i].Name
+		row, ok := rowByName[name]
+		if !ok {
+			row = Row{Name: name}
+		}
+		row.addStatements(statements[i])
+		rowByName[name] = row
 	}
 
-	results := make(Rows, 0, len(rowByUser))
-	for _, v := range rowByUser {
-		results = append(results, v)
+	results := make(Rows, 0, len(rowByName))
+	for _, v := range rowByName {
+		if v.Connections > 0 || v.Latency > 0 || v.Statements() > 0 {
+			results = append(results, v)
+		}
 	}
 	ul.Results = results
 	ul.Totals = ul.Results.totals()
 	ul.Totals.Hosts = uint64(len(globalHosts))
 	ul.Totals.Dbs = uint64(len(globalDbs))
 
-	logger.Println("UserLatency.processlist2byUser() END")
+	logger.Println("UserLatency.combine() END")
+}
+
+// Grouping returns how the results are grouped
+func (ul UserLatency) Grouping() Grouping {
+	return ul.grouping
+}
+
+// NextGrouping groups the results by the next of user, host or account
+func (ul *UserLatency) NextGrouping() {
+	ul.grouping = ul.grouping.Next()
+	ul.makeResults()
 }
 
-// HaveRelativeStats returns if we have relative information
+// HaveRelativeStats is true for this object
 func (ul UserLatency) HaveRelativeStats() bool {
-	return false
+	return true
 }
 
-// SetFirstFromLast - NOT IMPLEMENTED
+// SetFirstFromLast resets the statistics to current values
 func (ul *UserLatency) SetFirstFromLast() {
-	logger.Println("user_latency.UserLatency.SetFirstFromLast() NOT IMPLEMENTED")
+	ul.updateFirstFromLast()
+	ul.makeResults()
 }
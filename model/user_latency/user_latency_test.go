@@ -0,0 +1,160 @@
+package user_latency
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/sjmudd/ps-top/capabilities"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+func TestGetHostname(t *testing.T) {
+	var tests = []struct {
+		hostPort string
+		expected string
+	}{
+		{"", ""},
+		{"localhost", "localhost"},
+		{"10.0.0.1:53422", "10.0.0.1"},
+	}
+
+	for _, test := range tests {
+		if got := getHostname(test.hostPort); got != test.expected {
+			t.Errorf("getHostname(%q): expected %q, actual %q", test.hostPort, test.expected, got)
+		}
+	}
+}
+
+func TestThreadsUnavailable(t *testing.T) {
+	var tests = []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{&mysql.MySQLError{Number: 1142, Message: "SELECT command denied to user 'myuser'@'localhost' for table 'threads'"}, true},
+		{fmt.Errorf("collecting: %w", &mysql.MySQLError{Number: 1142}), true},
+		{&mysql.MySQLError{Number: 1054, Message: "Unknown column 'PROCESSLIST_ID' in 'field list'"}, false},
+		{errors.New("Error 1142: SELECT command denied"), false},
+		{errors.New("invalid connection"), false},
+	}
+
+	for _, test := range tests {
+		if got := threadsUnavailable(test.err); got != test.expected {
+			t.Errorf("threadsUnavailable(%v): expected %v, actual %v", test.err, test.expected, got)
+		}
+	}
+}
+
+func TestNewMySQLSource(t *testing.T) {
+	var tests = []struct {
+		version     string
+		processlist bool
+	}{
+		{"5.5.62", true},
+		{"5.6.51", false},
+		{"10.3.27-MariaDB", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if got := NewMySQLSource(nil, capabilities.New(test.version, "")).processlist; got != test.processlist {
+			t.Errorf("NewMySQLSource(%q): expected processlist %v, actual %v", test.version, test.processlist, got)
+		}
+	}
+}
+
+func TestGroupingName(t *testing.T) {
+	var tests = []struct {
+		grouping Grouping
+		expected string
+	}{
+		{ByUser, "app"},
+		{ByHost, "10.0.0.1"},
+		{ByAccount, "app@10.0.0.1"},
+	}
+
+	for _, test := range tests {
+		if got := test.grouping.name("app", "10.0.0.1"); got != test.expected {
+			t.Errorf("%v.name(): expected %q, actual %q", test.grouping, test.expected, got)
+		}
+	}
+	if got := ByAccount.Next(); got != ByUser {
+		t.Errorf("ByAccount.Next(): expected ByUser, actual %v", got)
+	}
+}
+
+func TestStatementRowOther(t *testing.T) {
+	row := StatementRow{CountStar: 10, Selects: 4, Inserts: 2, Updates: 1, Deletes: 1}
+	if got := row.Other(); got != 2 {
+		t.Errorf("%v.Other(): expected 2, actual %d", row, got)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	source := &MemorySource{
+		Rows: ProcesslistRows{
+			{ID: 1, User: "app", Host: "10.0.0.1:1000", Db: "shop", Command: "Query", Time: 3, Info: "SELECT * FROM orders"},
+			{ID: 2, User: "app", Host: "10.0.0.2:1000", Db: "shop", Command: "Sleep", Time: 10},
+			{ID: 3, User: "app", Host: "10.0.0.1:1001", Db: "stock", Command: "Query", Time: 1, Info: "update items set qty = 1"},
+			{ID: 4, User: "system user", Command: "Connect", Time: 100, State: "Waiting for master to send event"},
+		},
+		Statements: StatementRows{
+			{Grouping: ByUser, Name: "app", SumTimerWait: 100, CountStar: 10, Selects: 5, Updates: 2},
+			{Grouping: ByUser, Name: "batch", SumTimerWait: 50, CountStar: 1, Deletes: 1},
+			{Grouping: ByHost, Name: "10.0.0.1", SumTimerWait: 60, CountStar: 6, Selects: 6},
+			{Grouping: ByHost, Name: "10.0.0.2", SumTimerWait: 90, CountStar: 5, Selects: 5},
+		},
+	}
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	ctx.SetWantRelativeStats(true)
+	ul := NewUserLatencyWithSource(ctx, source)
+	ul.Collect()
+
+	// the statements run since the first collection are shown
+	source.Statements[0] = StatementRow{Grouping: ByUser, Name: "app", SumTimerWait: 130, CountStar: 14, Selects: 6, Inserts: 1, Updates: 2}
+	ul.Collect()
+
+	expected := map[string]Row{
+		"app":         {Name: "app", Runtime: 4, Sleeptime: 10, Connections: 3, Active: 2, Hosts: 2, Dbs: 2, Latency: 30, Selects: 1, Inserts: 1, Other: 2},
+		"system user": {Name: "system user", Connections: 1},
+	}
+	if len(ul.Results) != len(expected) {
+		t.Fatalf("Collect(): expected %d rows, actual %v", len(expected), ul.Results)
+	}
+	for _, row := range ul.Results {
+		if row != expected[row.Name] {
+			t.Errorf("Collect(): user %q: expected %v, actual %v", row.Name, expected[row.Name], row)
+		}
+	}
+
+	totals := Row{Name: "Totals", Runtime: 4, Sleeptime: 10, Connections: 4, Active: 2, Hosts: 2, Dbs: 2, Latency: 30, Selects: 1, Inserts: 1, Other: 2}
+	if ul.Totals != totals {
+		t.Errorf("Collect(): expected totals %v, actual %v", totals, ul.Totals)
+	}
+
+	// with absolute values users no longer connected are shown too
+	ctx.SetWantRelativeStats(false)
+	ul.NextGrouping()
+	if ul.Grouping() != ByHost {
+		t.Fatalf("NextGrouping(): expected ByHost, actual %v", ul.Grouping())
+	}
+	hosts := map[string]Row{
+		"10.0.0.1": {Name: "10.0.0.1", Runtime: 4, Connections: 2, Active: 2, Hosts: 1, Dbs: 2, Latency: 60, Selects: 6},
+		"10.0.0.2": {Name: "10.0.0.2", Sleeptime: 10, Connections: 1, Hosts: 1, Dbs: 1, Latency: 90, Selects: 5},
+		"":         {Connections: 1},
+	}
+	if len(ul.Results) != len(hosts) {
+		t.Fatalf("NextGrouping(): expected %d rows, actual %v", len(hosts), ul.Results)
+	}
+	for _, row := range ul.Results {
+		if row != hosts[row.Name] {
+			t.Errorf("NextGrouping(): host %q: expected %v, actual %v", row.Name, hosts[row.Name], row)
+		}
+	}
+}
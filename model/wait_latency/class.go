@@ -0,0 +1,109 @@
+// Package wait_latency contains the library routines for managing the
+// wait events of events_waits_summary_global_by_event_name.
+package wait_latency
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Classes are the names of the wait event classes which can be
+// collected, the name being the instrument name without the leading
+// wait/ and synch/.
+var Classes = []string{"mutex", "rwlock", "sxlock", "cond", "io/file", "io/table", "io/socket", "lock"}
+
+// ParseClasses returns the classes in the given comma-separated list,
+// or nil, meaning all classes, if the list is empty.
+func ParseClasses(list string) ([]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	var classes []string
+	for _, class := range strings.Split(list, ",") {
+		class = strings.TrimSpace(class)
+		if !validClass(class) {
+			return nil, fmt.Errorf("unknown wait class %q, possible values: %s", class, strings.Join(Classes, ", "))
+		}
+		classes = append(classes, class)
+	}
+
+	return classes, nil
+}
+
+// validClass returns true if class is one of Classes
+func validClass(class string) bool {
+	for i := range Classes {
+		if Classes[i] == class {
+			return true
+		}
+	}
+	return false
+}
+
+// instrument returns the setup_instruments name prefix of a class
+func instrument(class string) string {
+	switch class {
+	case "mutex", "rwlock", "sxlock", "cond":
+		return "wait/synch/" + class + "/"
+	}
+	return "wait/" + class + "/"
+}
+
+// Instruments returns the setup_instruments names to match with LIKE to
+// collect the given classes, or all classes if none are given.
+func Instruments(classes []string) []string {
+	if len(classes) == 0 {
+		classes = Classes
+	}
+	instruments := make([]string, 0, len(classes))
+	for _, class := range classes {
+		instruments = append(instruments, instrument(class)+"%")
+	}
+	return instruments
+}
+
+// shortName returns the event name without the leading wait/ and synch/
+// so it starts with the class, e.g. mutex/innodb/trx_mutex.
+func shortName(eventName string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(eventName, "wait/"), "synch/")
+}
+
+// className returns the class of a short name
+func className(name string) string {
+	parts := strings.SplitN(name, "/", 3)
+	if parts[0] == "io" && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+// split returns the class of a short name and the names below it
+func split(name string) (string, []string) {
+	class := className(name)
+	rest := strings.TrimPrefix(strings.TrimPrefix(name, class), "/")
+	if rest == "" {
+		return class, nil
+	}
+	return class, strings.Split(rest, "/")
+}
+
+// levels returns the number of levels of the hierarchy in a short name
+// where the class is the first level.
+func levels(name string) int {
+	_, parts := split(name)
+	return 1 + len(parts)
+}
+
+// level returns the short name cut down to the given level of the
+// hierarchy where level 1 is the class.
+func level(name string, depth int) string {
+	class, parts := split(name)
+	if depth <= 1 {
+		return class
+	}
+	if depth-1 >= len(parts) {
+		return name
+	}
+	return class + "/" + strings.Join(parts[:depth-1], "/")
+}
@@ -0,0 +1,52 @@
+// Package wait_latency contains the library routines for managing the
+// wait events of events_waits_summary_global_by_event_name.
+package wait_latency
+
+import (
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Row contains the waits of an event, or of a level of the event hierarchy
+type Row struct {
+	Name         string // event name without the leading wait/ and synch/
+	SumTimerWait uint64
+	CountStar    uint64
+	MaxTimerWait uint64 // the largest value, not a counter
+}
+
+// AvgTimerWait returns the average latency of the waits
+func (row Row) AvgTimerWait() uint64 {
+	if row.CountStar == 0 {
+		return 0
+	}
+	return row.SumTimerWait / row.CountStar
+}
+
+func (row *Row) add(other Row) {
+	row.SumTimerWait += other.SumTimerWait
+	row.CountStar += other.CountStar
+	if other.MaxTimerWait > row.MaxTimerWait {
+		row.MaxTimerWait = other.MaxTimerWait
+	}
+}
+
+// subtract the countable values in one row from another.
+// MaxTimerWait is kept as there's no way to know the maximum of the interval.
+func (row *Row) subtract(other Row) {
+	// check for issues here (we have a bug) and log it
+	// - this situation should not happen so there's a logic bug somewhere else
+	if row.SumTimerWait >= other.SumTimerWait {
+		row.SumTimerWait -= other.SumTimerWait
+		row.CountStar -= other.CountStar
+	} else {
+		logger.Println("WARNING: Row.subtract() - subtraction problem! (not subtracting)")
+		logger.Println("row=", row)
+		logger.Println("other=", other)
+	}
+}
+
+// decreased returns true if the counters have gone down since the
+// previous values were collected so the row has been truncated.
+func (row Row) decreased(previous Row) bool {
+	return row.SumTimerWait < previous.SumTimerWait || row.CountStar < previous.CountStar
+}
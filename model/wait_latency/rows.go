@@ -0,0 +1,124 @@
+// Package wait_latency contains the library routines for managing the
+// wait events of events_waits_summary_global_by_event_name.
+package wait_latency
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// Rows contains a slice of Row
+type Rows []Row
+
+func (rows Rows) totals() Row {
+	var totals Row
+	totals.Name = "Totals"
+
+	for i := range rows {
+		totals.add(rows[i])
+	}
+
+	return totals
+}
+
+func collect(ctx context.Context, dbh *sql.DB, classes []string) (Rows, error) {
+	var t Rows
+
+	instruments := Instruments(classes)
+	conditions := make([]string, 0, len(instruments))
+	args := make([]interface{}, 0, len(instruments))
+	for i := range instruments {
+		conditions = append(conditions, "EVENT_NAME LIKE ?")
+		args = append(args, instruments[i])
+	}
+	query := "SELECT EVENT_NAME, SUM_TIMER_WAIT, COUNT_STAR, MAX_TIMER_WAIT FROM events_waits_summary_global_by_event_name WHERE SUM_TIMER_WAIT > 0 AND (" + strings.Join(conditions, " OR ") + ")"
+	logger.Printf("collect(?,%v): %s\n", classes, query)
+
+	rows, err := dbh.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r Row
+		if err := rows.Scan(
+			&r.Name,
+			&r.SumTimerWait,
+			&r.CountStar,
+			&r.MaxTimerWait); err != nil {
+			return nil, err
+		}
+		r.Name = shortName(r.Name)
+		t = append(t, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// collapse returns the rows combined to the given level of the event
+// hierarchy, keeping the order in which each name is first seen.
+func (rows Rows) collapse(depth int) Rows {
+	var collapsed Rows
+	byName := make(map[string]int)
+
+	for i := range rows {
+		name := level(rows[i].Name, depth)
+		if j, ok := byName[name]; ok {
+			collapsed[j].add(rows[i])
+			continue
+		}
+		byName[name] = len(collapsed)
+		row := rows[i]
+		row.Name = name
+		collapsed = append(collapsed, row)
+	}
+
+	return collapsed
+}
+
+// remove the initial values from those rows where there's a match
+// - if we find a row we can't match ignore it
+func (rows *Rows) subtract(initial Rows) {
+	initialByName := make(map[string]int)
+
+	// iterate over rows by name
+	for i := range initial {
+		initialByName[initial[i].Name] = i
+	}
+
+	for i := range *rows {
+		if initialIndex, ok := initialByName[(*rows)[i].Name]; ok {
+			(*rows)[i].subtract(initial[initialIndex])
+		}
+	}
+}
+
+// if the data in t2 is "newer", "has more values" than t then it needs refreshing.
+// check this by comparing totals.
+func (rows Rows) needsRefresh(otherRows Rows) bool {
+	totals := rows.totals()
+	otherTotals := otherRows.totals()
+
+	return totals.SumTimerWait > otherTotals.SumTimerWait
+}
+
+// counterRows gives baseobject.Compensate access to the rows by name
+type counterRows struct {
+	rows *Rows
+}
+
+func (c counterRows) Len() int              { return len(*c.rows) }
+func (c counterRows) Key(i int) string      { return (*c.rows)[i].Name }
+func (c counterRows) Row(i int) interface{} { return (*c.rows)[i] }
+func (c counterRows) Decreased(i int, previous interface{}) bool {
+	return (*c.rows)[i].decreased(previous.(Row))
+}
+func (c counterRows) Add(i int, offset interface{}) { (*c.rows)[i].add(offset.(Row)) }
+func (c counterRows) Append(row interface{})        { *c.rows = append(*c.rows, row.(Row)) }
@@ -0,0 +1,46 @@
+package wait_latency
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Source provides the rows collected from events_waits_summary_global_by_event_name
+type Source interface {
+	Collect(ctx context.Context) (Rows, error)
+}
+
+// MySQLSource is the default Source which collects the rows from MySQL
+type MySQLSource struct {
+	db      *sql.DB
+	classes []string // wait classes to collect
+}
+
+// NewMySQLSource returns a Source collecting the rows of the given
+// wait classes using the given db handle
+func NewMySQLSource(db *sql.DB, classes []string) *MySQLSource {
+	return &MySQLSource{db: db, classes: classes}
+}
+
+// Collect returns the rows collected from MySQL
+func (s *MySQLSource) Collect(ctx context.Context) (Rows, error) {
+	return collect(ctx, s.db, s.classes)
+}
+
+// MemorySource is a Source holding the rows to return in memory which
+// is useful for testing. If Err is set it is returned instead of the rows.
+type MemorySource struct {
+	Rows Rows
+	Err  error
+}
+
+// Collect returns a copy of the rows held in memory.
+func (s *MemorySource) Collect(ctx context.Context) (Rows, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	rows := make(Rows, len(s.Rows))
+	copy(rows, s.Rows)
+
+	return rows, nil
+}
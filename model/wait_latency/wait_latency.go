@@ -0,0 +1,145 @@
+// Package wait_latency contains the library routines for managing the
+// wait events of events_waits_summary_global_by_event_name.
+package wait_latency
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/sjmudd/ps-top/baseobject"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/logger"
+)
+
+// WaitLatency holds the wait events collected, shown down to a level of the hierarchy
+type WaitLatency struct {
+	baseobject.BaseObject      // embedded
+	first                 Rows // initial data for relative values
+	last                  Rows // last loaded values
+	Results               Rows // results (maybe with subtraction) combined to depth
+	Totals                Row  // totals of results
+	depth                 int  // level of the hierarchy shown, 1 being the class
+	source                Source
+}
+
+// NewWaitLatency returns a WaitLatency collecting the given wait classes from MySQL using the given db handle
+func NewWaitLatency(ctx *context.Context, db *sql.DB, classes []string) *WaitLatency {
+	return NewWaitLatencyWithSource(ctx, NewMySQLSource(db, classes))
+}
+
+// NewWaitLatencyWithSource returns a WaitLatency collecting data from the given source
+func NewWaitLatencyWithSource(ctx *context.Context, source Source) *WaitLatency {
+	logger.Println("NewWaitLatency()")
+	wl := &WaitLatency{
+		depth:  1,
+		source: source,
+	}
+	wl.SetContext(ctx)
+
+	return wl
+}
+
+func (wl *WaitLatency) updateFirstFromLast() {
+	wl.first = make(Rows, len(wl.last))
+	wl.SetFirstCollectTime(wl.LastCollectTime())
+	copy(wl.first, wl.last)
+}
+
+// Collect collects data from the db, updating first
+// values if needed, and then subtracting first values if we want
+// relative values, after which it stores totals.
+func (wl *WaitLatency) Collect() error {
+	start := time.Now()
+	var collected Rows
+	if err := wl.CollectFrom("wait_latency", &collected, func() (err error) {
+		collected, err = wl.source.Collect(wl.QueryContext())
+		return err
+	}, func() {
+		if wl.Compensate(counterRows{&collected}) {
+			logger.Println("WaitLatency.Collect() truncation detected")
+			wl.SetTruncated(wl.LastCollectTime())
+		}
+		wl.last = collected
+		logger.Println("wl.last collected", len(wl.last), "row(s) from SELECT")
+
+		if len(wl.first) == 0 && len(wl.last) > 0 {
+			logger.Println("wl.first: copying from wl.last (initial setup)")
+			wl.updateFirstFromLast()
+		}
+
+		// check for reload initial characteristics
+		if wl.first.needsRefresh(wl.last) {
+			logger.Println("wl.first: copying from wl.last (data needs refreshing)")
+			wl.updateFirstFromLast()
+			wl.ResetSnapshots()
+		}
+		wl.AddSnapshot(wl.last)
+
+		wl.makeResults()
+	}); err != nil {
+		return err
+	}
+	logger.Println("WaitLatency.Collect() END, took:", time.Duration(time.Since(start)).String())
+
+	return nil
+}
+
+// makeResults subtracts the initial values from the individual events
+// and then combines them to the level of the hierarchy being shown.
+func (wl *WaitLatency) makeResults() {
+	results := make(Rows, len(wl.last))
+	copy(results, wl.last)
+	if wl.WantWindowStats() {
+		if baseline, ok := wl.WindowBaseline(); ok {
+			results.subtract(baseline.(Rows))
+		}
+	} else if wl.WantRelativeStats() {
+		results.subtract(wl.first)
+	}
+
+	wl.Results = results.collapse(wl.depth)
+	wl.Totals = results.totals()
+}
+
+// SetFirstFromLast resets the statistics to current values
+func (wl *WaitLatency) SetFirstFromLast() {
+	wl.updateFirstFromLast()
+	wl.makeResults()
+}
+
+// HaveRelativeStats is true for this object
+func (wl WaitLatency) HaveRelativeStats() bool {
+	return true
+}
+
+// Depth returns the level of the hierarchy shown, 1 being the class
+func (wl WaitLatency) Depth() int {
+	return wl.depth
+}
+
+// maxDepth returns the number of levels of the deepest event collected
+func (wl WaitLatency) maxDepth() int {
+	depth := 1
+	for i := range wl.last {
+		if l := levels(wl.last[i].Name); l > depth {
+			depth = l
+		}
+	}
+	return depth
+}
+
+// Expand shows one more level of the hierarchy if there is one
+func (wl *WaitLatency) Expand() {
+	if wl.depth < wl.maxDepth() {
+		wl.depth++
+		wl.makeResults()
+	}
+}
+
+// Collapse shows one less level of the hierarchy, down to the classes
+func (wl *WaitLatency) Collapse() {
+	if wl.depth > 1 {
+		wl.depth--
+		wl.makeResults()
+	}
+}
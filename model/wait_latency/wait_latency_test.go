@@ -0,0 +1,125 @@
+package wait_latency
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+func TestParseClasses(t *testing.T) {
+	var tests = []struct {
+		list     string
+		expected []string
+		err      bool
+	}{
+		{"", nil, false},
+		{"mutex", []string{"mutex"}, false},
+		{"io/file, lock", []string{"io/file", "lock"}, false},
+		{"mutex,latch", nil, true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseClasses(test.list)
+		if (err != nil) != test.err {
+			t.Errorf("ParseClasses(%q): unexpected error: %v", test.list, err)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("ParseClasses(%q): expected %v, got %v", test.list, test.expected, got)
+		}
+	}
+}
+
+func TestInstruments(t *testing.T) {
+	expected := []string{"wait/synch/mutex/%", "wait/io/file/%", "wait/lock/%"}
+	if got := Instruments([]string{"mutex", "io/file", "lock"}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Instruments(): expected %v, got %v", expected, got)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	var tests = []struct {
+		eventName string
+		depth     int
+		expected  string
+	}{
+		{"wait/synch/mutex/innodb/trx_mutex", 1, "mutex"},
+		{"wait/synch/mutex/innodb/trx_mutex", 2, "mutex/innodb"},
+		{"wait/synch/mutex/innodb/trx_mutex", 3, "mutex/innodb/trx_mutex"},
+		{"wait/synch/mutex/innodb/trx_mutex", 9, "mutex/innodb/trx_mutex"},
+		{"wait/io/file/innodb/innodb_data_file", 1, "io/file"},
+		{"wait/io/file/innodb/innodb_data_file", 2, "io/file/innodb"},
+		{"wait/lock/table/sql/handler", 2, "lock/table"},
+		{"wait/io/table/sql/handler", 1, "io/table"},
+	}
+
+	for _, test := range tests {
+		if got := level(shortName(test.eventName), test.depth); got != test.expected {
+			t.Errorf("level(%q, %d): expected %q, got %q", test.eventName, test.depth, test.expected, got)
+		}
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	rows := Rows{
+		{"mutex/innodb/trx_mutex", 10, 2, 6},
+		{"io/file/innodb/innodb_data_file", 20, 4, 9},
+		{"mutex/innodb/lock_mutex", 5, 1, 5},
+		{"mutex/sql/LOCK_open", 1, 1, 1},
+	}
+
+	expected := Rows{
+		{"mutex", 16, 4, 6},
+		{"io/file", 20, 4, 9},
+	}
+	if got := rows.collapse(1); !reflect.DeepEqual(got, expected) {
+		t.Errorf("collapse(1): expected %v, got %v", expected, got)
+	}
+
+	expected = Rows{
+		{"mutex/innodb", 15, 3, 6},
+		{"io/file/innodb", 20, 4, 9},
+		{"mutex/sql", 1, 1, 1},
+	}
+	if got := rows.collapse(2); !reflect.DeepEqual(got, expected) {
+		t.Errorf("collapse(2): expected %v, got %v", expected, got)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	ctx.SetWantRelativeStats(true)
+	source := &MemorySource{Rows: Rows{{"mutex/innodb/trx_mutex", 10, 5, 3}}}
+	wl := NewWaitLatencyWithSource(ctx, source)
+
+	wl.Collect()
+	source.Rows = Rows{{"mutex/innodb/trx_mutex", 15, 6, 4}, {"rwlock/innodb/btr_search_latch", 2, 1, 2}}
+	wl.Collect()
+
+	if expected := (Row{"Totals", 7, 2, 4}); wl.Totals != expected {
+		t.Errorf("Collect(): expected totals %v, got %v", expected, wl.Totals)
+	}
+	if len(wl.Results) != 2 || wl.Results[0].Name != "mutex" {
+		t.Errorf("Collect(): expected the classes, got %v", wl.Results)
+	}
+
+	wl.Expand()
+	wl.Expand()
+	wl.Expand() // no more levels
+	if wl.Depth() != 3 {
+		t.Errorf("Expand(): expected depth 3, got %d", wl.Depth())
+	}
+	if wl.Results[0] != (Row{"mutex/innodb/trx_mutex", 5, 1, 4}) {
+		t.Errorf("Expand(): expected the events, got %v", wl.Results)
+	}
+
+	wl.Collapse()
+	wl.Collapse()
+	wl.Collapse() // already showing the classes
+	if wl.Depth() != 1 {
+		t.Errorf("Collapse(): expected depth 1, got %d", wl.Depth())
+	}
+}
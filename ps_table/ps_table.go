@@ -3,21 +3,35 @@
 package ps_table
 
 import (
+	"sync"
 	"time"
+
+	"github.com/sjmudd/ps-top/record"
 )
 
 // Tabler is the interface for access to performance_schema rows
 type Tabler interface {
-	Collect() // Collect collects data for the table from the database
+	Collect() error // Collect collects data for the table from the database
 	Description() string
 	EmptyRowContent() string
 	HaveRelativeStats() bool
 	Headings() string
+	HideColumns(names []string) error // HideColumns hides the named columns
 	FirstCollectTime() time.Time
 	LastCollectTime() time.Time
+	LastTruncation() time.Time // LastTruncation returns when the table was last seen to be truncated
 	Len() int
+	Records() []record.Record // Records returns the rows as typed records
 	RowContent() []string
 	SetFirstFromLast()
+	SetLocker(locker sync.Locker)    // SetLocker sets the lock held while the collected data is changed
+	SetSortColumn(name string) error // SetSortColumn sorts the results by the named column
+	SortColumns() []string           // SortColumns returns the names of the columns which can be sorted on
+	SortHeading() string             // SortHeading returns the heading of the column being sorted on
+	SortNext()
+	SortPrev()
+	SortReverse()
+	TotalRecord() record.Record // TotalRecord returns the totals as a typed record
 	TotalRowContent() string
 	WantRelativeStats() bool
 }
@@ -0,0 +1,128 @@
+package rc
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	go_ini "github.com/vaughan0/go-ini"
+
+	"github.com/sjmudd/ps-top/logger"
+)
+
+const (
+	defaultsSection = "defaults" // section holding the default values of the flags
+	profilePrefix   = "server:"  // starts the name of each section holding a server profile
+	sortSection     = "sort"     // section holding the column to sort each view by
+	columnsSection  = "columns"  // section holding the columns to hide in each view
+	profileFlag     = "profile"  // the flag choosing the profile, which can not come from a profile
+)
+
+// Apply sets the flags which were not given on the command line to the
+// values in ~/.pstoprc. The values in the [server:<profile>] section
+// are used if a profile is given, then those in the [defaults] section,
+// so the precedence is: flags > profile > defaults. The keys are the
+// names of the flags, e.g.
+// [defaults]
+// interval = 5
+// view = digest_latency
+//
+// [server:prod-db1]
+// host = db1.example.com
+// port = 3307
+// Keys which are not flags of this program are ignored as the file is
+// shared by ps-top and ps-stats.
+func Apply(flags *flag.FlagSet, profile string) error {
+	file, ok := loadFile()
+	if !ok {
+		if profile != "" {
+			return fmt.Errorf("unable to use profile %q: ~/.pstoprc can not be read", profile)
+		}
+		return nil
+	}
+
+	return apply(file, flags, profile)
+}
+
+// apply sets the flags not given on the command line from the file
+func apply(file go_ini.File, flags *flag.FlagSet, profile string) error {
+	set := make(map[string]bool) // flags given on the command line or already set
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	var sections []string
+	if profile != "" {
+		name := profilePrefix + profile
+		if _, ok := file[name]; !ok {
+			return fmt.Errorf("~/.pstoprc has no [%s] section", name)
+		}
+		sections = append(sections, name)
+	}
+	sections = append(sections, defaultsSection)
+
+	for _, name := range sections {
+		section := file[name]
+		keys := make([]string, 0, len(section))
+		for key := range section {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if set[key] || key == profileFlag {
+				continue
+			}
+			if flags.Lookup(key) == nil {
+				logger.Println("rc.Apply() ignoring", key, "in ["+name+"] which is not a flag")
+				continue
+			}
+			if err := flags.Set(key, section[key]); err != nil {
+				return fmt.Errorf("~/.pstoprc [%s]: %s: %v", name, key, err)
+			}
+			set[key] = true
+		}
+	}
+
+	return nil
+}
+
+// SortColumns returns the column to sort each view by, by view name,
+// from the [sort] section of ~/.pstoprc, e.g.
+// [sort]
+// table_io_latency = ops
+// digest_latency = latency
+func SortColumns() map[string]string {
+	file, ok := loadFile()
+	if !ok {
+		return nil
+	}
+
+	return file.Section(sortSection)
+}
+
+// HiddenColumns returns the columns to hide in each view, by view name,
+// from the [columns] section of ~/.pstoprc, e.g.
+// [columns]
+// table_io_latency = insert, update
+// threads = host, db
+func HiddenColumns() map[string][]string {
+	file, ok := loadFile()
+	if !ok {
+		return nil
+	}
+
+	return hiddenColumns(file)
+}
+
+// hiddenColumns returns the comma separated column names of each view in the [columns] section
+func hiddenColumns(file go_ini.File) map[string][]string {
+	hidden := make(map[string][]string)
+	for view, value := range file.Section(columnsSection) {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				hidden[view] = append(hidden[view], name)
+			}
+		}
+	}
+	return hidden
+}
@@ -0,0 +1,118 @@
+package rc
+
+import (
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	go_ini "github.com/vaughan0/go-ini"
+)
+
+const testConfig = `
+[defaults]
+interval = 5
+view = digest_latency
+limit = 20
+anonymise = true
+host = localhost
+format = json
+
+[server:prod-db1]
+host = db1.example.com
+port = 3307
+view = replication
+
+[sort]
+table_io_latency = ops
+
+[columns]
+table_io_latency = insert, update
+threads = host,
+`
+
+// testFlags returns a flag set with some of the flags of ps-top parsed from args
+func testFlags(t *testing.T, args ...string) *flag.FlagSet {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	flags.Int("interval", 1, "")
+	flags.Int("limit", 0, "")
+	flags.Int("port", 0, "")
+	flags.Bool("anonymise", false, "")
+	flags.String("host", "", "")
+	flags.String("view", "", "")
+	flags.String("profile", "", "")
+	if err := flags.Parse(args); err != nil {
+		t.Fatalf("Parse(%v): unexpected error: %v", args, err)
+	}
+	return flags
+}
+
+func TestApply(t *testing.T) {
+	file, err := go_ini.Load(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatalf("go_ini.Load(): unexpected error: %v", err)
+	}
+
+	var tests = []struct {
+		args     []string
+		profile  string
+		expected map[string]string
+	}{
+		// defaults apply to all flags not given and unknown keys (format) are ignored
+		{nil, "", map[string]string{"interval": "5", "view": "digest_latency", "limit": "20", "anonymise": "true", "host": "localhost", "port": "0"}},
+		// the profile overrides the defaults
+		{nil, "prod-db1", map[string]string{"interval": "5", "view": "replication", "host": "db1.example.com", "port": "3307"}},
+		// flags override the profile and the defaults
+		{[]string{"--view=threads", "--interval=2", "--port=3306"}, "prod-db1", map[string]string{"interval": "2", "view": "threads", "host": "db1.example.com", "port": "3306", "limit": "20"}},
+		// even when given the built-in default value
+		{[]string{"--anonymise=false", "--limit=0"}, "", map[string]string{"anonymise": "false", "limit": "0", "interval": "5"}},
+	}
+
+	for _, test := range tests {
+		flags := testFlags(t, test.args...)
+		if err := apply(file, flags, test.profile); err != nil {
+			t.Fatalf("apply(%v, %q): unexpected error: %v", test.args, test.profile, err)
+		}
+		for name, expected := range test.expected {
+			if got := flags.Lookup(name).Value.String(); got != expected {
+				t.Errorf("apply(%v, %q): expected %s = %q, got %q", test.args, test.profile, name, expected, got)
+			}
+		}
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	for _, test := range []struct {
+		config  string
+		profile string
+	}{
+		{testConfig, "unknown"},
+		{"[defaults]\ninterval = often\n", ""},
+		{"[server:db]\nport = none\n", "db"},
+	} {
+		file, err := go_ini.Load(strings.NewReader(test.config))
+		if err != nil {
+			t.Fatalf("go_ini.Load(%q): unexpected error: %v", test.config, err)
+		}
+		if err := apply(file, testFlags(t), test.profile); err == nil {
+			t.Errorf("apply(%q, %q): expected an error", test.config, test.profile)
+		}
+	}
+}
+
+func TestHiddenColumns(t *testing.T) {
+	file, err := go_ini.Load(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatalf("go_ini.Load(): unexpected error: %v", err)
+	}
+
+	expected := map[string][]string{
+		"table_io_latency": {"insert", "update"},
+		"threads":          {"host"},
+	}
+	if got := hiddenColumns(file); !reflect.DeepEqual(got, expected) {
+		t.Errorf("hiddenColumns(): expected %v, got %v", expected, got)
+	}
+}
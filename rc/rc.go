@@ -1,5 +1,7 @@
 // Package rc provides routines to read ~/.pstoprc
 // ps-top / ps-stats configuration
+// - the default values of the flags, server profiles and sort columns
+// - the custom views
 // - and to munge some table names based on the [munge] section (if present)
 package rc
 
@@ -65,23 +67,15 @@ func convertFilename(filename string) string {
 	return filename
 }
 
-// Load the ~/.pstoprc regexp expressions in section [munge]
-func loadRegexps() {
-	if loadedRegexps {
-		return
-	}
-	loadedRegexps = true
-
-	logger.Println("rc.loadRegexps()")
-
-	haveRegexps = false
+// loadFile loads ~/.pstoprc, returning false if it is not there
+func loadFile() (go_ini.File, bool) {
 	filename := convertFilename(pstoprc)
 
 	// Is the file is there?
 	f, err := os.Open(filename)
 	if err != nil {
-		logger.Println("- unable to open " + filename + ", nothing to munge")
-		return // can't open file. This is not fatal. We just can't do anything useful.
+		logger.Println("- unable to open " + filename + ", no configuration to read")
+		return nil, false // can't open file. This is not fatal. We just can't do anything useful.
 	}
 	// If we get here the file is readable, so close it again.
 	err = f.Close()
@@ -95,6 +89,24 @@ func loadRegexps() {
 		log.Fatal("Could not load ~/.pstoprc", filename, ":", err)
 	}
 
+	return i, true
+}
+
+// Load the ~/.pstoprc regexp expressions in section [munge]
+func loadRegexps() {
+	if loadedRegexps {
+		return
+	}
+	loadedRegexps = true
+
+	logger.Println("rc.loadRegexps()")
+
+	haveRegexps = false
+	i, ok := loadFile()
+	if !ok {
+		return
+	}
+
 	// Note: This is wrong if I want to have an _ordered_ list of regexps
 	// as go-ini provides me a hash so I lose the ordering. This may not
 	// be desirable but as a first step accept this is broken.
@@ -0,0 +1,89 @@
+package rc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	go_ini "github.com/vaughan0/go-ini"
+
+	"github.com/sjmudd/ps-top/model/custom"
+)
+
+// viewPrefix starts the name of each section defining a custom view
+const viewPrefix = "view:"
+
+// Views returns the custom views defined in ~/.pstoprc, sorted by name.
+// - e.g.
+// [view:file_io]
+// query = SELECT file, count_read, total_read, sum_timer_wait FROM sys.x$io_global_by_file_by_bytes
+// keys = file
+// counters = count_read, total_read, sum_timer_wait
+// units = total_read:bytes, sum_timer_wait:time
+func Views() ([]custom.Definition, error) {
+	file, ok := loadFile()
+	if !ok {
+		return nil, nil
+	}
+
+	return views(file)
+}
+
+// views returns the custom views defined in the given file
+func views(file go_ini.File) ([]custom.Definition, error) {
+	var names []string
+	for name := range file {
+		if strings.HasPrefix(name, viewPrefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	definitions := make([]custom.Definition, 0, len(names))
+	for _, name := range names {
+		section := file[name]
+		units, err := parseUnits(section["units"])
+		if err != nil {
+			return nil, fmt.Errorf("~/.pstoprc [%s]: %v", name, err)
+		}
+		d, err := custom.NewDefinition(
+			strings.TrimPrefix(name, viewPrefix),
+			section["query"],
+			parseList(section["keys"]),
+			parseList(section["counters"]),
+			parseList(section["gauges"]),
+			units)
+		if err != nil {
+			return nil, fmt.Errorf("~/.pstoprc [%s]: %v", name, err)
+		}
+		definitions = append(definitions, d)
+	}
+
+	return definitions, nil
+}
+
+// parseList returns the items of a comma-separated list
+func parseList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// parseUnits returns the units of the columns given as a list of column:unit
+func parseUnits(list string) (map[string]string, error) {
+	units := make(map[string]string)
+	for _, item := range parseList(list) {
+		i := strings.Index(item, ":")
+		if i < 1 {
+			return nil, fmt.Errorf("units: expected column:unit, got %q", item)
+		}
+		units[strings.TrimSpace(item[:i])] = strings.TrimSpace(item[i+1:])
+	}
+
+	return units, nil
+}
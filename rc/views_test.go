@@ -0,0 +1,68 @@
+package rc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	go_ini "github.com/vaughan0/go-ini"
+
+	"github.com/sjmudd/ps-top/model/custom"
+)
+
+func TestViews(t *testing.T) {
+	file, err := go_ini.Load(strings.NewReader(`
+[munge]
+_[0-9]{8}$ = _YYYYMMDD
+
+[view:waits]
+query = SELECT event_name, count_star, sum_timer_wait FROM performance_schema.events_waits_summary_global_by_event_name WHERE count_star > 0
+keys = event_name
+counters = count_star, sum_timer_wait
+units = sum_timer_wait:time
+
+[view:connections]
+query = SELECT user, current_connections, total_connections FROM performance_schema.users
+keys = user
+counters = total_connections
+gauges = current_connections
+`))
+	if err != nil {
+		t.Fatalf("go_ini.Load(): unexpected error: %v", err)
+	}
+
+	definitions, err := views(file)
+	if err != nil {
+		t.Fatalf("views(): unexpected error: %v", err)
+	}
+	var names []string
+	for _, d := range definitions {
+		names = append(names, d.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"connections", "waits"}) {
+		t.Errorf("views(): expected the views connections and waits, actual %v", names)
+	}
+	expected := []custom.Column{{Name: "total_connections", Unit: custom.Count}, {Name: "current_connections", Gauge: true, Unit: custom.Count}}
+	if !reflect.DeepEqual(definitions[0].Columns, expected) {
+		t.Errorf("views(): expected the columns %v, actual %v", expected, definitions[0].Columns)
+	}
+	if definitions[1].Columns[1].Unit != custom.Time {
+		t.Errorf("views(): expected sum_timer_wait to be a time, actual %q", definitions[1].Columns[1].Unit)
+	}
+}
+
+func TestViewsErrors(t *testing.T) {
+	for _, config := range []string{
+		"[view:v]\nkeys = a\ncounters = b\n",
+		"[view:v]\nquery = SELECT a, b FROM t\ncounters = b\n",
+		"[view:v]\nquery = SELECT a, b FROM t\nkeys = a\ncounters = b\nunits = b\n",
+	} {
+		file, err := go_ini.Load(strings.NewReader(config))
+		if err != nil {
+			t.Fatalf("go_ini.Load(%q): unexpected error: %v", config, err)
+		}
+		if _, err := views(file); err == nil {
+			t.Errorf("views(%q): expected an error", config)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Package record holds the typed (structured) version of the rows shown
+// in the different views so they can be output in a machine readable format.
+package record
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Field is a named value of a Record. Values are kept in their raw units
+// (picoseconds, bytes, counts) rather than being formatted for display.
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Record is the ordered list of fields which represents a row of a view
+type Record []Field
+
+// Names returns the names of the fields in the record
+func (r Record) Names() []string {
+	names := make([]string, 0, len(r))
+	for i := range r {
+		names = append(names, r[i].Name)
+	}
+	return names
+}
+
+// Value returns the value of the named field and whether the field was found
+func (r Record) Value(name string) (interface{}, bool) {
+	for i := range r {
+		if r[i].Name == name {
+			return r[i].Value, true
+		}
+	}
+	return nil, false
+}
+
+// Strings returns the values of the fields in the record as strings
+func (r Record) Strings() []string {
+	values := make([]string, 0, len(r))
+	for i := range r {
+		values = append(values, fmt.Sprint(r[i].Value))
+	}
+	return values
+}
+
+// HasData returns true if any of the numeric values in the record are not zero
+func (r Record) HasData() bool {
+	for i := range r {
+		switch v := r[i].Value.(type) {
+		case uint64:
+			if v != 0 {
+				return true
+			}
+		case int64:
+			if v != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MarshalJSON returns the record as a JSON object keeping the fields in order
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+	for i := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(r[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(r[i].Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
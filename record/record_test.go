@@ -0,0 +1,60 @@
+package record
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHasData(t *testing.T) {
+	var tests = []struct {
+		input    Record
+		expected bool
+	}{
+		{Record{}, false},
+		{Record{{"name", "db.table"}, {"latency_ps", uint64(0)}}, false},
+		{Record{{"name", "db.table"}, {"latency_ps", uint64(10)}}, true},
+		{Record{{"name", "memory"}, {"current_bytes", int64(-1)}}, true},
+	}
+
+	for _, test := range tests {
+		if got := test.input.HasData(); got != test.expected {
+			t.Errorf("%v.HasData(): expected %v, got %v", test.input, test.expected, got)
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	r := Record{{"name", "db.table"}, {"latency_ps", uint64(1000)}}
+
+	if value, ok := r.Value("name"); !ok || value != "db.table" {
+		t.Errorf("%v.Value(name): expected db.table, got %v (found: %v)", r, value, ok)
+	}
+	if value, ok := r.Value("ops"); ok {
+		t.Errorf("%v.Value(ops): expected no value, got %v", r, value)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	r := Record{{"name", "db.table"}, {"latency_ps", uint64(1000)}, {"ops", uint64(3)}}
+	const expected = `{"name":"db.table","latency_ps":1000,"ops":3}`
+
+	got, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("json.Marshal(%v) failed: %v", r, err)
+	}
+	if string(got) != expected {
+		t.Errorf("json.Marshal(%v): expected %s, got %s", r, expected, got)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	r := Record{{"name", "db.table"}, {"current_bytes", int64(-10)}}
+	names, values := r.Names(), r.Strings()
+
+	if len(names) != 2 || names[0] != "name" || names[1] != "current_bytes" {
+		t.Errorf("%v.Names(): got %v", r, names)
+	}
+	if len(values) != 2 || values[0] != "db.table" || values[1] != "-10" {
+		t.Errorf("%v.Strings(): got %v", r, values)
+	}
+}
@@ -31,6 +31,19 @@ func (s *TermboxScreen) BoldPrintAt(x int, y int, text string) {
 	s.Flush()
 }
 
+// HighlightPrintAt displays reversed bold text at the location specified,
+// but does not try to display outside of the screen boundary.
+func (s *TermboxScreen) HighlightPrintAt(x int, y int, text string) {
+	offset := 0
+	for c := range text {
+		if (x + offset) < s.width {
+			termbox.SetCell(x+offset, y, rune(text[c]), s.fg|termbox.AttrBold|termbox.AttrReverse, s.bg)
+			offset++
+		}
+	}
+	s.Flush()
+}
+
 // Clear clears the screen
 func (s *TermboxScreen) Clear() {
 	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
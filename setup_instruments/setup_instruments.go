@@ -5,7 +5,6 @@ package setup_instruments
 import (
 	"database/sql"
 	"fmt"
-	"log"
 
 	"github.com/sjmudd/ps-top/logger"
 )
@@ -46,14 +45,23 @@ func NewSetupInstruments(dbh *sql.DB) SetupInstruments {
 	return SetupInstruments{dbh: dbh}
 }
 
-// EnableMonitoring enables mutex and stage monitoring
-func (si *SetupInstruments) EnableMonitoring() {
-	si.EnableMutexMonitoring()
-	si.EnableStageMonitoring()
+// EnableMonitoring enables mutex, stage and metadata lock monitoring
+// and the wait instruments matching the given names
+func (si *SetupInstruments) EnableMonitoring(waitInstruments []string) error {
+	if err := si.EnableMutexMonitoring(); err != nil {
+		return err
+	}
+	if err := si.EnableStageMonitoring(); err != nil {
+		return err
+	}
+	if err := si.EnableMetadataLockMonitoring(); err != nil {
+		return err
+	}
+	return si.EnableWaitMonitoring(waitInstruments)
 }
 
 // EnableStageMonitoring change settings to monitor stage/sql/%
-func (si *SetupInstruments) EnableStageMonitoring() {
+func (si *SetupInstruments) EnableStageMonitoring() error {
 	logger.Println("EnableStageMonitoring")
 	sqlMatch := "stage/sql/%"
 	sqlSelect := "SELECT NAME, ENABLED, TIMED FROM setup_instruments WHERE NAME LIKE '" + sqlMatch + "' AND 'YES' NOT IN (ENABLED,TIMED)"
@@ -61,20 +69,57 @@ func (si *SetupInstruments) EnableStageMonitoring() {
 	collecting := "Collecting setup_instruments stage/sql configuration settings"
 	updating := "Updating setup_instruments configuration for: stage/sql"
 
-	si.Configure(sqlSelect, collecting, updating)
+	err := si.Configure(sqlSelect, collecting, updating)
 	logger.Println("EnableStageMonitoring finishes")
+
+	return err
 }
 
 // EnableMutexMonitoring changes settings to monitor wait/synch/mutex/%
-func (si *SetupInstruments) EnableMutexMonitoring() {
+func (si *SetupInstruments) EnableMutexMonitoring() error {
 	logger.Println("EnableMutexMonitoring")
 	sqlMatch := "wait/synch/mutex/%"
 	sqlSelect := "SELECT NAME, ENABLED, TIMED FROM setup_instruments WHERE NAME LIKE '" + sqlMatch + "' AND 'YES' NOT IN (ENABLED,TIMED)"
 	collecting := "Collecting setup_instruments wait/synch/mutex configuration settings"
 	updating := "Updating setup_instruments configuration for: wait/synch/mutex"
 
-	si.Configure(sqlSelect, collecting, updating)
+	err := si.Configure(sqlSelect, collecting, updating)
 	logger.Println("EnableMutexMonitoring finishes")
+
+	return err
+}
+
+// EnableMetadataLockMonitoring changes settings to monitor wait/lock/metadata/sql/mdl
+// which is needed to see metadata lock waits and is disabled by default in MySQL 5.7.
+func (si *SetupInstruments) EnableMetadataLockMonitoring() error {
+	logger.Println("EnableMetadataLockMonitoring")
+	sqlMatch := "wait/lock/metadata/sql/mdl"
+	sqlSelect := "SELECT NAME, ENABLED, TIMED FROM setup_instruments WHERE NAME LIKE '" + sqlMatch + "' AND 'YES' NOT IN (ENABLED,TIMED)"
+	collecting := "Collecting setup_instruments wait/lock/metadata/sql/mdl configuration settings"
+	updating := "Updating setup_instruments configuration for: wait/lock/metadata/sql/mdl"
+
+	err := si.Configure(sqlSelect, collecting, updating)
+	logger.Println("EnableMetadataLockMonitoring finishes")
+
+	return err
+}
+
+// EnableWaitMonitoring changes settings to monitor the wait instruments
+// matching the given names, e.g. wait/io/file/%
+func (si *SetupInstruments) EnableWaitMonitoring(instruments []string) error {
+	logger.Println("EnableWaitMonitoring", instruments)
+	for _, sqlMatch := range instruments {
+		sqlSelect := "SELECT NAME, ENABLED, TIMED FROM setup_instruments WHERE NAME LIKE '" + sqlMatch + "' AND 'YES' NOT IN (ENABLED,TIMED)"
+		collecting := "Collecting setup_instruments " + sqlMatch + " configuration settings"
+		updating := "Updating setup_instruments configuration for: " + sqlMatch
+
+		if err := si.Configure(sqlSelect, collecting, updating); err != nil {
+			return err
+		}
+	}
+	logger.Println("EnableWaitMonitoring finishes")
+
+	return nil
 }
 
 // return true if the error is not in the expected list
@@ -94,14 +139,15 @@ func errorInExpectedList(actualError string, expectedErrors []string) bool {
 }
 
 // Configure updates setup_instruments so we can monitor tables correctly.
-func (si *SetupInstruments) Configure(sqlSelect string, collecting, updating string) {
+// Insufficient privileges to make the changes are not treated as an error.
+func (si *SetupInstruments) Configure(sqlSelect string, collecting, updating string) error {
 	const updateSQL = "UPDATE setup_instruments SET enabled = ?, TIMED = ? WHERE NAME = ?"
 
 	logger.Println(fmt.Sprintf("Configure(%q,%q,%q)", sqlSelect, collecting, updating))
 	// skip if we've tried and failed
 	if si.updateTried && !si.updateSucceeded {
 		logger.Println("SetupInstruments.Configure() - Skipping further configuration")
-		return
+		return nil
 	}
 
 	// setup the old values in case they're not set
@@ -114,8 +160,9 @@ func (si *SetupInstruments) Configure(sqlSelect string, collecting, updating str
 	logger.Println("dbh.query", sqlSelect)
 	rows, err := si.dbh.Query(sqlSelect)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	defer rows.Close()
 
 	count := 0
 	for rows.Next() {
@@ -124,15 +171,14 @@ func (si *SetupInstruments) Configure(sqlSelect string, collecting, updating str
 			&r.name,
 			&r.enabled,
 			&r.timed); err != nil {
-			log.Fatal(err)
+			return err
 		}
 		si.rows = append(si.rows, r)
 		count++
 	}
 	if err := rows.Err(); err != nil {
-		log.Fatal(err)
+		return err
 	}
-	rows.Close()
 	logger.Println("- found", count, "rows whose configuration need changing")
 
 	// update the rows which need to be set - do multiple updates but I don't care
@@ -145,7 +191,7 @@ func (si *SetupInstruments) Configure(sqlSelect string, collecting, updating str
 	if err != nil {
 		logger.Println("- prepare gave error:", err.Error())
 		if !errorInExpectedList(err.Error(), ExpectedUpdateErrors) {
-			log.Fatal("Not expected error so giving up")
+			return fmt.Errorf("unable to update setup_instruments: %v", err)
 		} else {
 			logger.Println("- expected error so not running statement")
 		}
@@ -165,9 +211,11 @@ func (si *SetupInstruments) Configure(sqlSelect string, collecting, updating str
 				if errorInExpectedList(err.Error(), ExpectedUpdateErrors) {
 					logger.Println("Insufficient privileges to UPDATE setup_instruments: " + err.Error())
 					logger.Println("Not attempting further updates")
-					return
+					stmt.Close()
+					return nil
 				}
-				log.Fatal(err)
+				stmt.Close()
+				return fmt.Errorf("unable to update setup_instruments: %v", err)
 			}
 		}
 		if si.updateSucceeded {
@@ -176,15 +224,18 @@ func (si *SetupInstruments) Configure(sqlSelect string, collecting, updating str
 		stmt.Close()
 	}
 	logger.Println("Configure() returns updateTried", si.updateTried, ", updateSucceeded", si.updateSucceeded)
+
+	return nil
 }
 
 // RestoreConfiguration restores setup_instruments rows to their previous settings (if changed previously).
-func (si *SetupInstruments) RestoreConfiguration() {
+// All rows are restored even if some of them fail, the first error being returned.
+func (si *SetupInstruments) RestoreConfiguration() error {
 	logger.Println("RestoreConfiguration()")
 	// If the previous update didn't work then don't try to restore
 	if !si.updateSucceeded {
 		logger.Println("Not restoring p_s.setup_instruments to original settings as initial configuration attempt failed")
-		return
+		return nil
 	}
 	logger.Println("Restoring p_s.setup_instruments to its original settings")
 
@@ -193,17 +244,24 @@ func (si *SetupInstruments) RestoreConfiguration() {
 	logger.Println("dbh.Prepare(", updateSQL, ")")
 	stmt, err := si.dbh.Prepare(updateSQL)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("unable to restore setup_instruments: %v", err)
 	}
+	var firstErr error
 	count := 0
 	for i := range si.rows {
 		logger.Println("stmt.Exec(", si.rows[i].enabled, si.rows[i].timed, si.rows[i].name, ")")
 		if _, err := stmt.Exec(si.rows[i].enabled, si.rows[i].timed, si.rows[i].name); err != nil {
-			log.Fatal(err)
+			logger.Println("unable to restore", si.rows[i].name, ":", err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("unable to restore setup_instruments: %v", err)
+			}
+			continue
 		}
 		count++
 	}
 	logger.Println("stmt.Close()")
 	stmt.Close()
 	logger.Println(count, "rows changed in p_s.setup_instruments")
+
+	return firstErr
 }
@@ -0,0 +1,107 @@
+// Package sorting holds the columns a view can be sorted by and
+// remembers which column is currently used to sort the results.
+package sorting
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Column describes a column which can be used for sorting
+type Column struct {
+	Name    string // name used to select the column, e.g. write_latency
+	Heading int    // index of the column in the view's headings
+}
+
+// Sorter holds the sortable columns, the current column and the order
+type Sorter struct {
+	columns []Column
+	current int
+	reverse bool
+}
+
+// NewSorter returns a Sorter for the given columns.  The first
+// column is used by default.
+func NewSorter(columns ...Column) *Sorter {
+	return &Sorter{columns: columns}
+}
+
+// SortNext changes to the next column, wrapping around at the end
+func (s *Sorter) SortNext() {
+	if len(s.columns) > 0 {
+		s.current = (s.current + 1) % len(s.columns)
+	}
+}
+
+// SortPrev changes to the previous column, wrapping around at the start
+func (s *Sorter) SortPrev() {
+	if len(s.columns) > 0 {
+		s.current = (s.current + len(s.columns) - 1) % len(s.columns)
+	}
+}
+
+// SortReverse reverses the sort order
+func (s *Sorter) SortReverse() {
+	s.reverse = !s.reverse
+}
+
+// SortReversed indicates if the sort order is reversed
+func (s Sorter) SortReversed() bool {
+	return s.reverse
+}
+
+// SortColumn returns the index of the current sort column
+func (s Sorter) SortColumn() int {
+	return s.current
+}
+
+// SortColumnHeading returns the heading index of the current sort column
+func (s Sorter) SortColumnHeading() int {
+	if len(s.columns) == 0 {
+		return -1
+	}
+	return s.columns[s.current].Heading
+}
+
+// SortColumns returns the names of the sortable columns
+func (s Sorter) SortColumns() []string {
+	names := make([]string, 0, len(s.columns))
+	for i := range s.columns {
+		names = append(names, s.columns[i].Name)
+	}
+	return names
+}
+
+// SetSortColumn sets the sort column by name
+func (s *Sorter) SetSortColumn(name string) error {
+	for i := range s.columns {
+		if s.columns[i].Name == name {
+			s.current = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown sort column %q. Try one of: %s", name, strings.Join(s.SortColumns(), " "))
+}
+
+// Less returns the result of less, swapping the arguments if the sort order is reversed
+func (s Sorter) Less(less func(i, j int) bool) func(i, j int) bool {
+	if s.reverse {
+		return func(i, j int) bool { return less(j, i) }
+	}
+	return less
+}
+
+// Descending orders by value (descending) but also by name (ascending) if the values are the same
+func Descending(value, otherValue uint64, name, otherName string) bool {
+	return value > otherValue || (value == otherValue && name < otherName)
+}
+
+// SignedDescending is the same as Descending but for signed values
+func SignedDescending(value, otherValue int64, name, otherName string) bool {
+	return value > otherValue || (value == otherValue && name < otherName)
+}
+
+// Ascending orders by name (ascending)
+func Ascending(name, otherName string) bool {
+	return name < otherName
+}
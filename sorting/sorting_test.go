@@ -0,0 +1,57 @@
+package sorting
+
+import (
+	"testing"
+)
+
+func TestSorter(t *testing.T) {
+	s := NewSorter(
+		Column{Name: "latency", Heading: 0},
+		Column{Name: "ops", Heading: 3},
+		Column{Name: "name", Heading: 5},
+	)
+
+	var tests = []struct {
+		action   func()
+		expected int
+		heading  int
+	}{
+		{func() {}, 0, 0},
+		{s.SortNext, 1, 3},
+		{s.SortNext, 2, 5},
+		{s.SortNext, 0, 0},
+		{s.SortPrev, 2, 5},
+		{func() { _ = s.SetSortColumn("ops") }, 1, 3},
+	}
+
+	for i, test := range tests {
+		test.action()
+		if s.SortColumn() != test.expected {
+			t.Errorf("test %d: SortColumn() expected %d, got %d", i, test.expected, s.SortColumn())
+		}
+		if s.SortColumnHeading() != test.heading {
+			t.Errorf("test %d: SortColumnHeading() expected %d, got %d", i, test.heading, s.SortColumnHeading())
+		}
+	}
+
+	if err := s.SetSortColumn("junk"); err == nil {
+		t.Errorf("SetSortColumn(%q) expected an error", "junk")
+	}
+	if s.SortColumn() != 1 {
+		t.Errorf("SetSortColumn() with a bad name should not change the column")
+	}
+}
+
+func TestLess(t *testing.T) {
+	values := []uint64{1, 2}
+	less := func(i, j int) bool { return Descending(values[i], values[j], "", "") }
+
+	s := NewSorter(Column{Name: "value"})
+	if s.Less(less)(0, 1) {
+		t.Errorf("Less(0,1) expected false")
+	}
+	s.SortReverse()
+	if !s.Less(less)(0, 1) {
+		t.Errorf("Less(0,1) reversed expected true")
+	}
+}
@@ -2,6 +2,7 @@
 package table
 
 import (
+	"context"
 	"database/sql"
 	"log"
 
@@ -43,14 +44,14 @@ func (ta Access) Name() string {
 }
 
 // SelectError returns whether SELECT works on the table
-func (ta *Access) CheckSelectError(dbh *sql.DB) error {
+func (ta *Access) CheckSelectError(ctx context.Context, dbh *sql.DB) error {
 	// return cached result if we have one
 	if ta.checkedSelectError {
 		return ta.selectError
 	}
 
 	var one int
-	err := dbh.QueryRow("SELECT 1 FROM " + ta.Name() + " LIMIT 1").Scan(&one)
+	err := dbh.QueryRowContext(ctx, "SELECT 1 FROM "+ta.Name()+" LIMIT 1").Scan(&one)
 
 	switch {
 	case err == sql.ErrNoRows:
@@ -65,6 +66,12 @@ func (ta *Access) CheckSelectError(dbh *sql.DB) error {
 	return ta.selectError
 }
 
+// SetSelectError records the result of checking if SELECT works on the table
+func (ta *Access) SetSelectError(err error) {
+	ta.selectError = err
+	ta.checkedSelectError = true
+}
+
 // this hands back whatever it has
 func (ta Access) SelectError() error {
 	if !ta.checkedSelectError {
@@ -1,10 +1,16 @@
 package view
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
+	"sync"
+	"time"
 
+	"github.com/sjmudd/ps-top/capabilities"
+	"github.com/sjmudd/ps-top/datasource"
 	"github.com/sjmudd/ps-top/logger"
 	"github.com/sjmudd/ps-top/table"
 )
@@ -14,25 +20,51 @@ type Code int
 
 // View* constants represent different views we can see
 const (
-	ViewNone    Code = iota // view nothing (should never be set)
-	ViewLatency Code = iota // view the table latency information
-	ViewOps     Code = iota // view the table information by number of operations
-	ViewIO      Code = iota // view the file I/O information
-	ViewLocks   Code = iota // view lock information
-	ViewUsers   Code = iota // view user information
-	ViewMutex   Code = iota // view mutex information
-	ViewStages  Code = iota // view SQL stages information
-	ViewMemory  Code = iota // view memory usage (5.7 only)
+	ViewNone        Code = iota // view nothing (should never be set)
+	ViewLatency     Code = iota // view the table latency information
+	ViewOps         Code = iota // view the table information by number of operations
+	ViewIO          Code = iota // view the file I/O information
+	ViewLocks       Code = iota // view lock information
+	ViewUsers       Code = iota // view user information
+	ViewMutex       Code = iota // view mutex information
+	ViewStages      Code = iota // view SQL stages information
+	ViewMemory      Code = iota // view memory usage (5.7 only)
+	ViewReplication Code = iota // view replication applier and connection status (5.7+)
+	ViewDigest      Code = iota // view statement latency by digest
+	ViewIndexes     Code = iota // view the index usage information
+	ViewBlocking    Code = iota // view the threads waiting for metadata or InnoDB locks
+	ViewWaits       Code = iota // view the wait events by class
+	ViewThreads     Code = iota // view the connections and what they are doing
+	ViewStatus      Code = iota // view the changes in the global status variables
+
+	firstCustom = iota // the code of the first view registered with Register
 )
 
+// checkTimeout is how long the check that a view can be collected may take
+const checkTimeout = 10 * time.Second
+
 // View holds the integer type of view (maybe need to fix this setup)
 type View struct {
 	code Code
 }
 
 var (
-	names  map[Code]string       // map View* to a string name
-	tables map[Code]table.Access // map a view to a table name and whether it's selectable or not
+	mu sync.RWMutex // protects tables, nextView and prevView which ValidateViews changes when reconnecting
+
+	names  map[Code]string         // map View* to a string name
+	tables map[Code]table.Access   // map a view to a table name and whether it's selectable or not
+	joined map[Code][]table.Access // map a view to the other tables its query uses which must also be selectable
+
+	// requires maps a view to the server feature it needs, if any
+	requires = map[Code]capabilities.Feature{
+		ViewMemory:      capabilities.MemoryInstrumentation,
+		ViewReplication: capabilities.ReplicationTables,
+		ViewBlocking:    capabilities.MetadataLocks,
+		ViewStatus:      capabilities.PerformanceSchemaVariables,
+	}
+
+	checks      map[Code]func(ctx context.Context, dbh *sql.DB) error // checks of the views registered with Register
+	customViews []Code                                                // the views registered with Register in order
 
 	nextView map[Code]Code // map from one view to the next taking into account invalid views
 	prevView map[Code]Code // map from one view to the next taking into account invalid views
@@ -40,38 +72,130 @@ var (
 
 func init() {
 	names = map[Code]string{
-		ViewLatency: "table_io_latency",
-		ViewOps:     "table_io_ops",
-		ViewIO:      "file_io_latency",
-		ViewLocks:   "table_lock_latency",
-		ViewUsers:   "user_latency",
-		ViewMutex:   "mutex_latency",
-		ViewStages:  "stages_latency",
-		ViewMemory:  "memory_usage",
+		ViewLatency:     "table_io_latency",
+		ViewOps:         "table_io_ops",
+		ViewIO:          "file_io_latency",
+		ViewLocks:       "table_lock_latency",
+		ViewUsers:       "user_latency",
+		ViewMutex:       "mutex_latency",
+		ViewStages:      "stages_latency",
+		ViewMemory:      "memory_usage",
+		ViewReplication: "replication",
+		ViewDigest:      "digest_latency",
+		ViewIndexes:     "index_usage",
+		ViewBlocking:    "blocking",
+		ViewWaits:       "wait_latency",
+		ViewThreads:     "threads",
+		ViewStatus:      "global_status",
 	}
 
 	tables = map[Code]table.Access{
-		ViewLatency: table.NewAccess("performance_schema", "table_io_waits_summary_by_table"),
-		ViewOps:     table.NewAccess("performance_schema", "table_io_waits_summary_by_table"),
-		ViewIO:      table.NewAccess("performance_schema", "file_summary_by_instance"),
-		ViewLocks:   table.NewAccess("performance_schema", "table_lock_waits_summary_by_table"),
-		ViewUsers:   table.NewAccess("information_schema", "processlist"),
-		ViewMutex:   table.NewAccess("performance_schema", "events_waits_summary_global_by_event_name"),
-		ViewStages:  table.NewAccess("performance_schema", "events_stages_summary_global_by_event_name"),
-		ViewMemory:  table.NewAccess("performance_schema", "memory_summary_global_by_event_name"),
+		ViewLatency:     table.NewAccess("performance_schema", "table_io_waits_summary_by_table"),
+		ViewOps:         table.NewAccess("performance_schema", "table_io_waits_summary_by_table"),
+		ViewIO:          table.NewAccess("performance_schema", "file_summary_by_instance"),
+		ViewLocks:       table.NewAccess("performance_schema", "table_lock_waits_summary_by_table"),
+		ViewUsers:       table.NewAccess("performance_schema", "events_statements_summary_by_account_by_event_name"),
+		ViewMutex:       table.NewAccess("performance_schema", "events_waits_summary_global_by_event_name"),
+		ViewStages:      table.NewAccess("performance_schema", "events_stages_summary_global_by_event_name"),
+		ViewMemory:      table.NewAccess("performance_schema", "memory_summary_global_by_event_name"),
+		ViewReplication: table.NewAccess("performance_schema", "replication_applier_status_by_worker"),
+		ViewDigest:      table.NewAccess("performance_schema", "events_statements_summary_by_digest"),
+		ViewIndexes:     table.NewAccess("performance_schema", "table_io_waits_summary_by_index_usage"),
+		ViewBlocking:    table.NewAccess("performance_schema", "metadata_locks"),
+		ViewThreads:     table.NewAccess("performance_schema", "threads"),
+		ViewWaits:       table.NewAccess("performance_schema", "events_waits_summary_global_by_event_name"),
+		ViewStatus:      table.NewAccess("performance_schema", "global_status"),
+	}
+	joined = map[Code][]table.Access{
+		ViewReplication: {
+			table.NewAccess("performance_schema", "replication_applier_status_by_coordinator"),
+			table.NewAccess("performance_schema", "replication_connection_status"),
+			table.NewAccess("performance_schema", "events_transactions_summary_by_thread_by_event_name"),
+		},
+	}
+	checks = make(map[Code]func(ctx context.Context, dbh *sql.DB) error)
+}
+
+// checkJoined returns a check that the table of the view and any other
+// tables its query uses are all selectable
+func checkJoined(ta *table.Access, others []table.Access) func(ctx context.Context, dbh *sql.DB) error {
+	return func(ctx context.Context, dbh *sql.DB) error {
+		if err := ta.CheckSelectError(ctx, dbh); err != nil {
+			return err
+		}
+		for i := range others {
+			if err := others[i].CheckSelectError(ctx, dbh); err != nil {
+				return fmt.Errorf("%s: %v", others[i].Name(), err)
+			}
+		}
+		return nil
 	}
 }
 
+// Register adds a view defined by the user with the given name, which
+// follows the built-in views. check returns an error if the view can
+// not be collected. Views must be registered before ValidateViews is
+// called.
+func Register(name string, check func(ctx context.Context, dbh *sql.DB) error) (Code, error) {
+	if _, found := Lookup(name); found {
+		return ViewNone, fmt.Errorf("view %q already exists", name)
+	}
+	code := Code(firstCustom + len(customViews))
+	names[code] = name
+	mu.Lock()
+	tables[code] = table.Access{}
+	mu.Unlock()
+	checks[code] = check
+	customViews = append(customViews, code)
+
+	return code, nil
+}
+
+// Custom returns the views registered with Register in order
+func Custom() []Code {
+	return customViews
+}
+
 // ValidateViews check which views are readable. If none are we give a fatal error
-func ValidateViews(dbh *sql.DB) error {
+// Views needing a feature the server does not have are not readable.  The
+// checks of the other views are made via the given source so they may be
+// recorded or replayed.
+func ValidateViews(source datasource.Source, dbh *sql.DB, caps capabilities.Capabilities) error {
 	var count int
 	var status string
 	logger.Println("Validating access to views...")
 
 	// determine which of the defined views is valid because the underlying table access works
+	checked := make(map[Code]table.Access)
 	for v := range names {
+		mu.RLock()
 		ta := tables[v]
-		e := ta.CheckSelectError(dbh)
+		mu.RUnlock()
+		name, check := ta.Name(), ta.CheckSelectError
+		if others, ok := joined[v]; ok {
+			check = checkJoined(&ta, others)
+		}
+		if c, ok := checks[v]; ok {
+			name, check = "view:"+v.String(), c
+		}
+		var selectError string
+		if feature, ok := requires[v]; ok && !caps.Has(feature) {
+			selectError = caps.Require(feature).Error()
+		} else if _, err := source.Collect("select_error/"+name, &selectError, func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+			defer cancel()
+			if err := check(ctx, dbh); err != nil {
+				selectError = err.Error()
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		var e error
+		if selectError != "" {
+			e = errors.New(selectError)
+		}
+		ta.SetSelectError(e)
 		suffix := ""
 		if e == nil {
 			status = "is"
@@ -80,8 +204,8 @@ func ValidateViews(dbh *sql.DB) error {
 			status = "IS NOT"
 			suffix = " " + e.Error()
 		}
-		tables[v] = ta
-		logger.Println(v.String() + ": " + ta.Name() + " " + status + " SELECTable" + suffix)
+		checked[v] = ta
+		logger.Println(v.String() + ": " + name + " " + status + " SELECTable" + suffix)
 	}
 
 	if count == 0 {
@@ -89,6 +213,11 @@ func ValidateViews(dbh *sql.DB) error {
 	}
 	logger.Println(count, "of", len(names), "view(s) are SELECTable, continuing")
 
+	mu.Lock()
+	defer mu.Unlock()
+	for v := range checked {
+		tables[v] = checked[v]
+	}
 	setPrevAndNextViews()
 
 	return nil
@@ -118,8 +247,12 @@ func setPrevAndNextViews() {
 	}
 
 	// Cleaner way to do this? Probably. Fix later.
-	prevCodeOrder := []Code{ViewMemory, ViewStages, ViewMutex, ViewUsers, ViewLocks, ViewIO, ViewOps, ViewLatency}
-	nextCodeOrder := []Code{ViewLatency, ViewOps, ViewIO, ViewLocks, ViewUsers, ViewMutex, ViewStages, ViewMemory}
+	var prevCodeOrder []Code
+	for i := len(customViews) - 1; i >= 0; i-- {
+		prevCodeOrder = append(prevCodeOrder, customViews[i])
+	}
+	prevCodeOrder = append(prevCodeOrder, ViewStatus, ViewReplication, ViewDigest, ViewMemory, ViewStages, ViewMutex, ViewWaits, ViewThreads, ViewUsers, ViewBlocking, ViewLocks, ViewIO, ViewIndexes, ViewOps, ViewLatency)
+	nextCodeOrder := append([]Code{ViewLatency, ViewOps, ViewIndexes, ViewIO, ViewLocks, ViewBlocking, ViewUsers, ViewThreads, ViewWaits, ViewMutex, ViewStages, ViewMemory, ViewDigest, ViewReplication, ViewStatus}, customViews...)
 	prevView = setValidByValues(prevCodeOrder)
 	nextView = setValidByValues(nextCodeOrder)
 
@@ -180,6 +313,8 @@ func setValidByValues(orderedCodes []Code) map[Code]Code {
 
 // SetNext changes the current view to the next one
 func (v *View) SetNext() Code {
+	mu.RLock()
+	defer mu.RUnlock()
 	v.code = nextView[v.code]
 
 	return v.code
@@ -187,6 +322,8 @@ func (v *View) SetNext() Code {
 
 // SetPrev changes the current view to the previous one
 func (v *View) SetPrev() Code {
+	mu.RLock()
+	defer mu.RUnlock()
 	v.code = prevView[v.code]
 
 	return v.code
@@ -196,6 +333,8 @@ func (v *View) SetPrev() Code {
 func (v *View) Set(viewCode Code) {
 	v.code = viewCode
 
+	mu.RLock()
+	defer mu.RUnlock()
 	if tables[v.code].SelectError() != nil {
 		v.code = nextView[v.code]
 	}
@@ -243,3 +382,20 @@ func (v View) Name() string {
 func (s Code) String() string {
 	return names[s]
 }
+
+// Selectable returns true if the table used by the view can be SELECTed
+func (s Code) Selectable() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return tables[s].SelectError() == nil
+}
+
+// Lookup returns the view with the given name
+func Lookup(name string) (Code, bool) {
+	for code := range names {
+		if names[code] == name {
+			return code, true
+		}
+	}
+	return ViewNone, false
+}
@@ -10,10 +10,14 @@ import (
 // over-schedule the next wait by this time _iff__ the last scheduled time is in the past.
 const extraDelay = 200 * time.Millisecond
 
+// the longest we delay collections after repeated failures
+const maxBackoff = time.Minute
+
 // WaitInfo is used to record when we need to collect information from MySQL
 type WaitInfo struct {
 	lastCollected   time.Time
 	collectInterval time.Duration
+	backoff         time.Duration // extra delay after failed collections
 }
 
 // WaitInterval returns the configured wait interval between collecting data.
@@ -23,9 +27,31 @@ func (wi *WaitInfo) WaitInterval() time.Duration {
 
 // CollectedNow records we have just collected data now.
 func (wi *WaitInfo) CollectedNow() {
+	wi.backoff = 0
+	wi.SetCollected(time.Now())
+}
+
+// CollectFailed records we have just failed to collect data. The next
+// collection is delayed by an extra amount which doubles on each
+// consecutive failure, starting at the wait interval, up to maxBackoff.
+func (wi *WaitInfo) CollectFailed() {
+	if wi.backoff == 0 {
+		wi.backoff = wi.collectInterval
+	} else {
+		wi.backoff *= 2
+	}
+	if wi.backoff > maxBackoff {
+		wi.backoff = maxBackoff
+	}
 	wi.SetCollected(time.Now())
 }
 
+// Backoff returns the extra delay added before the next collection
+// because of previous failures.
+func (wi WaitInfo) Backoff() time.Duration {
+	return wi.backoff
+}
+
 // SetWaitInterval changes the desired collection interval to a new value
 func (wi *WaitInfo) SetWaitInterval(requiredInterval time.Duration) {
 	wi.collectInterval = requiredInterval
@@ -48,7 +74,7 @@ func (wi WaitInfo) TimeToWait() time.Duration {
 	now := time.Now()
 	logger.Println("WaitInfo.TimeToWait() now: ", now)
 
-	nextTime := wi.lastCollected.Add(wi.collectInterval)
+	nextTime := wi.lastCollected.Add(wi.collectInterval + wi.backoff)
 	logger.Println("WaitInfo.TimeToWait() nextTime: ", nextTime)
 	if nextTime.Before(now) {
 		logger.Println("WaitInfo.TimeToWait() nextTime scheduled time in the past, so schedule", extraDelay, "after", now)
@@ -0,0 +1,33 @@
+package wait_info
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCollectFailed(t *testing.T) {
+	var wi WaitInfo
+	wi.SetWaitInterval(10 * time.Second)
+
+	expected := []time.Duration{
+		10 * time.Second,
+		20 * time.Second,
+		40 * time.Second,
+		maxBackoff,
+		maxBackoff,
+	}
+	for i := range expected {
+		wi.CollectFailed()
+		if wi.Backoff() != expected[i] {
+			t.Errorf("CollectFailed() #%d: expected backoff %v, got %v", i+1, expected[i], wi.Backoff())
+		}
+		if wait := wi.TimeToWait(); wait <= wi.WaitInterval() || wait > wi.WaitInterval()+wi.Backoff() {
+			t.Errorf("TimeToWait() #%d: expected more than %v and at most %v, got %v", i+1, wi.WaitInterval(), wi.WaitInterval()+wi.Backoff(), wait)
+		}
+	}
+
+	wi.CollectedNow()
+	if wi.Backoff() != 0 {
+		t.Errorf("CollectedNow(): expected no backoff, got %v", wi.Backoff())
+	}
+}
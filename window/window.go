@@ -0,0 +1,106 @@
+// Package window describes the size of a sliding window of collected
+// data which is used to show how values change over a recent period
+// rather than since statistics were reset.
+package window
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDuration is the window size used if none is given explicitly.
+const DefaultDuration = 60 * time.Second
+
+// Window holds the size of the window, either as a duration or as a number
+// of samples (collections).  Only one of the two values is set.
+type Window struct {
+	duration time.Duration
+	samples  int
+}
+
+// NewDurationWindow returns a window covering the given duration
+func NewDurationWindow(duration time.Duration) Window {
+	return Window{duration: duration}
+}
+
+// NewSampleWindow returns a window covering the given number of samples
+func NewSampleWindow(samples int) Window {
+	return Window{samples: samples}
+}
+
+// Parse converts the user's input into a Window.
+// - an empty string gives the default window
+// - a plain integer is taken as a number of samples, e.g. "10"
+// - anything else must be a valid duration, e.g. "60s" or "5m"
+func Parse(value string) (Window, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return NewDurationWindow(DefaultDuration), nil
+	}
+
+	if samples, err := strconv.Atoi(value); err == nil {
+		if samples < 1 {
+			return Window{}, errors.New("window: number of samples must be at least 1")
+		}
+		return NewSampleWindow(samples), nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return Window{}, fmt.Errorf("window: unable to parse %q: expecting a number of samples or a duration", value)
+	}
+	if duration < time.Second {
+		return Window{}, errors.New("window: duration must be at least 1s")
+	}
+
+	return NewDurationWindow(duration), nil
+}
+
+// Duration returns the duration of the window, or 0 if the window is sample based
+func (w Window) Duration() time.Duration {
+	return w.duration
+}
+
+// Samples returns the number of samples in the window, or 0 if the window is duration based
+func (w Window) Samples() int {
+	return w.samples
+}
+
+// String returns a printable version of the window size
+func (w Window) String() string {
+	if w.samples > 0 {
+		return fmt.Sprintf("%d samples", w.samples)
+	}
+	return w.duration.String()
+}
+
+// Expired returns the number of entries at the start of times (ordered
+// oldest first) which are no longer needed to cover the window.  The
+// entry which remains first is the baseline used for the window.
+func (w Window) Expired(times []time.Time) int {
+	if len(times) == 0 {
+		return 0
+	}
+
+	if w.samples > 0 {
+		if len(times) > w.samples+1 {
+			return len(times) - (w.samples + 1)
+		}
+		return 0
+	}
+
+	// keep the newest entry which is at least w.duration older than the last one
+	cutoff := times[len(times)-1].Add(-w.duration)
+	expired := 0
+	for i := range times {
+		if times[i].After(cutoff) {
+			break
+		}
+		expired = i
+	}
+
+	return expired
+}
@@ -0,0 +1,59 @@
+package window
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected Window
+		isError  bool
+	}{
+		{"", Window{duration: DefaultDuration}, false},
+		{"10", Window{samples: 10}, false},
+		{"90s", Window{duration: 90 * time.Second}, false},
+		{"5m", Window{duration: 5 * time.Minute}, false},
+		{"0", Window{}, true},
+		{"100ms", Window{}, true},
+		{"junk", Window{}, true},
+	}
+
+	for _, test := range tests {
+		got, err := Parse(test.input)
+		if (err != nil) != test.isError {
+			t.Errorf("Parse(%q) error: expected %v, got %v", test.input, test.isError, err)
+		}
+		if got != test.expected {
+			t.Errorf("Parse(%q): expected %+v, got %+v", test.input, test.expected, got)
+		}
+	}
+}
+
+func TestExpired(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	times := make([]time.Time, 0, 10)
+	for i := 0; i < 10; i++ {
+		times = append(times, start.Add(time.Duration(i)*time.Second))
+	}
+
+	var tests = []struct {
+		window   Window
+		times    []time.Time
+		expected int
+	}{
+		{NewSampleWindow(3), times, 6},
+		{NewSampleWindow(20), times, 0},
+		{NewDurationWindow(3 * time.Second), times, 6},
+		{NewDurationWindow(2500 * time.Millisecond), times, 6},
+		{NewDurationWindow(time.Minute), times, 0},
+		{NewDurationWindow(time.Minute), nil, 0},
+	}
+
+	for _, test := range tests {
+		if got := test.window.Expired(test.times); got != test.expected {
+			t.Errorf("%v.Expired(): expected %d, got %d", test.window, test.expected, got)
+		}
+	}
+}
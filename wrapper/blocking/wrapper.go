@@ -0,0 +1,220 @@
+// Package blocking holds the routines which manage the lock waits
+package blocking
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sjmudd/ps-top/columns"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/model/blocking"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
+)
+
+// Wrapper wraps a Blocking struct
+type Wrapper struct {
+	*sorting.Sorter
+	b      *blocking.Blocking
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "wait_age", Heading: 0},
+	{Name: "blocking", Heading: 2},
+	{Name: "root", Heading: 3},
+	{Name: "name", Heading: 6},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b blocking.Row) bool{
+	func(a, b blocking.Row) bool {
+		return (a.WaitAge > b.WaitAge) ||
+			((a.WaitAge == b.WaitAge) && (a.WaitingID < b.WaitingID))
+	},
+	func(a, b blocking.Row) bool {
+		return (a.BlockingID < b.BlockingID) ||
+			((a.BlockingID == b.BlockingID) && (a.WaitAge > b.WaitAge))
+	},
+	func(a, b blocking.Row) bool {
+		return (a.RootBlockingID < b.RootBlockingID) ||
+			((a.RootBlockingID == b.RootBlockingID) && (a.WaitAge > b.WaitAge))
+	},
+	func(a, b blocking.Row) bool { return sorting.Ascending(a.Object(), b.Object()) },
+}
+
+// NewBlocking creates a wrapper around blocking.Blocking
+func NewBlocking(ctx *context.Context, db *sql.DB) *Wrapper {
+	return NewBlockingWithSource(ctx, blocking.NewMySQLSource(db, ctx.Capabilities()))
+}
+
+// NewBlockingWithSource creates a wrapper collecting data from the given source
+func NewBlockingWithSource(ctx *context.Context, source blocking.Source) *Wrapper {
+	return &Wrapper{
+		Sorter: sorting.NewSorter(sortColumns...),
+		b:      blocking.NewBlockingWithSource(ctx, source),
+	}
+}
+
+// SetFirstFromLast resets the statistics to last values
+func (bw *Wrapper) SetFirstFromLast() {
+	bw.b.SetFirstFromLast()
+}
+
+// Collect data from the db
+func (bw *Wrapper) Collect() error {
+	return bw.b.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (bw *Wrapper) SetLocker(locker sync.Locker) {
+	bw.b.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (bw *Wrapper) HideColumns(names []string) error {
+	return bw.hidden.Hide(bw.headings(), names)
+}
+
+// sort the results by the current sort column
+func (bw Wrapper) sort() {
+	results := bw.b.Results
+	compare := less[bw.SortColumn()]
+
+	sort.Slice(results, bw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
+}
+
+// headings returns the individual column headings
+func (bw Wrapper) headings() []string {
+	return []string{"Wait Age", "Waiting", "Blocking", "Root", "Kind", "Lock Type", "Object", "Blocking Statement"}
+}
+
+// Headings returns the headings for a table
+func (bw Wrapper) Headings() string {
+	return bw.hidden.Strings("%8s|%8s %8s %8s|%-8s %-20s|%-40s|%s", bw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (bw Wrapper) SortHeading() string {
+	return bw.headings()[bw.SortColumnHeading()]
+}
+
+// RowContent returns the rows we need for displaying
+func (bw Wrapper) RowContent() []string {
+	bw.sort()
+	rows := make([]string, 0, len(bw.b.Results))
+
+	for i := range bw.b.Results {
+		rows = append(rows, bw.content(bw.b.Results[i]))
+	}
+
+	return rows
+}
+
+// TotalRowContent returns all the totals
+func (bw Wrapper) TotalRowContent() string {
+	return bw.content(bw.b.Totals)
+}
+
+// Records returns the rows as typed records in the current sort order
+func (bw Wrapper) Records() []record.Record {
+	bw.sort()
+	records := make([]record.Record, 0, len(bw.b.Results))
+
+	for i := range bw.b.Results {
+		records = append(records, newRecord(bw.b.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (bw Wrapper) TotalRecord() record.Record {
+	return newRecord(bw.b.Totals)
+}
+
+// EmptyRowContent returns an empty string of data (for filling in)
+func (bw Wrapper) EmptyRowContent() string {
+	var empty blocking.Row
+
+	return bw.content(empty)
+}
+
+// Description returns a description of the table
+func (bw Wrapper) Description() string {
+	return fmt.Sprintf("Lock waits (metadata_locks, data_lock_waits) %d waiting, %d blocking thread(s)", len(bw.b.Results), bw.b.Blockers())
+}
+
+// HaveRelativeStats is false for this object
+func (bw Wrapper) HaveRelativeStats() bool {
+	return bw.b.HaveRelativeStats()
+}
+
+// FirstCollectTime returns the time the first value was collected
+func (bw Wrapper) FirstCollectTime() time.Time {
+	return bw.b.FirstCollectTime()
+}
+
+// LastCollectTime returns the time the last value was collected
+func (bw Wrapper) LastCollectTime() time.Time {
+	return bw.b.LastCollectTime()
+}
+
+// LastTruncation returns when the table was last seen to be truncated
+func (bw Wrapper) LastTruncation() time.Time {
+	return bw.b.LastTruncation()
+}
+
+// WantRelativeStats indiates if we want relative statistics
+func (bw Wrapper) WantRelativeStats() bool {
+	return bw.b.WantRelativeStats()
+}
+
+// Len return the length of the result set
+func (bw Wrapper) Len() int {
+	return len(bw.b.Results)
+}
+
+// content generate a printable result for a row
+func (bw Wrapper) content(row blocking.Row) string {
+	object := row.Object()
+	if len(object) > 40 {
+		object = object[:40]
+	}
+	lockType := row.LockType
+	if len(lockType) > 20 {
+		lockType = lockType[:20]
+	}
+
+	return bw.hidden.Sprintf("%8s|%8s %8s %8s|%-8s %-20s|%-40s|%s",
+		lib.FormatSeconds(row.WaitAge),
+		lib.FormatCounter(int(row.WaitingID), 8),
+		lib.FormatCounter(int(row.BlockingID), 8),
+		lib.FormatCounter(int(row.RootBlockingID), 8),
+		row.Kind,
+		lockType,
+		object,
+		row.BlockingQuery)
+}
+
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row blocking.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "index", Value: row.Index},
+		{Name: "kind", Value: row.Kind},
+		{Name: "waiting_id", Value: row.WaitingID},
+		{Name: "blocking_id", Value: row.BlockingID},
+		{Name: "root_blocking_id", Value: row.RootBlockingID},
+		{Name: "lock_type", Value: row.LockType},
+		{Name: "blocking_lock_type", Value: row.BlockingLockType},
+		{Name: "wait_age_seconds", Value: row.WaitAge},
+		{Name: "waiting_query", Value: row.WaitingQuery},
+		{Name: "blocking_query", Value: row.BlockingQuery},
+	}
+}
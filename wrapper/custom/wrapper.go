@@ -0,0 +1,256 @@
+// Package custom holds the routines which manage the views defined by the user
+package custom
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sjmudd/ps-top/columns"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/model/custom"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
+)
+
+// minWidth is the narrowest a numeric column is shown
+const minWidth = 10
+
+// Wrapper wraps a Custom struct
+type Wrapper struct {
+	*sorting.Sorter
+	c      *custom.Custom
+	hidden columns.Hidden // columns which are not shown
+	format string         // format of a row with a %s for each column and the name
+}
+
+// NewCustom creates a wrapper around custom.Custom
+func NewCustom(ctx *context.Context, db *sql.DB, definition custom.Definition) *Wrapper {
+	return NewCustomWithSource(ctx, custom.NewMySQLSource(db, definition), definition)
+}
+
+// NewCustomWithSource creates a wrapper collecting data from the given
+// source. The results may be sorted by any numeric column, descending,
+// or by name.
+func NewCustomWithSource(ctx *context.Context, source custom.Source, definition custom.Definition) *Wrapper {
+	var columns []sorting.Column
+	var formats []string
+	for i, column := range definition.Columns {
+		columns = append(columns, sorting.Column{Name: column.Name, Heading: i})
+		formats = append(formats, fmt.Sprintf("%%%ds", width(column)))
+	}
+	columns = append(columns, sorting.Column{Name: "name", Heading: len(definition.Columns)})
+
+	return &Wrapper{
+		Sorter: sorting.NewSorter(columns...),
+		c:      custom.NewCustomWithSource(ctx, source, definition),
+		format: strings.Join(formats, " ") + "|%s",
+	}
+}
+
+// width returns the width of the column, which must fit its heading
+func width(column custom.Column) int {
+	w := len(column.Name)
+	if !column.Gauge {
+		w += len("/s")
+	}
+	if w < minWidth {
+		w = minWidth
+	}
+	return w
+}
+
+// SetFirstFromLast resets the statistics to last values
+func (cw *Wrapper) SetFirstFromLast() {
+	cw.c.SetFirstFromLast()
+}
+
+// Collect data from the db, then merge it in.
+func (cw *Wrapper) Collect() error {
+	return cw.c.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (cw *Wrapper) SetLocker(locker sync.Locker) {
+	cw.c.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (cw *Wrapper) HideColumns(names []string) error {
+	return cw.hidden.Hide(cw.headings(), names)
+}
+
+// sort the results by the current sort column
+func (cw Wrapper) sort() {
+	results := cw.c.Results
+	column := cw.SortColumn()
+
+	sort.Slice(results, cw.Less(func(i, j int) bool {
+		if column >= len(cw.c.Definition().Columns) {
+			return sorting.Ascending(results[i].Name, results[j].Name)
+		}
+		return sorting.Descending(results[i].Values[column], results[j].Values[column], results[i].Name, results[j].Name)
+	}))
+}
+
+// RowContent returns the rows we need for displaying
+func (cw Wrapper) RowContent() []string {
+	cw.sort()
+	rows := make([]string, 0, len(cw.c.Results))
+
+	for i := range cw.c.Results {
+		rows = append(rows, cw.content(cw.c.Results[i]))
+	}
+
+	return rows
+}
+
+// TotalRowContent returns all the totals
+func (cw Wrapper) TotalRowContent() string {
+	return cw.content(cw.c.Totals)
+}
+
+// Records returns the rows as typed records in the current sort order
+func (cw Wrapper) Records() []record.Record {
+	cw.sort()
+	records := make([]record.Record, 0, len(cw.c.Results))
+
+	for i := range cw.c.Results {
+		records = append(records, cw.newRecord(cw.c.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (cw Wrapper) TotalRecord() record.Record {
+	return cw.newRecord(cw.c.Totals)
+}
+
+// Len return the length of the result set
+func (cw Wrapper) Len() int {
+	return len(cw.c.Results)
+}
+
+// EmptyRowContent returns an empty string of data (for filling in)
+func (cw Wrapper) EmptyRowContent() string {
+	return cw.content(custom.Row{Values: make([]uint64, len(cw.c.Definition().Columns))})
+}
+
+// HaveRelativeStats is true for this object
+func (cw Wrapper) HaveRelativeStats() bool {
+	return cw.c.HaveRelativeStats()
+}
+
+// FirstCollectTime returns the time the first value was collected
+func (cw Wrapper) FirstCollectTime() time.Time {
+	return cw.c.FirstCollectTime()
+}
+
+// LastCollectTime returns the time the last value was collected
+func (cw Wrapper) LastCollectTime() time.Time {
+	return cw.c.LastCollectTime()
+}
+
+// LastTruncation returns when the counters were last seen to be reset
+func (cw Wrapper) LastTruncation() time.Time {
+	return cw.c.LastTruncation()
+}
+
+// WantRelativeStats indiates if we want relative statistics
+func (cw Wrapper) WantRelativeStats() bool {
+	return cw.c.WantRelativeStats()
+}
+
+// Description returns a description of the table
+func (cw Wrapper) Description() string {
+	return fmt.Sprintf("Custom view %s %d rows", cw.c.Definition().Name, len(cw.c.Results))
+}
+
+// headings returns the individual column headings
+func (cw Wrapper) headings() []string {
+	definition := cw.c.Definition()
+	headings := make([]string, 0, len(definition.Columns)+1)
+
+	for _, column := range definition.Columns {
+		heading := column.Name
+		if !column.Gauge && cw.c.WantRates() {
+			heading += "/s"
+		}
+		headings = append(headings, heading)
+	}
+
+	return append(headings, strings.Join(definition.Keys, " "))
+}
+
+// Headings returns the headings for a table
+func (cw Wrapper) Headings() string {
+	return cw.hidden.Strings(cw.format, cw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (cw Wrapper) SortHeading() string {
+	return cw.headings()[cw.SortColumnHeading()]
+}
+
+// content generate a printable result for a row. Counters are shown
+// as the change (or rate) and gauges as their current value.
+func (cw Wrapper) content(row custom.Row) string {
+	columns := cw.c.Definition().Columns
+	values := make([]interface{}, 0, len(columns)+1)
+
+	for i, column := range columns {
+		value := row.Values[i]
+		if !column.Gauge {
+			value = cw.c.Rate(value)
+		}
+		values = append(values, formatValue(value, column.Unit))
+	}
+
+	return cw.hidden.Sprintf(cw.format, append(values, row.Name)...)
+}
+
+// formatValue formats the value according to its unit
+func formatValue(value uint64, unit string) string {
+	if unit == custom.Time {
+		return lib.FormatTime(value)
+	}
+	return lib.FormatAmount(value)
+}
+
+// newRecord returns the row as a record with values in their raw units
+func (cw Wrapper) newRecord(row custom.Row) record.Record {
+	columns := cw.c.Definition().Columns
+	r := record.Record{{Name: "name", Value: row.Name}}
+
+	for i, column := range columns {
+		r = append(r, record.Field{Name: recordName(column), Value: row.Values[i]})
+	}
+
+	return r
+}
+
+// recordName returns the name of the column in a record. Times are
+// named with a _ps suffix so they are exported in seconds.
+func recordName(column custom.Column) string {
+	if column.Unit == custom.Time && !strings.HasSuffix(column.Name, "_ps") {
+		return column.Name + "_ps"
+	}
+	return column.Name
+}
+
+// Gauges returns the names of the fields of the records which are gauges
+func (cw Wrapper) Gauges() []string {
+	var gauges []string
+	for _, column := range cw.c.Definition().Columns {
+		if column.Gauge {
+			gauges = append(gauges, recordName(column))
+		}
+	}
+
+	return gauges
+}
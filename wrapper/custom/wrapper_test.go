@@ -0,0 +1,78 @@
+package custom
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/custom"
+	"github.com/sjmudd/ps-top/model/filter"
+)
+
+func TestRecords(t *testing.T) {
+	d, err := custom.NewDefinition("io", "SELECT file, reads, latency, bytes FROM t",
+		[]string{"file"}, []string{"reads", "latency"}, []string{"bytes"},
+		map[string]string{"latency": custom.Time, "bytes": custom.Bytes})
+	if err != nil {
+		t.Fatalf("NewDefinition(): unexpected error: %v", err)
+	}
+	rows := custom.Rows{
+		{Name: "a", Values: []uint64{1, 3000, 10}},
+		{Name: "c", Values: []uint64{3, 1000, 0}},
+		{Name: "b", Values: []uint64{2, 2000, 20}},
+		{Name: "d", Values: []uint64{0, 0, 0}},
+	}
+	var tests = []struct {
+		sort     string
+		reverse  bool
+		expected []string
+	}{
+		{"reads", false, []string{"c", "b", "a"}},
+		{"reads", true, []string{"a", "b", "c"}},
+		{"latency", false, []string{"a", "b", "c"}},
+		{"bytes", false, []string{"b", "a", "c"}},
+		{"name", false, []string{"a", "b", "c"}},
+	}
+
+	for _, test := range tests {
+		ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+		cw := NewCustomWithSource(ctx, &custom.MemorySource{Rows: rows}, d)
+		if err := cw.SetSortColumn(test.sort); err != nil {
+			t.Fatalf("SetSortColumn(%q): unexpected error: %v", test.sort, err)
+		}
+		if test.reverse {
+			cw.SortReverse()
+		}
+		cw.Collect()
+
+		var names []string
+		for _, r := range cw.Records() {
+			names = append(names, r[0].Value.(string))
+		}
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("Records() sorted by %q (reverse: %v): expected %v, actual %v", test.sort, test.reverse, test.expected, names)
+		}
+		if len(cw.RowContent()) != len(test.expected) {
+			t.Errorf("RowContent(): expected %d rows, actual %d", len(test.expected), len(cw.RowContent()))
+		}
+	}
+
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	cw := NewCustomWithSource(ctx, &custom.MemorySource{Rows: rows}, d)
+	cw.Collect()
+	if got := cw.TotalRecord().Names(); !reflect.DeepEqual(got, []string{"name", "reads", "latency_ps", "bytes"}) {
+		t.Errorf("TotalRecord(): unexpected field names %v", got)
+	}
+	if got := cw.Gauges(); !reflect.DeepEqual(got, []string{"bytes"}) {
+		t.Errorf("Gauges(): expected [bytes], actual %v", got)
+	}
+	if headings := cw.Headings(); !strings.HasSuffix(headings, "|file") || len(headings) != len(cw.EmptyRowContent())+len("file") {
+		t.Errorf("Headings(): %q does not line up with the rows %q", headings, cw.EmptyRowContent())
+	}
+	if total := cw.TotalRowContent(); !strings.HasSuffix(total, "|Totals") || !strings.Contains(total, "6.00 ns") {
+		t.Errorf("TotalRowContent(): unexpected %q", total)
+	}
+}
@@ -0,0 +1,245 @@
+// Package digest_latency holds the routines which manage the statement digests
+package digest_latency
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sjmudd/ps-top/columns"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/model/digest_latency"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
+)
+
+// Wrapper wraps a DigestLatency struct
+type Wrapper struct {
+	*sorting.Sorter
+	dl     *digest_latency.DigestLatency
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "latency", Heading: 0},
+	{Name: "count", Heading: 2},
+	{Name: "avg_latency", Heading: 3},
+	{Name: "max_latency", Heading: 4},
+	{Name: "rows_examined", Heading: 5},
+	{Name: "tmp_disk_tables", Heading: 7},
+	{Name: "no_index", Heading: 8},
+	{Name: "name", Heading: 10},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b digest_latency.Row) bool{
+	func(a, b digest_latency.Row) bool {
+		return sorting.Descending(a.SumTimerWait, b.SumTimerWait, a.Name, b.Name)
+	},
+	func(a, b digest_latency.Row) bool {
+		return sorting.Descending(a.CountStar, b.CountStar, a.Name, b.Name)
+	},
+	func(a, b digest_latency.Row) bool {
+		return sorting.Descending(a.AvgTimerWait(), b.AvgTimerWait(), a.Name, b.Name)
+	},
+	func(a, b digest_latency.Row) bool {
+		return sorting.Descending(a.MaxTimerWait, b.MaxTimerWait, a.Name, b.Name)
+	},
+	func(a, b digest_latency.Row) bool {
+		return sorting.Descending(a.SumRowsExamined, b.SumRowsExamined, a.Name, b.Name)
+	},
+	func(a, b digest_latency.Row) bool {
+		return sorting.Descending(a.SumCreatedTmpDiskTables, b.SumCreatedTmpDiskTables, a.Name, b.Name)
+	},
+	func(a, b digest_latency.Row) bool {
+		return sorting.Descending(a.SumNoIndexUsed, b.SumNoIndexUsed, a.Name, b.Name)
+	},
+	func(a, b digest_latency.Row) bool { return sorting.Ascending(a.Name, b.Name) },
+}
+
+// NewDigestLatency creates a wrapper around digest_latency.DigestLatency
+func NewDigestLatency(ctx *context.Context, db *sql.DB) *Wrapper {
+	return NewDigestLatencyWithSource(ctx, digest_latency.NewMySQLSource(db))
+}
+
+// NewDigestLatencyWithSource creates a wrapper collecting data from the given source
+func NewDigestLatencyWithSource(ctx *context.Context, source digest_latency.Source) *Wrapper {
+	return &Wrapper{
+		Sorter: sorting.NewSorter(sortColumns...),
+		dl:     digest_latency.NewDigestLatencyWithSource(ctx, source),
+	}
+}
+
+// SetFirstFromLast resets the statistics to last values
+func (dlw *Wrapper) SetFirstFromLast() {
+	dlw.dl.SetFirstFromLast()
+}
+
+// Collect data from the db, then merge it in.
+func (dlw *Wrapper) Collect() error {
+	return dlw.dl.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (dlw *Wrapper) SetLocker(locker sync.Locker) {
+	dlw.dl.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (dlw *Wrapper) HideColumns(names []string) error {
+	return dlw.hidden.Hide(dlw.headings(), names)
+}
+
+// sort the results by the current sort column
+func (dlw Wrapper) sort() {
+	results := dlw.dl.Results
+	compare := less[dlw.SortColumn()]
+
+	sort.Slice(results, dlw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
+}
+
+// headings returns the individual column headings
+func (dlw Wrapper) headings() []string {
+	latency, counter, examined, sent := "Latency", "Count", "Examined", "Sent"
+	if dlw.dl.WantRates() {
+		latency, counter, examined, sent = "Latency/s", "Count/s", "Exam/s", "Sent/s"
+	}
+
+	return []string{latency, "%", counter, "Avg", "Max", examined, sent, "TmpDisk", "NoIndex", "Schema", "Statement Digest"}
+}
+
+// Headings returns the headings for a table
+func (dlw Wrapper) Headings() string {
+	return dlw.hidden.Strings("%10s %6s %8s %9s %9s %8s %8s %7s %7s|%-12s|%s", dlw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (dlw Wrapper) SortHeading() string {
+	return dlw.headings()[dlw.SortColumnHeading()]
+}
+
+// RowContent returns the rows we need for displaying
+func (dlw Wrapper) RowContent() []string {
+	dlw.sort()
+	rows := make([]string, 0, len(dlw.dl.Results))
+
+	for i := range dlw.dl.Results {
+		rows = append(rows, dlw.content(dlw.dl.Results[i], dlw.dl.Totals))
+	}
+
+	return rows
+}
+
+// TotalRowContent returns all the totals
+func (dlw Wrapper) TotalRowContent() string {
+	return dlw.content(dlw.dl.Totals, dlw.dl.Totals)
+}
+
+// Records returns the rows as typed records in the current sort order
+func (dlw Wrapper) Records() []record.Record {
+	dlw.sort()
+	records := make([]record.Record, 0, len(dlw.dl.Results))
+
+	for i := range dlw.dl.Results {
+		records = append(records, newRecord(dlw.dl.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (dlw Wrapper) TotalRecord() record.Record {
+	return newRecord(dlw.dl.Totals)
+}
+
+// Len return the length of the result set
+func (dlw Wrapper) Len() int {
+	return dlw.dl.Len()
+}
+
+// EmptyRowContent returns an empty string of data (for filling in)
+func (dlw Wrapper) EmptyRowContent() string {
+	var empty digest_latency.Row
+
+	return dlw.content(empty, empty)
+}
+
+// Description returns a description of the table
+func (dlw Wrapper) Description() string {
+	var count int
+	for row := range dlw.dl.Results {
+		if dlw.dl.Results[row].HasData() {
+			count++
+		}
+	}
+
+	return fmt.Sprintf("Statement Latency by Digest (events_statements_summary_by_digest) %d rows", count)
+}
+
+// HaveRelativeStats is true for this object
+func (dlw Wrapper) HaveRelativeStats() bool {
+	return dlw.dl.HaveRelativeStats()
+}
+
+// FirstCollectTime returns the time the first value was collected
+func (dlw Wrapper) FirstCollectTime() time.Time {
+	return dlw.dl.FirstCollectTime()
+}
+
+// LastCollectTime returns the time the last value was collected
+func (dlw Wrapper) LastCollectTime() time.Time {
+	return dlw.dl.LastCollectTime()
+}
+
+// LastTruncation returns when the table was last seen to be truncated
+func (dlw Wrapper) LastTruncation() time.Time {
+	return dlw.dl.LastTruncation()
+}
+
+// WantRelativeStats indiates if we want relative statistics
+func (dlw Wrapper) WantRelativeStats() bool {
+	return dlw.dl.WantRelativeStats()
+}
+
+// content generate a printable result for a row, given the totals
+func (dlw Wrapper) content(row, totals digest_latency.Row) string {
+	name := row.Name
+	if row.CountStar == 0 && name != "Totals" {
+		name = ""
+	}
+
+	return dlw.hidden.Sprintf("%10s %6s %8s %9s %9s %8s %8s %7s %7s|%-12s|%s",
+		lib.FormatTime(dlw.dl.Rate(row.SumTimerWait)),
+		lib.FormatPct(lib.Divide(row.SumTimerWait, totals.SumTimerWait)),
+		lib.FormatAmount(dlw.dl.Rate(row.CountStar)),
+		lib.FormatTime(row.AvgTimerWait()),
+		lib.FormatTime(row.MaxTimerWait),
+		lib.FormatAmount(dlw.dl.Rate(row.SumRowsExamined)),
+		lib.FormatAmount(dlw.dl.Rate(row.SumRowsSent)),
+		lib.FormatAmount(dlw.dl.Rate(row.SumCreatedTmpDiskTables)),
+		lib.FormatAmount(dlw.dl.Rate(row.SumNoIndexUsed)),
+		row.Schema,
+		name)
+}
+
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row digest_latency.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "schema", Value: row.Schema},
+		{Name: "digest", Value: row.Digest},
+		{Name: "latency_ps", Value: row.SumTimerWait},
+		{Name: "count", Value: row.CountStar},
+		{Name: "avg_latency_ps", Value: row.AvgTimerWait()},
+		{Name: "max_latency_ps", Value: row.MaxTimerWait},
+		{Name: "rows_examined", Value: row.SumRowsExamined},
+		{Name: "rows_sent", Value: row.SumRowsSent},
+		{Name: "tmp_disk_tables", Value: row.SumCreatedTmpDiskTables},
+		{Name: "no_index_used", Value: row.SumNoIndexUsed},
+		{Name: "no_good_index_used", Value: row.SumNoGoodIndexUsed},
+	}
+}
@@ -5,55 +5,129 @@ import (
 	"database/sql"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
+	"github.com/sjmudd/ps-top/columns"
 	"github.com/sjmudd/ps-top/context"
 	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/model/file_io"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
 )
 
 // Wrapper wraps a FileIoLatency struct  representing the contents of the data collected from file_summary_by_instance, but adding formatting for presentation in the terminal
 type Wrapper struct {
-	fiol *file_io.FileIoLatency
+	*sorting.Sorter
+	fiol   *file_io.FileIoLatency
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "latency", Heading: 0},
+	{Name: "read_latency", Heading: 2},
+	{Name: "write_latency", Heading: 3},
+	{Name: "misc_latency", Heading: 4},
+	{Name: "read_bytes", Heading: 5},
+	{Name: "write_bytes", Heading: 6},
+	{Name: "ops", Heading: 7},
+	{Name: "read_ops", Heading: 8},
+	{Name: "write_ops", Heading: 9},
+	{Name: "misc_ops", Heading: 10},
+	{Name: "name", Heading: 11},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b file_io.Row) bool{
+	func(a, b file_io.Row) bool { return sorting.Descending(a.SumTimerWait, b.SumTimerWait, a.Name, b.Name) },
+	func(a, b file_io.Row) bool { return sorting.Descending(a.SumTimerRead, b.SumTimerRead, a.Name, b.Name) },
+	func(a, b file_io.Row) bool {
+		return sorting.Descending(a.SumTimerWrite, b.SumTimerWrite, a.Name, b.Name)
+	},
+	func(a, b file_io.Row) bool { return sorting.Descending(a.SumTimerMisc, b.SumTimerMisc, a.Name, b.Name) },
+	func(a, b file_io.Row) bool {
+		return sorting.Descending(a.SumNumberOfBytesRead, b.SumNumberOfBytesRead, a.Name, b.Name)
+	},
+	func(a, b file_io.Row) bool {
+		return sorting.Descending(a.SumNumberOfBytesWrite, b.SumNumberOfBytesWrite, a.Name, b.Name)
+	},
+	func(a, b file_io.Row) bool { return sorting.Descending(a.CountStar, b.CountStar, a.Name, b.Name) },
+	func(a, b file_io.Row) bool { return sorting.Descending(a.CountRead, b.CountRead, a.Name, b.Name) },
+	func(a, b file_io.Row) bool { return sorting.Descending(a.CountWrite, b.CountWrite, a.Name, b.Name) },
+	func(a, b file_io.Row) bool { return sorting.Descending(a.CountMisc, b.CountMisc, a.Name, b.Name) },
+	func(a, b file_io.Row) bool { return sorting.Ascending(a.Name, b.Name) },
 }
 
 // NewFileSummaryByInstance creates a wrapper around FileIoLatency
 func NewFileSummaryByInstance(ctx *context.Context, db *sql.DB) *Wrapper {
+	return NewFileSummaryByInstanceWithSource(ctx, file_io.NewMySQLSource(db))
+}
+
+// NewFileSummaryByInstanceWithSource creates a wrapper collecting data from the given source
+func NewFileSummaryByInstanceWithSource(ctx *context.Context, source file_io.Source) *Wrapper {
 	return &Wrapper{
-		fiol: file_io.NewFileSummaryByInstance(ctx, db),
+		Sorter: sorting.NewSorter(sortColumns...),
+		fiol:   file_io.NewFileSummaryByInstanceWithSource(ctx, source),
 	}
 }
 
+// FileIoLatency returns the wrapped FileIoLatency so other views can use its data
+func (fiolw *Wrapper) FileIoLatency() *file_io.FileIoLatency {
+	return fiolw.fiol
+}
+
 // SetFirstFromLast resets the statistics to last values
 func (fiolw *Wrapper) SetFirstFromLast() {
 	fiolw.fiol.SetFirstFromLast()
 }
 
 // Collect data from the db, then merge it in.
-func (fiolw *Wrapper) Collect() {
-	fiolw.fiol.Collect()
-	sort.Sort(ByLatency(fiolw.fiol.Results))
+func (fiolw *Wrapper) Collect() error {
+	return fiolw.fiol.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (fiolw *Wrapper) SetLocker(locker sync.Locker) {
+	fiolw.fiol.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (fiolw *Wrapper) HideColumns(names []string) error {
+	return fiolw.hidden.Hide(fiolw.headings(), names)
+}
+
+// sort the results by the current sort column
+func (fiolw Wrapper) sort() {
+	results := fiolw.fiol.Results
+	compare := less[fiolw.SortColumn()]
+
+	sort.Slice(results, fiolw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
+}
+
+// headings returns the individual column headings
+func (fiolw Wrapper) headings() []string {
+	latency, rdBytes, wrBytes, ops := "Latency", "Rd bytes", "Wr bytes", "Ops"
+	if fiolw.fiol.WantRates() {
+		latency, rdBytes, wrBytes, ops = "Latency/s", "Rd B/s", "Wr B/s", "Ops/s"
+	}
+
+	return []string{latency, "%", "Read", "Write", "Misc", rdBytes, wrBytes, ops, "R Ops", "W Ops", "M Ops", "Table Name"}
 }
 
 // Headings returns the headings for a table
 func (fiolw Wrapper) Headings() string {
-	return fmt.Sprintf("%10s %6s|%6s %6s %6s|%8s %8s|%8s %6s %6s %6s|%s",
-		"Latency",
-		"%",
-		"Read",
-		"Write",
-		"Misc",
-		"Rd bytes",
-		"Wr bytes",
-		"Ops",
-		"R Ops",
-		"W Ops",
-		"M Ops",
-		"Table Name")
+	return fiolw.hidden.Strings("%10s %6s|%6s %6s %6s|%8s %8s|%8s %6s %6s %6s|%s", fiolw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (fiolw Wrapper) SortHeading() string {
+	return fiolw.headings()[fiolw.SortColumnHeading()]
 }
 
 // RowContent returns the rows we need for displaying
 func (fiolw Wrapper) RowContent() []string {
+	fiolw.sort()
 	rows := make([]string, 0, len(fiolw.fiol.Results))
 
 	for i := range fiolw.fiol.Results {
@@ -68,6 +142,23 @@ func (fiolw Wrapper) TotalRowContent() string {
 	return fiolw.content(fiolw.fiol.Totals, fiolw.fiol.Totals)
 }
 
+// Records returns the rows as typed records in the current sort order
+func (fiolw Wrapper) Records() []record.Record {
+	fiolw.sort()
+	records := make([]record.Record, 0, len(fiolw.fiol.Results))
+
+	for i := range fiolw.fiol.Results {
+		records = append(records, newRecord(fiolw.fiol.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (fiolw Wrapper) TotalRecord() record.Record {
+	return newRecord(fiolw.fiol.Totals)
+}
+
 // Len return the length of the result set
 func (fiolw Wrapper) Len() int {
 	return len(fiolw.fiol.Results)
@@ -108,6 +199,11 @@ func (fiolw Wrapper) LastCollectTime() time.Time {
 	return fiolw.fiol.LastCollectTime()
 }
 
+// LastTruncation returns when the table was last seen to be truncated
+func (fiolw Wrapper) LastTruncation() time.Time {
+	return fiolw.fiol.LastTruncation()
+}
+
 // WantRelativeStats indiates if we want relative statistics
 func (fiolw Wrapper) WantRelativeStats() bool {
 	return fiolw.fiol.WantRelativeStats()
@@ -123,26 +219,34 @@ func (fiolw Wrapper) content(row, totals file_io.Row) string {
 		name = ""
 	}
 
-	return fmt.Sprintf("%10s %6s|%6s %6s %6s|%8s %8s|%8s %6s %6s %6s|%s",
-		lib.FormatTime(row.SumTimerWait),
+	return fiolw.hidden.Sprintf("%10s %6s|%6s %6s %6s|%8s %8s|%8s %6s %6s %6s|%s",
+		lib.FormatTime(fiolw.fiol.Rate(row.SumTimerWait)),
 		lib.FormatPct(lib.Divide(row.SumTimerWait, totals.SumTimerWait)),
 		lib.FormatPct(lib.Divide(row.SumTimerRead, row.SumTimerWait)),
 		lib.FormatPct(lib.Divide(row.SumTimerWrite, row.SumTimerWait)),
 		lib.FormatPct(lib.Divide(row.SumTimerMisc, row.SumTimerWait)),
-		lib.FormatAmount(row.SumNumberOfBytesRead),
-		lib.FormatAmount(row.SumNumberOfBytesWrite),
-		lib.FormatAmount(row.CountStar),
+		lib.FormatAmount(fiolw.fiol.Rate(row.SumNumberOfBytesRead)),
+		lib.FormatAmount(fiolw.fiol.Rate(row.SumNumberOfBytesWrite)),
+		lib.FormatAmount(fiolw.fiol.Rate(row.CountStar)),
 		lib.FormatPct(lib.Divide(row.CountRead, row.CountStar)),
 		lib.FormatPct(lib.Divide(row.CountWrite, row.CountStar)),
 		lib.FormatPct(lib.Divide(row.CountMisc, row.CountStar)),
 		name)
 }
 
-type ByLatency file_io.Rows
-
-func (rows ByLatency) Len() int      { return len(rows) }
-func (rows ByLatency) Swap(i, j int) { rows[i], rows[j] = rows[j], rows[i] }
-func (rows ByLatency) Less(i, j int) bool {
-	return (rows[i].SumTimerWait > rows[j].SumTimerWait) ||
-		((rows[i].SumTimerWait == rows[j].SumTimerWait) && (rows[i].Name < rows[j].Name))
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row file_io.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "latency_ps", Value: row.SumTimerWait},
+		{Name: "read_latency_ps", Value: row.SumTimerRead},
+		{Name: "write_latency_ps", Value: row.SumTimerWrite},
+		{Name: "misc_latency_ps", Value: row.SumTimerMisc},
+		{Name: "read_bytes", Value: row.SumNumberOfBytesRead},
+		{Name: "write_bytes", Value: row.SumNumberOfBytesWrite},
+		{Name: "ops", Value: row.CountStar},
+		{Name: "read_ops", Value: row.CountRead},
+		{Name: "write_ops", Value: row.CountWrite},
+		{Name: "misc_ops", Value: row.CountMisc},
+	}
 }
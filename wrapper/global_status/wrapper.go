@@ -0,0 +1,204 @@
+// Package global_status holds the routines which manage the server status variables
+package global_status
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sjmudd/ps-top/columns"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/model/global_status"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
+)
+
+// Wrapper wraps a GlobalStatus struct
+type Wrapper struct {
+	*sorting.Sorter
+	gs     *global_status.GlobalStatus
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "group", Heading: 2},
+	{Name: "value", Heading: 0},
+	{Name: "name", Heading: 3},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b global_status.Row) bool{
+	func(a, b global_status.Row) bool {
+		if a.Group() != b.Group() {
+			return a.Group() < b.Group()
+		}
+		return a.Name < b.Name
+	},
+	func(a, b global_status.Row) bool { return sorting.Descending(a.Value, b.Value, a.Name, b.Name) },
+	func(a, b global_status.Row) bool { return sorting.Ascending(a.Name, b.Name) },
+}
+
+// NewGlobalStatus creates a wrapper around global_status.GlobalStatus
+// showing the variables starting with the given prefixes
+func NewGlobalStatus(ctx *context.Context, db *sql.DB, prefixes []string) *Wrapper {
+	return NewGlobalStatusWithSource(ctx, global_status.NewMySQLSource(db), prefixes)
+}
+
+// NewGlobalStatusWithSource creates a wrapper collecting data from the given source
+func NewGlobalStatusWithSource(ctx *context.Context, source global_status.Source, prefixes []string) *Wrapper {
+	return &Wrapper{
+		Sorter: sorting.NewSorter(sortColumns...),
+		gs:     global_status.NewGlobalStatusWithSource(ctx, source, prefixes),
+	}
+}
+
+// SetFirstFromLast resets the statistics to last values
+func (gsw *Wrapper) SetFirstFromLast() {
+	gsw.gs.SetFirstFromLast()
+}
+
+// Collect data from the db, then merge it in.
+func (gsw *Wrapper) Collect() error {
+	return gsw.gs.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (gsw *Wrapper) SetLocker(locker sync.Locker) {
+	gsw.gs.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (gsw *Wrapper) HideColumns(names []string) error {
+	return gsw.hidden.Hide(gsw.headings(), names)
+}
+
+// sort the results by the current sort column
+func (gsw Wrapper) sort() {
+	results := gsw.gs.Results
+	compare := less[gsw.SortColumn()]
+
+	sort.Slice(results, gsw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
+}
+
+// RowContent returns the rows we need for displaying
+func (gsw Wrapper) RowContent() []string {
+	gsw.sort()
+	rows := make([]string, 0, len(gsw.gs.Results))
+
+	for i := range gsw.gs.Results {
+		rows = append(rows, gsw.content(gsw.gs.Results[i]))
+	}
+
+	return rows
+}
+
+// TotalRowContent returns all the totals, which for status variables
+// of different kinds is only the number of variables shown
+func (gsw Wrapper) TotalRowContent() string {
+	return gsw.hidden.Sprintf("%12s %7s %-10s|Totals: %d variable(s)", "", "", "", len(gsw.gs.Results))
+}
+
+// Records returns the rows as typed records in the current sort order
+func (gsw Wrapper) Records() []record.Record {
+	gsw.sort()
+	records := make([]record.Record, 0, len(gsw.gs.Results))
+
+	for i := range gsw.gs.Results {
+		records = append(records, newRecord(gsw.gs.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (gsw Wrapper) TotalRecord() record.Record {
+	return newRecord(gsw.gs.Totals)
+}
+
+// Len return the length of the result set
+func (gsw Wrapper) Len() int {
+	return len(gsw.gs.Results)
+}
+
+// EmptyRowContent returns an empty string of data (for filling in)
+func (gsw Wrapper) EmptyRowContent() string {
+	return gsw.hidden.Sprintf("%12s %7s %-10s|", "", "", "")
+}
+
+// HaveRelativeStats is true for this object
+func (gsw Wrapper) HaveRelativeStats() bool {
+	return gsw.gs.HaveRelativeStats()
+}
+
+// FirstCollectTime returns the time the first value was collected
+func (gsw Wrapper) FirstCollectTime() time.Time {
+	return gsw.gs.FirstCollectTime()
+}
+
+// LastCollectTime returns the time the last value was collected
+func (gsw Wrapper) LastCollectTime() time.Time {
+	return gsw.gs.LastCollectTime()
+}
+
+// LastTruncation returns when the status counters were last seen to be reset
+func (gsw Wrapper) LastTruncation() time.Time {
+	return gsw.gs.LastTruncation()
+}
+
+// WantRelativeStats indiates if we want relative statistics
+func (gsw Wrapper) WantRelativeStats() bool {
+	return gsw.gs.WantRelativeStats()
+}
+
+// Description returns a description of the table
+func (gsw Wrapper) Description() string {
+	return fmt.Sprintf("Global Status (global_status) %d rows", len(gsw.gs.Results))
+}
+
+// headings returns the individual column headings
+func (gsw Wrapper) headings() []string {
+	value := "Value"
+	if gsw.gs.WantRates() {
+		value = "Value/s"
+	}
+
+	return []string{value, "Type", "Group", "Variable"}
+}
+
+// Headings returns the headings for a table
+func (gsw Wrapper) Headings() string {
+	return gsw.hidden.Strings("%12s %7s %-10s|%s", gsw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (gsw Wrapper) SortHeading() string {
+	return gsw.headings()[gsw.SortColumnHeading()]
+}
+
+// content generate a printable result for a row. Counters are shown
+// as the change (or rate) and gauges as their current value.
+func (gsw Wrapper) content(row global_status.Row) string {
+	value, kind := gsw.gs.Rate(row.Value), "counter"
+	if row.Gauge {
+		value, kind = row.Value, "gauge"
+	}
+
+	return gsw.hidden.Sprintf("%12s %7s %-10.10s|%s",
+		lib.FormatAmount(value),
+		kind,
+		row.Group(),
+		row.Name)
+}
+
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row global_status.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "value", Value: row.Value},
+		{Name: "gauge", Value: row.Gauge},
+	}
+}
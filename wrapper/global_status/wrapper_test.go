@@ -0,0 +1,56 @@
+package global_status
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+	"github.com/sjmudd/ps-top/model/global_status"
+)
+
+func TestRecords(t *testing.T) {
+	rows := global_status.Rows{
+		{Name: "Threads_running", Value: 3, Gauge: true},
+		{Name: "Com_select", Value: 20},
+		{Name: "Com_insert", Value: 5},
+		{Name: "Handler_write", Value: 0},
+	}
+	var tests = []struct {
+		sort     string
+		reverse  bool
+		prefixes []string
+		expected []string
+	}{
+		{"group", false, nil, []string{"Com_insert", "Com_select", "Threads_running"}},
+		{"group", true, nil, []string{"Threads_running", "Com_select", "Com_insert"}},
+		{"value", false, nil, []string{"Com_select", "Com_insert", "Threads_running"}},
+		{"name", false, nil, []string{"Com_insert", "Com_select", "Threads_running"}},
+		{"group", false, []string{"Threads_"}, []string{"Threads_running"}},
+	}
+
+	for _, test := range tests {
+		ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+		gsw := NewGlobalStatusWithSource(ctx, &global_status.MemorySource{Rows: rows}, test.prefixes)
+		if err := gsw.SetSortColumn(test.sort); err != nil {
+			t.Fatalf("SetSortColumn(%q): unexpected error: %v", test.sort, err)
+		}
+		if test.reverse {
+			gsw.SortReverse()
+		}
+		gsw.Collect()
+
+		var names []string
+		for _, r := range gsw.Records() {
+			names = append(names, r[0].Value.(string))
+		}
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("Records() sorted by %q (reverse: %v): expected %v, actual %v", test.sort, test.reverse, test.expected, names)
+		}
+		if len(gsw.RowContent()) != len(test.expected) {
+			t.Errorf("RowContent(): expected %d rows, actual %d", len(test.expected), len(gsw.RowContent()))
+		}
+	}
+}
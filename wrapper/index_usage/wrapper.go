@@ -0,0 +1,266 @@
+// Package index_usage holds the routines which manage the index usage
+package index_usage
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sjmudd/ps-top/columns"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/model/index_usage"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
+)
+
+// Wrapper wraps an IndexUsage struct
+type Wrapper struct {
+	*sorting.Sorter
+	iu     *index_usage.IndexUsage
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "latency", Heading: 0},
+	{Name: "fetch_latency", Heading: 2},
+	{Name: "insert_latency", Heading: 3},
+	{Name: "update_latency", Heading: 4},
+	{Name: "delete_latency", Heading: 5},
+	{Name: "ops", Heading: 6},
+	{Name: "unused", Heading: 7},
+	{Name: "name", Heading: 9},
+}
+
+// name returns the table and index name, used to order rows with equal values
+func name(row index_usage.Row) string {
+	return row.Name + " " + row.Index
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b index_usage.Row) bool{
+	func(a, b index_usage.Row) bool {
+		return sorting.Descending(a.SumTimerWait, b.SumTimerWait, name(a), name(b))
+	},
+	func(a, b index_usage.Row) bool {
+		return sorting.Descending(a.SumTimerFetch, b.SumTimerFetch, name(a), name(b))
+	},
+	func(a, b index_usage.Row) bool {
+		return sorting.Descending(a.SumTimerInsert, b.SumTimerInsert, name(a), name(b))
+	},
+	func(a, b index_usage.Row) bool {
+		return sorting.Descending(a.SumTimerUpdate, b.SumTimerUpdate, name(a), name(b))
+	},
+	func(a, b index_usage.Row) bool {
+		return sorting.Descending(a.SumTimerDelete, b.SumTimerDelete, name(a), name(b))
+	},
+	func(a, b index_usage.Row) bool {
+		return sorting.Descending(a.CountStar, b.CountStar, name(a), name(b))
+	},
+	func(a, b index_usage.Row) bool {
+		if a.Unused() != b.Unused() {
+			return a.Unused()
+		}
+		return sorting.Ascending(name(a), name(b))
+	},
+	func(a, b index_usage.Row) bool { return sorting.Ascending(name(a), name(b)) },
+}
+
+// NewIndexUsage creates a wrapper around index_usage.IndexUsage
+func NewIndexUsage(ctx *context.Context, db *sql.DB) *Wrapper {
+	return NewIndexUsageWithSource(ctx, index_usage.NewMySQLSource(db))
+}
+
+// NewIndexUsageWithSource creates a wrapper collecting data from the given source
+func NewIndexUsageWithSource(ctx *context.Context, source index_usage.Source) *Wrapper {
+	return &Wrapper{
+		Sorter: sorting.NewSorter(sortColumns...),
+		iu:     index_usage.NewIndexUsageWithSource(ctx, source),
+	}
+}
+
+// IndexUsage returns the wrapped IndexUsage so other views can use its data
+func (iuw *Wrapper) IndexUsage() *index_usage.IndexUsage {
+	return iuw.iu
+}
+
+// SetFirstFromLast resets the statistics to last values
+func (iuw *Wrapper) SetFirstFromLast() {
+	iuw.iu.SetFirstFromLast()
+}
+
+// Collect data from the db, then merge it in.
+func (iuw *Wrapper) Collect() error {
+	return iuw.iu.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (iuw *Wrapper) SetLocker(locker sync.Locker) {
+	iuw.iu.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (iuw *Wrapper) HideColumns(names []string) error {
+	return iuw.hidden.Hide(iuw.headings(), names)
+}
+
+// sort the results by the current sort column
+func (iuw Wrapper) sort() {
+	results := iuw.iu.Results
+	compare := less[iuw.SortColumn()]
+
+	sort.Slice(results, iuw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
+}
+
+// headings returns the individual column headings
+func (iuw Wrapper) headings() []string {
+	latency, ops := "Latency", "Ops"
+	if iuw.iu.WantRates() {
+		latency, ops = "Latency/s", "Ops/s"
+	}
+
+	return []string{latency, "%", "Fetch", "Insert", "Update", "Delete", ops, "Flag", "Index", "Table Name"}
+}
+
+// Headings returns the headings for a table
+func (iuw Wrapper) Headings() string {
+	return iuw.hidden.Strings("%10s %6s|%6s %6s %6s %6s|%8s %-6s|%-20s|%s", iuw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (iuw Wrapper) SortHeading() string {
+	return iuw.headings()[iuw.SortColumnHeading()]
+}
+
+// RowContent returns the rows we need for displaying
+func (iuw Wrapper) RowContent() []string {
+	iuw.sort()
+	rows := make([]string, 0, len(iuw.iu.Results))
+
+	for i := range iuw.iu.Results {
+		rows = append(rows, iuw.content(iuw.iu.Results[i], iuw.iu.Totals))
+	}
+
+	return rows
+}
+
+// TotalRowContent returns all the totals
+func (iuw Wrapper) TotalRowContent() string {
+	return iuw.content(iuw.iu.Totals, iuw.iu.Totals)
+}
+
+// Records returns the rows as typed records in the current sort order
+func (iuw Wrapper) Records() []record.Record {
+	iuw.sort()
+	records := make([]record.Record, 0, len(iuw.iu.Results))
+
+	for i := range iuw.iu.Results {
+		records = append(records, newRecord(iuw.iu.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (iuw Wrapper) TotalRecord() record.Record {
+	return newRecord(iuw.iu.Totals)
+}
+
+// Len return the length of the result set
+func (iuw Wrapper) Len() int {
+	return iuw.iu.Len()
+}
+
+// EmptyRowContent returns an empty string of data (for filling in)
+func (iuw Wrapper) EmptyRowContent() string {
+	var empty index_usage.Row
+
+	return iuw.content(empty, empty)
+}
+
+// Description returns a description of the table
+func (iuw Wrapper) Description() string {
+	var count int
+	for row := range iuw.iu.Results {
+		if iuw.iu.Results[row].HasData() {
+			count++
+		}
+	}
+
+	return fmt.Sprintf("Index Usage (table_io_waits_summary_by_index_usage) %d rows, %d unused indexes", count, iuw.iu.Unused())
+}
+
+// HaveRelativeStats is true for this object
+func (iuw Wrapper) HaveRelativeStats() bool {
+	return iuw.iu.HaveRelativeStats()
+}
+
+// FirstCollectTime returns the time the first value was collected
+func (iuw Wrapper) FirstCollectTime() time.Time {
+	return iuw.iu.FirstCollectTime()
+}
+
+// LastCollectTime returns the time the last value was collected
+func (iuw Wrapper) LastCollectTime() time.Time {
+	return iuw.iu.LastCollectTime()
+}
+
+// LastTruncation returns when the table was last seen to be truncated
+func (iuw Wrapper) LastTruncation() time.Time {
+	return iuw.iu.LastTruncation()
+}
+
+// WantRelativeStats indiates if we want relative statistics
+func (iuw Wrapper) WantRelativeStats() bool {
+	return iuw.iu.WantRelativeStats()
+}
+
+// flag returns SCAN for accesses without an index and UNUSED for
+// indexes which are candidates for removal
+func flag(row index_usage.Row) string {
+	switch {
+	case row.FullScan():
+		return "SCAN"
+	case row.Unused():
+		return "UNUSED"
+	}
+	return ""
+}
+
+// content generate a printable result for a row, given the totals
+func (iuw Wrapper) content(row, totals index_usage.Row) string {
+	index := row.Index
+	if row.FullScan() {
+		index = "(no index)"
+	}
+
+	return iuw.hidden.Sprintf("%10s %6s|%6s %6s %6s %6s|%8s %-6s|%-20s|%s",
+		lib.FormatTime(iuw.iu.Rate(row.SumTimerWait)),
+		lib.FormatPct(lib.Divide(row.SumTimerWait, totals.SumTimerWait)),
+		lib.FormatPct(lib.Divide(row.SumTimerFetch, row.SumTimerWait)),
+		lib.FormatPct(lib.Divide(row.SumTimerInsert, row.SumTimerWait)),
+		lib.FormatPct(lib.Divide(row.SumTimerUpdate, row.SumTimerWait)),
+		lib.FormatPct(lib.Divide(row.SumTimerDelete, row.SumTimerWait)),
+		lib.FormatAmount(iuw.iu.Rate(row.CountStar)),
+		flag(row),
+		index,
+		row.Name)
+}
+
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row index_usage.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "index", Value: row.Index},
+		{Name: "flag", Value: flag(row)},
+		{Name: "latency_ps", Value: row.SumTimerWait},
+		{Name: "fetch_latency_ps", Value: row.SumTimerFetch},
+		{Name: "insert_latency_ps", Value: row.SumTimerInsert},
+		{Name: "update_latency_ps", Value: row.SumTimerUpdate},
+		{Name: "delete_latency_ps", Value: row.SumTimerDelete},
+		{Name: "ops", Value: row.CountStar},
+	}
+}
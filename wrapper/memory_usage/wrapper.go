@@ -5,22 +5,64 @@ import (
 	"database/sql"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
+	"github.com/sjmudd/ps-top/columns"
 	"github.com/sjmudd/ps-top/context"
 	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/model/memory_usage"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
 )
 
 // Wrapper wraps a FileIoLatency struct  representing the contents of the data collected from file_summary_by_instance, but adding formatting for presentation in the terminal
 type Wrapper struct {
-	mu *memory_usage.MemoryUsage
+	*sorting.Sorter
+	mu     *memory_usage.MemoryUsage
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "current_bytes", Heading: 0},
+	{Name: "high_bytes", Heading: 2},
+	{Name: "ops", Heading: 3},
+	{Name: "current_count", Heading: 5},
+	{Name: "high_count", Heading: 7},
+	{Name: "name", Heading: 8},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b memory_usage.Row) bool{
+	func(a, b memory_usage.Row) bool {
+		return sorting.SignedDescending(a.CurrentBytesUsed, b.CurrentBytesUsed, a.Name, b.Name)
+	},
+	func(a, b memory_usage.Row) bool {
+		return sorting.SignedDescending(a.HighBytesUsed, b.HighBytesUsed, a.Name, b.Name)
+	},
+	func(a, b memory_usage.Row) bool {
+		return sorting.SignedDescending(a.TotalMemoryOps, b.TotalMemoryOps, a.Name, b.Name)
+	},
+	func(a, b memory_usage.Row) bool {
+		return sorting.SignedDescending(a.CurrentCountUsed, b.CurrentCountUsed, a.Name, b.Name)
+	},
+	func(a, b memory_usage.Row) bool {
+		return sorting.SignedDescending(a.HighCountUsed, b.HighCountUsed, a.Name, b.Name)
+	},
+	func(a, b memory_usage.Row) bool { return sorting.Ascending(a.Name, b.Name) },
 }
 
 // NewMemoryUsage creates a wrapper around MemoryUsage
 func NewMemoryUsage(ctx *context.Context, db *sql.DB) *Wrapper {
+	return NewMemoryUsageWithSource(ctx, memory_usage.NewMySQLSource(db))
+}
+
+// NewMemoryUsageWithSource creates a wrapper collecting data from the given source
+func NewMemoryUsageWithSource(ctx *context.Context, source memory_usage.Source) *Wrapper {
 	return &Wrapper{
-		mu: memory_usage.NewMemoryUsage(ctx, db),
+		Sorter: sorting.NewSorter(sortColumns...),
+		mu:     memory_usage.NewMemoryUsageWithSource(ctx, source),
 	}
 }
 
@@ -30,19 +72,52 @@ func (muw *Wrapper) SetFirstFromLast() {
 }
 
 // Collect data from the db, then merge it in.
-func (muw *Wrapper) Collect() {
-	muw.mu.Collect()
-	sort.Sort(ByBytes(muw.mu.Results))
+func (muw *Wrapper) Collect() error {
+	return muw.mu.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (muw *Wrapper) SetLocker(locker sync.Locker) {
+	muw.mu.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (muw *Wrapper) HideColumns(names []string) error {
+	return muw.hidden.Hide(muw.headings(), names)
+}
+
+// sort the results by the current sort column
+func (muw Wrapper) sort() {
+	results := muw.mu.Results
+	compare := less[muw.SortColumn()]
+
+	sort.Slice(results, muw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
+}
+
+// headings returns the individual column headings
+func (muw Wrapper) headings() []string {
+	ops := "MemOps"
+	if muw.mu.WantRates() {
+		ops = "MemOps/s"
+	}
+
+	return []string{"CurBytes", "%", "High Bytes", ops, "%", "CurAlloc", "%", "HiAlloc", "Memory Area"}
 }
 
 // Headings returns the headings for a table
 func (muw Wrapper) Headings() string {
-	return fmt.Sprint("CurBytes         %  High Bytes|MemOps          %|CurAlloc       %  HiAlloc|Memory Area")
+	return muw.hidden.Strings("%-10s  %6s  %10s|%-10s %6s|%-8s  %6s  %s|%s", muw.headings())
 	//                         1234567890  100.0%  1234567890|123456789  100.0%|12345678  100.0%  12345678|Some memory name
 }
 
+// SortHeading returns the heading of the column the results are sorted by
+func (muw Wrapper) SortHeading() string {
+	return muw.headings()[muw.SortColumnHeading()]
+}
+
 // RowContent returns the rows we need for displaying
 func (muw Wrapper) RowContent() []string {
+	muw.sort()
 	rows := make([]string, 0, len(muw.mu.Results))
 
 	for i := range muw.mu.Results {
@@ -57,6 +132,23 @@ func (muw Wrapper) TotalRowContent() string {
 	return muw.content(muw.mu.Totals, muw.mu.Totals)
 }
 
+// Records returns the rows as typed records in the current sort order
+func (muw Wrapper) Records() []record.Record {
+	muw.sort()
+	records := make([]record.Record, 0, len(muw.mu.Results))
+
+	for i := range muw.mu.Results {
+		records = append(records, newRecord(muw.mu.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (muw Wrapper) TotalRecord() record.Record {
+	return newRecord(muw.mu.Totals)
+}
+
 // Len return the length of the result set
 func (muw Wrapper) Len() int {
 	return len(muw.mu.Results)
@@ -97,6 +189,11 @@ func (muw Wrapper) LastCollectTime() time.Time {
 	return muw.mu.LastCollectTime()
 }
 
+// LastTruncation returns when the table was last seen to be truncated
+func (muw Wrapper) LastTruncation() time.Time {
+	return muw.mu.LastTruncation()
+}
+
 // WantRelativeStats indiates if we want relative statistics
 func (muw Wrapper) WantRelativeStats() bool {
 	return muw.mu.WantRelativeStats()
@@ -110,11 +207,11 @@ func (muw Wrapper) content(row, totals memory_usage.Row) string {
 		name = ""
 	}
 
-	return fmt.Sprintf("%10s  %6s  %10s|%10s %6s|%8s  %6s  %8s|%s",
+	return muw.hidden.Sprintf("%10s  %6s  %10s|%10s %6s|%8s  %6s  %8s|%s",
 		lib.SignedFormatAmount(row.CurrentBytesUsed),
 		lib.FormatPct(lib.SignedDivide(row.CurrentBytesUsed, totals.CurrentBytesUsed)),
 		lib.SignedFormatAmount(row.HighBytesUsed),
-		lib.SignedFormatAmount(row.TotalMemoryOps),
+		lib.SignedFormatAmount(muw.mu.Rate(row.TotalMemoryOps)),
 		lib.FormatPct(lib.SignedDivide(row.TotalMemoryOps, totals.TotalMemoryOps)),
 		lib.SignedFormatAmount(row.CurrentCountUsed),
 		lib.FormatPct(lib.SignedDivide(row.CurrentCountUsed, totals.CurrentCountUsed)),
@@ -122,13 +219,14 @@ func (muw Wrapper) content(row, totals memory_usage.Row) string {
 		name)
 }
 
-type ByBytes memory_usage.Rows
-
-func (t ByBytes) Len() int      { return len(t) }
-func (t ByBytes) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
-func (t ByBytes) Less(i, j int) bool {
-	return (t[i].CurrentBytesUsed > t[j].CurrentBytesUsed) ||
-		((t[i].CurrentBytesUsed == t[j].CurrentBytesUsed) &&
-			(t[i].Name < t[j].Name))
-
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row memory_usage.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "current_bytes", Value: row.CurrentBytesUsed},
+		{Name: "high_bytes", Value: row.HighBytesUsed},
+		{Name: "ops", Value: row.TotalMemoryOps},
+		{Name: "current_count", Value: row.CurrentCountUsed},
+		{Name: "high_count", Value: row.HighCountUsed},
+	}
 }
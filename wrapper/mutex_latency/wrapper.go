@@ -5,22 +5,50 @@ import (
 	"database/sql"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
+	"github.com/sjmudd/ps-top/columns"
 	"github.com/sjmudd/ps-top/context"
 	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/model/mutex_latency"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
 )
 
 // Wrapper wraps a MutexLatency struct
 type Wrapper struct {
-	ml *mutex_latency.MutexLatency
+	*sorting.Sorter
+	ml     *mutex_latency.MutexLatency
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "latency", Heading: 0},
+	{Name: "count", Heading: 1},
+	{Name: "name", Heading: 3},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b mutex_latency.Row) bool{
+	func(a, b mutex_latency.Row) bool {
+		return sorting.Descending(a.SumTimerWait, b.SumTimerWait, a.Name, b.Name)
+	},
+	func(a, b mutex_latency.Row) bool { return sorting.Descending(a.CountStar, b.CountStar, a.Name, b.Name) },
+	func(a, b mutex_latency.Row) bool { return sorting.Ascending(a.Name, b.Name) },
 }
 
 // NewMutexLatency creates a wrapper around mutex_latency.MutexLatency
 func NewMutexLatency(ctx *context.Context, db *sql.DB) *Wrapper {
+	return NewMutexLatencyWithSource(ctx, mutex_latency.NewMySQLSource(db))
+}
+
+// NewMutexLatencyWithSource creates a wrapper collecting data from the given source
+func NewMutexLatencyWithSource(ctx *context.Context, source mutex_latency.Source) *Wrapper {
 	return &Wrapper{
-		ml: mutex_latency.NewMutexLatency(ctx, db),
+		Sorter: sorting.NewSorter(sortColumns...),
+		ml:     mutex_latency.NewMutexLatencyWithSource(ctx, source),
 	}
 }
 
@@ -30,13 +58,31 @@ func (mlw *Wrapper) SetFirstFromLast() {
 }
 
 // Collect data from the db, then merge it in.
-func (mlw *Wrapper) Collect() {
-	mlw.ml.Collect()
-	sort.Sort(ByValue(mlw.ml.Results))
+func (mlw *Wrapper) Collect() error {
+	return mlw.ml.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (mlw *Wrapper) SetLocker(locker sync.Locker) {
+	mlw.ml.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (mlw *Wrapper) HideColumns(names []string) error {
+	return mlw.hidden.Hide(mlw.headings(), names)
+}
+
+// sort the results by the current sort column
+func (mlw Wrapper) sort() {
+	results := mlw.ml.Results
+	compare := less[mlw.SortColumn()]
+
+	sort.Slice(results, mlw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
 }
 
 // RowContent returns the rows we need for displaying
 func (mlw Wrapper) RowContent() []string {
+	mlw.sort()
 	rows := make([]string, 0, len(mlw.ml.Results))
 
 	for i := range mlw.ml.Results {
@@ -51,6 +97,23 @@ func (mlw Wrapper) TotalRowContent() string {
 	return mlw.content(mlw.ml.Totals, mlw.ml.Totals)
 }
 
+// Records returns the rows as typed records in the current sort order
+func (mlw Wrapper) Records() []record.Record {
+	mlw.sort()
+	records := make([]record.Record, 0, len(mlw.ml.Results))
+
+	for i := range mlw.ml.Results {
+		records = append(records, newRecord(mlw.ml.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (mlw Wrapper) TotalRecord() record.Record {
+	return newRecord(mlw.ml.Totals)
+}
+
 // Len return the length of the result set
 func (mlw Wrapper) Len() int {
 	return len(mlw.ml.Results)
@@ -78,6 +141,11 @@ func (mlw Wrapper) LastCollectTime() time.Time {
 	return mlw.ml.LastCollectTime()
 }
 
+// LastTruncation returns when the table was last seen to be truncated
+func (mlw Wrapper) LastTruncation() time.Time {
+	return mlw.ml.LastTruncation()
+}
+
 // WantRelativeStats indiates if we want relative statistics
 func (mlw Wrapper) WantRelativeStats() bool {
 	return mlw.ml.WantRelativeStats()
@@ -94,9 +162,24 @@ func (mlw Wrapper) Description() string {
 	return fmt.Sprintf("Mutex Latency (events_waits_summary_global_by_event_name) %d rows", count)
 }
 
+// headings returns the individual column headings
+func (mlw Wrapper) headings() []string {
+	latency, count := "Latency", "MtxCnt"
+	if mlw.ml.WantRates() {
+		latency, count = "Latency/s", "MtxCnt/s"
+	}
+
+	return []string{latency, count, "%", "Mutex Name"}
+}
+
 // Headings returns the headings for a table
 func (mlw Wrapper) Headings() string {
-	return fmt.Sprintf("%10s %8s %8s|%s", "Latency", "MtxCnt", "%", "Mutex Name")
+	return mlw.hidden.Strings("%10s %8s %8s|%s", mlw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (mlw Wrapper) SortHeading() string {
+	return mlw.headings()[mlw.SortColumnHeading()]
 }
 
 // content generate a printable result for a row, given the totals
@@ -106,19 +189,18 @@ func (mlw Wrapper) content(row, totals mutex_latency.Row) string {
 		name = ""
 	}
 
-	return fmt.Sprintf("%10s %8s %8s|%s",
-		lib.FormatTime(row.SumTimerWait),
-		lib.FormatAmount(row.CountStar),
+	return mlw.hidden.Sprintf("%10s %8s %8s|%s",
+		lib.FormatTime(mlw.ml.Rate(row.SumTimerWait)),
+		lib.FormatAmount(mlw.ml.Rate(row.CountStar)),
 		lib.FormatPct(lib.Divide(row.SumTimerWait, totals.SumTimerWait)),
 		name)
 }
 
-type ByValue mutex_latency.Rows
-
-func (rows ByValue) Len() int      { return len(rows) }
-func (rows ByValue) Swap(i, j int) { rows[i], rows[j] = rows[j], rows[i] }
-
-// sort by value (descending) but also by "name" (ascending) if the values are the same
-func (rows ByValue) Less(i, j int) bool {
-	return rows[i].SumTimerWait > rows[j].SumTimerWait
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row mutex_latency.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "latency_ps", Value: row.SumTimerWait},
+		{Name: "count", Value: row.CountStar},
+	}
 }
@@ -0,0 +1,69 @@
+package mutex_latency
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+	"github.com/sjmudd/ps-top/model/mutex_latency"
+)
+
+func TestRecords(t *testing.T) {
+	rows := mutex_latency.Rows{
+		{Name: "trx_mutex", SumTimerWait: 20, CountStar: 1},
+		{Name: "lock_mutex", SumTimerWait: 30, CountStar: 5},
+		{Name: "buf_pool_mutex", SumTimerWait: 20, CountStar: 9},
+	}
+	var tests = []struct {
+		sort     string
+		reverse  bool
+		expected []string
+	}{
+		{"latency", false, []string{"lock_mutex", "buf_pool_mutex", "trx_mutex"}},
+		{"latency", true, []string{"trx_mutex", "buf_pool_mutex", "lock_mutex"}},
+		{"count", false, []string{"buf_pool_mutex", "lock_mutex", "trx_mutex"}},
+		{"name", false, []string{"buf_pool_mutex", "lock_mutex", "trx_mutex"}},
+	}
+
+	for _, test := range tests {
+		ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+		mlw := NewMutexLatencyWithSource(ctx, &mutex_latency.MemorySource{Rows: rows})
+		if err := mlw.SetSortColumn(test.sort); err != nil {
+			t.Fatalf("SetSortColumn(%q): unexpected error: %v", test.sort, err)
+		}
+		if test.reverse {
+			mlw.SortReverse()
+		}
+		mlw.Collect()
+
+		var names []string
+		for _, r := range mlw.Records() {
+			names = append(names, r[0].Value.(string))
+		}
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("Records() sorted by %q (reverse: %v): expected %v, actual %v", test.sort, test.reverse, test.expected, names)
+		}
+		if len(mlw.RowContent()) != len(rows) {
+			t.Errorf("RowContent(): expected %d rows, actual %d", len(rows), len(mlw.RowContent()))
+		}
+	}
+}
+
+func TestTotalRecord(t *testing.T) {
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	mlw := NewMutexLatencyWithSource(ctx, &mutex_latency.MemorySource{
+		Rows: mutex_latency.Rows{
+			{Name: "trx_mutex", SumTimerWait: 20, CountStar: 1},
+			{Name: "lock_mutex", SumTimerWait: 30, CountStar: 5},
+		},
+	})
+	mlw.Collect()
+
+	expected := newRecord(mutex_latency.Row{Name: "Totals", SumTimerWait: 50, CountStar: 6})
+	if got := mlw.TotalRecord(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("TotalRecord(): expected %v, actual %v", expected, got)
+	}
+}
@@ -0,0 +1,222 @@
+// Package replication holds the routines which manage the replication threads
+package replication
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sjmudd/ps-top/columns"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/model/replication"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
+)
+
+// Wrapper wraps a Replication struct
+type Wrapper struct {
+	*sorting.Sorter
+	r      *replication.Replication
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "applied", Heading: 0},
+	{Name: "lag", Heading: 2},
+	{Name: "name", Heading: 7},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b replication.Row) bool{
+	func(a, b replication.Row) bool { return sorting.Descending(a.Applied, b.Applied, a.Name, b.Name) },
+	func(a, b replication.Row) bool { return sorting.Descending(a.ApplyLag, b.ApplyLag, a.Name, b.Name) },
+	func(a, b replication.Row) bool { return sorting.Ascending(a.Name, b.Name) },
+}
+
+// NewReplication creates a wrapper around replication.Replication
+func NewReplication(ctx *context.Context, db *sql.DB) *Wrapper {
+	return NewReplicationWithSource(ctx, replication.NewMySQLSource(db, ctx.Capabilities()))
+}
+
+// NewReplicationWithSource creates a wrapper collecting data from the given source
+func NewReplicationWithSource(ctx *context.Context, source replication.Source) *Wrapper {
+	return &Wrapper{
+		Sorter: sorting.NewSorter(sortColumns...),
+		r:      replication.NewReplicationWithSource(ctx, source),
+	}
+}
+
+// SetFirstFromLast resets the statistics to last values
+func (rw *Wrapper) SetFirstFromLast() {
+	rw.r.SetFirstFromLast()
+}
+
+// Collect data from the db, then merge it in.
+func (rw *Wrapper) Collect() error {
+	return rw.r.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (rw *Wrapper) SetLocker(locker sync.Locker) {
+	rw.r.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (rw *Wrapper) HideColumns(names []string) error {
+	return rw.hidden.Hide(rw.headings(), names)
+}
+
+// sort the results by the current sort column
+func (rw Wrapper) sort() {
+	results := rw.r.Results
+	compare := less[rw.SortColumn()]
+
+	sort.Slice(results, rw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
+}
+
+// headings returns the individual column headings
+func (rw Wrapper) headings() []string {
+	applied := "Applied"
+	if rw.r.WantRates() {
+		applied = "Applied/s"
+	}
+
+	return []string{applied, "%", "Apply Lag", "State", "Conn", "Error", "Last Transaction", "Channel/Thread"}
+}
+
+// Headings returns the headings for a table
+func (rw Wrapper) Headings() string {
+	return rw.hidden.Strings("%9s %6s %9s %-5s %-10s %5s|%-46s|%s", rw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (rw Wrapper) SortHeading() string {
+	return rw.headings()[rw.SortColumnHeading()]
+}
+
+// RowContent returns the rows we need for displaying
+func (rw Wrapper) RowContent() []string {
+	rw.sort()
+	rows := make([]string, 0, len(rw.r.Results))
+
+	for i := range rw.r.Results {
+		rows = append(rows, rw.content(rw.r.Results[i], rw.r.Totals))
+	}
+
+	return rows
+}
+
+// TotalRowContent returns all the totals
+func (rw Wrapper) TotalRowContent() string {
+	return rw.content(rw.r.Totals, rw.r.Totals)
+}
+
+// Records returns the rows as typed records in the current sort order
+func (rw Wrapper) Records() []record.Record {
+	rw.sort()
+	records := make([]record.Record, 0, len(rw.r.Results))
+
+	for i := range rw.r.Results {
+		records = append(records, newRecord(rw.r.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (rw Wrapper) TotalRecord() record.Record {
+	return newRecord(rw.r.Totals)
+}
+
+// EmptyRowContent returns an empty string of data (for filling in)
+func (rw Wrapper) EmptyRowContent() string {
+	var empty replication.Row
+
+	return rw.content(empty, empty)
+}
+
+// Description returns a description of the table
+func (rw Wrapper) Description() string {
+	var errors int
+	for i := range rw.r.Results {
+		if rw.r.Results[i].ErrorNumber != 0 {
+			errors++
+		}
+	}
+
+	return fmt.Sprintf("Replication (replication_applier_status_by_worker) %d thread(s), %d with errors", len(rw.r.Results), errors)
+}
+
+// HaveRelativeStats is true for this object
+func (rw Wrapper) HaveRelativeStats() bool {
+	return rw.r.HaveRelativeStats()
+}
+
+// FirstCollectTime returns the time the first value was collected
+func (rw Wrapper) FirstCollectTime() time.Time {
+	return rw.r.FirstCollectTime()
+}
+
+// LastCollectTime returns the time the last value was collected
+func (rw Wrapper) LastCollectTime() time.Time {
+	return rw.r.LastCollectTime()
+}
+
+// LastTruncation returns when the table was last seen to be truncated
+func (rw Wrapper) LastTruncation() time.Time {
+	return rw.r.LastTruncation()
+}
+
+// WantRelativeStats indiates if we want relative statistics
+func (rw Wrapper) WantRelativeStats() bool {
+	return rw.r.WantRelativeStats()
+}
+
+// Len return the length of the result set
+func (rw Wrapper) Len() int {
+	return len(rw.r.Results)
+}
+
+// content generate a printable result for a row, given the totals.
+// The last error message follows the name if there is one.
+func (rw Wrapper) content(row, totals replication.Row) string {
+	name := row.Name
+	if row.ErrorMessage != "" {
+		name += ": " + row.ErrorMessage
+	}
+	errorNumber := ""
+	if row.ErrorNumber != 0 {
+		errorNumber = fmt.Sprint(row.ErrorNumber)
+	}
+
+	return rw.hidden.Sprintf("%9s %6s %9s %-5s %-10s %5s|%-46s|%s",
+		lib.FormatAmount(rw.r.Rate(row.Applied)),
+		lib.FormatPct(lib.Divide(row.Applied, totals.Applied)),
+		lib.FormatTime(row.ApplyLag),
+		row.State,
+		row.ConnectionState,
+		errorNumber,
+		row.LastTransaction,
+		name)
+}
+
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row replication.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "channel", Value: row.Channel},
+		{Name: "worker_id", Value: row.WorkerID},
+		{Name: "thread_id", Value: row.ThreadID},
+		{Name: "state", Value: row.State},
+		{Name: "connection_state", Value: row.ConnectionState},
+		{Name: "last_transaction", Value: row.LastTransaction},
+		{Name: "apply_lag_ps", Value: row.ApplyLag},
+		{Name: "error_number", Value: row.ErrorNumber},
+		{Name: "error_message", Value: row.ErrorMessage},
+		{Name: "applied", Value: row.Applied},
+	}
+}
@@ -5,22 +5,52 @@ import (
 	"database/sql"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
+	"github.com/sjmudd/ps-top/columns"
 	"github.com/sjmudd/ps-top/context"
 	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/model/stages_latency"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
 )
 
 // Wrapper wraps a Stages struct
 type Wrapper struct {
-	sl *stages_latency.StagesLatency
+	*sorting.Sorter
+	sl     *stages_latency.StagesLatency
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "latency", Heading: 0},
+	{Name: "count", Heading: 2},
+	{Name: "name", Heading: 3},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b stages_latency.Row) bool{
+	func(a, b stages_latency.Row) bool {
+		return sorting.Descending(a.SumTimerWait, b.SumTimerWait, a.Name, b.Name)
+	},
+	func(a, b stages_latency.Row) bool {
+		return sorting.Descending(a.CountStar, b.CountStar, a.Name, b.Name)
+	},
+	func(a, b stages_latency.Row) bool { return sorting.Ascending(a.Name, b.Name) },
 }
 
 // NewStages creates a wrapper around Stages
 func NewStagesLatency(ctx *context.Context, db *sql.DB) *Wrapper {
+	return NewStagesLatencyWithSource(ctx, stages_latency.NewMySQLSource(db))
+}
+
+// NewStagesLatencyWithSource creates a wrapper collecting data from the given source
+func NewStagesLatencyWithSource(ctx *context.Context, source stages_latency.Source) *Wrapper {
 	return &Wrapper{
-		sl: stages_latency.NewStagesLatency(ctx, db),
+		Sorter: sorting.NewSorter(sortColumns...),
+		sl:     stages_latency.NewStagesLatencyWithSource(ctx, source),
 	}
 }
 
@@ -30,19 +60,51 @@ func (slw *Wrapper) SetFirstFromLast() {
 }
 
 // Collect data from the db, then merge it in.
-func (slw *Wrapper) Collect() {
-	slw.sl.Collect()
-	sort.Sort(ByLatency(slw.sl.Results))
+func (slw *Wrapper) Collect() error {
+	return slw.sl.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (slw *Wrapper) SetLocker(locker sync.Locker) {
+	slw.sl.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (slw *Wrapper) HideColumns(names []string) error {
+	return slw.hidden.Hide(slw.headings(), names)
+}
+
+// sort the results by the current sort column
+func (slw Wrapper) sort() {
+	results := slw.sl.Results
+	compare := less[slw.SortColumn()]
+
+	sort.Slice(results, slw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
+}
+
+// headings returns the individual column headings
+func (slw Wrapper) headings() []string {
+	latency, counter := "Latency", "Counter"
+	if slw.sl.WantRates() {
+		latency, counter = "Latency/s", "Count/s"
+	}
+
+	return []string{latency, "%", counter, "Stage Name"}
 }
 
 // Headings returns the headings for a table
 func (slw Wrapper) Headings() string {
-	return fmt.Sprintf("%10s %6s %8s|%s", "Latency", "%", "Counter", "Stage Name")
+	return slw.hidden.Strings("%10s %6s %8s|%s", slw.headings())
+}
 
+// SortHeading returns the heading of the column the results are sorted by
+func (slw Wrapper) SortHeading() string {
+	return slw.headings()[slw.SortColumnHeading()]
 }
 
 // RowContent returns the rows we need for displaying
 func (slw Wrapper) RowContent() []string {
+	slw.sort()
 	rows := make([]string, 0, len(slw.sl.Results))
 
 	for i := range slw.sl.Results {
@@ -57,6 +119,23 @@ func (slw Wrapper) TotalRowContent() string {
 	return slw.content(slw.sl.Totals, slw.sl.Totals)
 }
 
+// Records returns the rows as typed records in the current sort order
+func (slw Wrapper) Records() []record.Record {
+	slw.sort()
+	records := make([]record.Record, 0, len(slw.sl.Results))
+
+	for i := range slw.sl.Results {
+		records = append(records, newRecord(slw.sl.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (slw Wrapper) TotalRecord() record.Record {
+	return newRecord(slw.sl.Totals)
+}
+
 // Len return the length of the result set
 func (slw Wrapper) Len() int {
 	return len(slw.sl.Results)
@@ -96,6 +175,11 @@ func (slw Wrapper) LastCollectTime() time.Time {
 	return slw.sl.LastCollectTime()
 }
 
+// LastTruncation returns when the table was last seen to be truncated
+func (slw Wrapper) LastTruncation() time.Time {
+	return slw.sl.LastTruncation()
+}
+
 // WantRelativeStats indiates if we want relative statistics
 func (slw Wrapper) WantRelativeStats() bool {
 	return slw.sl.WantRelativeStats()
@@ -108,20 +192,18 @@ func (slw Wrapper) content(row, totals stages_latency.Row) string {
 		name = ""
 	}
 
-	return fmt.Sprintf("%10s %6s %8s|%s",
-		lib.FormatTime(row.SumTimerWait),
+	return slw.hidden.Sprintf("%10s %6s %8s|%s",
+		lib.FormatTime(slw.sl.Rate(row.SumTimerWait)),
 		lib.FormatPct(lib.Divide(row.SumTimerWait, totals.SumTimerWait)),
-		lib.FormatAmount(row.CountStar),
+		lib.FormatAmount(slw.sl.Rate(row.CountStar)),
 		name)
 }
 
-type ByLatency stages_latency.Rows
-
-func (rows ByLatency) Len() int      { return len(rows) }
-func (rows ByLatency) Swap(i, j int) { rows[i], rows[j] = rows[j], rows[i] }
-
-// sort by value (descending) but also by "name" (ascending) if the values are the same
-func (rows ByLatency) Less(i, j int) bool {
-	return (rows[i].SumTimerWait > rows[j].SumTimerWait) ||
-		((rows[i].SumTimerWait == rows[j].SumTimerWait) && (rows[i].Name < rows[j].Name))
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row stages_latency.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "latency_ps", Value: row.SumTimerWait},
+		{Name: "count", Value: row.CountStar},
+	}
 }
@@ -0,0 +1,326 @@
+// Package table_detail holds the routines which show the details of a
+// single table: its index I/O, lock breakdown and file I/O.
+package table_detail
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/model/file_io"
+	"github.com/sjmudd/ps-top/model/index_usage"
+	"github.com/sjmudd/ps-top/model/table_locks"
+	"github.com/sjmudd/ps-top/rc"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/wrapper/file_io_latency"
+	indexUsage "github.com/sjmudd/ps-top/wrapper/index_usage"
+	"github.com/sjmudd/ps-top/wrapper/table_lock_latency"
+)
+
+// line is a line of the detail pane
+type line struct {
+	section string // index, lock or file
+	name    string // what the values refer to
+	latency uint64 // picoseconds
+	total   uint64 // latency the percentage is relative to
+	count   uint64 // number of operations or bytes
+	bytes   bool   // count holds bytes rather than operations
+}
+
+// Wrapper shows the details of a table using the data collected by
+// the index usage, table lock and file I/O views.
+type Wrapper struct {
+	name    string // <schema>.<table>
+	indexes *indexUsage.Wrapper
+	locks   *table_lock_latency.Wrapper
+	files   *file_io_latency.Wrapper
+}
+
+// NewTableDetail returns a wrapper showing the details of a table
+// from the data of the given views
+func NewTableDetail(indexes *indexUsage.Wrapper, locks *table_lock_latency.Wrapper, files *file_io_latency.Wrapper) *Wrapper {
+	return &Wrapper{
+		indexes: indexes,
+		locks:   locks,
+		files:   files,
+	}
+}
+
+// SetTable sets the name of the table, <schema>.<table>, to show
+func (tdw *Wrapper) SetTable(name string) {
+	tdw.name = name
+}
+
+// Table returns the name of the table being shown
+func (tdw Wrapper) Table() string {
+	return tdw.name
+}
+
+// Collect collects the data of the views used to show the details.
+// Collection continues if there are errors and the first one is returned.
+func (tdw *Wrapper) Collect() error {
+	var firstErr error
+	for _, collect := range []func() error{tdw.indexes.Collect, tdw.locks.Collect, tdw.files.Collect} {
+		if err := collect(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// munged returns the name of the table as used by the views which
+// munge table names, such as index usage and file I/O
+func (tdw Wrapper) munged() string {
+	return rc.Munge(tdw.name)
+}
+
+// indexLines returns the index I/O of the table, busiest index first
+func (tdw Wrapper) indexLines() []line {
+	var rows index_usage.Rows
+	var total uint64
+	for _, row := range tdw.indexes.IndexUsage().Results {
+		if row.Name == tdw.munged() {
+			rows = append(rows, row)
+			total += row.SumTimerWait
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].SumTimerWait > rows[j].SumTimerWait })
+
+	lines := make([]line, 0, len(rows))
+	for _, row := range rows {
+		name := row.Index
+		switch {
+		case row.FullScan():
+			name = "(no index) SCAN"
+		case row.Unused():
+			name += " UNUSED"
+		}
+		lines = append(lines, line{section: "index", name: name, latency: row.SumTimerWait, total: total, count: row.CountStar})
+	}
+
+	return lines
+}
+
+// lockLines returns the breakdown of the table lock latency
+func (tdw Wrapper) lockLines() []line {
+	var row table_locks.Row
+	for _, r := range tdw.locks.TableLocks().Results {
+		if r.Name == tdw.name {
+			row = r
+			break
+		}
+	}
+
+	lines := make([]line, 0, 12)
+	for _, l := range []struct {
+		name    string
+		latency uint64
+	}{
+		{"read", row.SumTimerRead},
+		{"  read normal", row.SumTimerReadNormal},
+		{"  read with shared locks", row.SumTimerReadWithSharedLocks},
+		{"  read high priority", row.SumTimerReadHighPriority},
+		{"  read no insert", row.SumTimerReadNoInsert},
+		{"  read external", row.SumTimerReadExternal},
+		{"write", row.SumTimerWrite},
+		{"  write allow write", row.SumTimerWriteAllowWrite},
+		{"  write concurrent insert", row.SumTimerWriteConcurrentInsert},
+		{"  write low priority", row.SumTimerWriteLowPriority},
+		{"  write normal", row.SumTimerWriteNormal},
+		{"  write external", row.SumTimerWriteExternal},
+	} {
+		lines = append(lines, line{section: "lock", name: l.name, latency: l.latency, total: row.SumTimerWait})
+	}
+
+	return lines
+}
+
+// fileLines returns the file I/O of the table's files
+func (tdw Wrapper) fileLines() []line {
+	var row file_io.Row
+	for _, r := range tdw.files.FileIoLatency().Results {
+		if r.Name == tdw.munged() {
+			row = r
+			break
+		}
+	}
+
+	return []line{
+		{section: "file", name: "read", latency: row.SumTimerRead, total: row.SumTimerWait, count: row.CountRead},
+		{section: "file", name: "  bytes read", count: row.SumNumberOfBytesRead, bytes: true},
+		{section: "file", name: "write", latency: row.SumTimerWrite, total: row.SumTimerWait, count: row.CountWrite},
+		{section: "file", name: "  bytes written", count: row.SumNumberOfBytesWrite, bytes: true},
+		{section: "file", name: "misc", latency: row.SumTimerMisc, total: row.SumTimerWait, count: row.CountMisc},
+	}
+}
+
+// sections returns the headings and lines of each section of the pane
+func (tdw Wrapper) sections() []struct {
+	heading string
+	lines   []line
+} {
+	return []struct {
+		heading string
+		lines   []line
+	}{
+		{"Index I/O (table_io_waits_summary_by_index_usage)", tdw.indexLines()},
+		{"Table Locks (table_lock_waits_summary_by_table)", tdw.lockLines()},
+		{"File I/O (file_summary_by_instance)", tdw.fileLines()},
+	}
+}
+
+// Headings returns the headings for the pane
+func (tdw Wrapper) Headings() string {
+	latency, count := "Latency", "Ops"
+	if tdw.wantRates() {
+		latency, count = "Latency/s", "Ops/s"
+	}
+
+	return fmt.Sprintf("%10s %6s %8s|%s", latency, "%", count, "Detail")
+}
+
+// SortHeading returns an empty string as the pane can not be sorted
+func (tdw Wrapper) SortHeading() string {
+	return ""
+}
+
+// RowContent returns the lines of each section of the pane
+func (tdw Wrapper) RowContent() []string {
+	var rows []string
+
+	for _, s := range tdw.sections() {
+		rows = append(rows, fmt.Sprintf("%10s %6s %8s|%s", "", "", "", s.heading))
+		for _, l := range s.lines {
+			rows = append(rows, tdw.content(l))
+		}
+	}
+
+	return rows
+}
+
+// TotalRowContent returns the index I/O latency of the table
+func (tdw Wrapper) TotalRowContent() string {
+	return tdw.content(tdw.total())
+}
+
+// EmptyRowContent returns an empty string of data (for filling in)
+func (tdw Wrapper) EmptyRowContent() string {
+	return tdw.content(line{})
+}
+
+// total returns the total index I/O of the table
+func (tdw Wrapper) total() line {
+	total := line{section: "index", name: tdw.name}
+	for _, l := range tdw.indexLines() {
+		total.latency += l.latency
+		total.count += l.count
+	}
+	total.total = total.latency
+
+	return total
+}
+
+// Records returns the lines of the pane as typed records
+func (tdw Wrapper) Records() []record.Record {
+	var records []record.Record
+
+	for _, s := range tdw.sections() {
+		for _, l := range s.lines {
+			records = append(records, newRecord(l))
+		}
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (tdw Wrapper) TotalRecord() record.Record {
+	return newRecord(tdw.total())
+}
+
+// Len returns the number of lines in the pane
+func (tdw Wrapper) Len() int {
+	return len(tdw.RowContent())
+}
+
+// Description returns a description of the pane
+func (tdw Wrapper) Description() string {
+	return fmt.Sprintf("Table Detail for %s (press <esc> to return)", tdw.name)
+}
+
+// HaveRelativeStats is true for this object
+func (tdw Wrapper) HaveRelativeStats() bool {
+	return tdw.indexes.HaveRelativeStats()
+}
+
+// WantRelativeStats indiates if we want relative statistics
+func (tdw Wrapper) WantRelativeStats() bool {
+	return tdw.indexes.WantRelativeStats()
+}
+
+// FirstCollectTime returns the time the first value was collected
+func (tdw Wrapper) FirstCollectTime() time.Time {
+	return tdw.indexes.FirstCollectTime()
+}
+
+// LastCollectTime returns the time the last value was collected
+func (tdw Wrapper) LastCollectTime() time.Time {
+	return tdw.indexes.LastCollectTime()
+}
+
+// LastTruncation returns when any of the tables used was last seen to be truncated
+func (tdw Wrapper) LastTruncation() time.Time {
+	last := tdw.indexes.LastTruncation()
+	for _, t := range []time.Time{tdw.locks.LastTruncation(), tdw.files.LastTruncation()} {
+		if t.After(last) {
+			last = t
+		}
+	}
+
+	return last
+}
+
+// wantRates returns true if we want to see values per second
+func (tdw Wrapper) wantRates() bool {
+	return tdw.indexes.IndexUsage().WantRates()
+}
+
+// rate returns the value per second if we want rates
+func (tdw Wrapper) rate(value uint64) uint64 {
+	return tdw.indexes.IndexUsage().Rate(value)
+}
+
+// content generates a printable line. The number of locks is not
+// collected so only their latency is shown.
+func (tdw Wrapper) content(l line) string {
+	var count string
+	if l.section != "lock" {
+		count = lib.FormatAmount(tdw.rate(l.count))
+	}
+
+	return fmt.Sprintf("%10s %6s %8s|%s",
+		lib.FormatTime(tdw.rate(l.latency)),
+		lib.FormatPct(lib.Divide(l.latency, l.total)),
+		count,
+		l.name)
+}
+
+// newRecord returns the line as a record with values in their raw units
+func newRecord(l line) record.Record {
+	var ops, bytes uint64
+	if l.bytes {
+		bytes = l.count
+	} else {
+		ops = l.count
+	}
+
+	return record.Record{
+		{Name: "section", Value: l.section},
+		{Name: "name", Value: l.name},
+		{Name: "latency_ps", Value: l.latency},
+		{Name: "ops", Value: ops},
+		{Name: "bytes", Value: bytes},
+	}
+}
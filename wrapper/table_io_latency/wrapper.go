@@ -5,22 +5,64 @@ import (
 	"database/sql"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
+	"github.com/sjmudd/ps-top/columns"
 	"github.com/sjmudd/ps-top/context"
 	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/model/table_io"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
 )
 
 // FileIoLatency represents the contents of the data collected from file_summary_by_instance
 type Wrapper struct {
-	tiol *table_io.TableIo
+	*sorting.Sorter
+	tiol   *table_io.TableIo
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "latency", Heading: 0},
+	{Name: "fetch_latency", Heading: 2},
+	{Name: "insert_latency", Heading: 3},
+	{Name: "update_latency", Heading: 4},
+	{Name: "delete_latency", Heading: 5},
+	{Name: "name", Heading: 6},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b table_io.Row) bool{
+	func(a, b table_io.Row) bool {
+		return sorting.Descending(a.SumTimerWait, b.SumTimerWait, a.Name, b.Name)
+	},
+	func(a, b table_io.Row) bool {
+		return sorting.Descending(a.SumTimerFetch, b.SumTimerFetch, a.Name, b.Name)
+	},
+	func(a, b table_io.Row) bool {
+		return sorting.Descending(a.SumTimerInsert, b.SumTimerInsert, a.Name, b.Name)
+	},
+	func(a, b table_io.Row) bool {
+		return sorting.Descending(a.SumTimerUpdate, b.SumTimerUpdate, a.Name, b.Name)
+	},
+	func(a, b table_io.Row) bool {
+		return sorting.Descending(a.SumTimerDelete, b.SumTimerDelete, a.Name, b.Name)
+	},
+	func(a, b table_io.Row) bool { return sorting.Ascending(a.Name, b.Name) },
 }
 
 // NewFileSummaryByInstance creates a wrapper around FileIoLatency
 func NewTableIoLatency(ctx *context.Context, db *sql.DB) *Wrapper {
+	return NewTableIoLatencyWithSource(ctx, table_io.NewMySQLSource(db))
+}
+
+// NewTableIoLatencyWithSource creates a wrapper collecting data from the given source
+func NewTableIoLatencyWithSource(ctx *context.Context, source table_io.Source) *Wrapper {
 	return &Wrapper{
-		tiol: table_io.NewTableIo(ctx, db),
+		Sorter: sorting.NewSorter(sortColumns...),
+		tiol:   table_io.NewTableIoWithSource(ctx, source),
 	}
 }
 
@@ -34,27 +76,51 @@ func (tiolw *Wrapper) SetFirstFromLast() {
 }
 
 // Collect data from the db, then merge it in.
-func (tiolw *Wrapper) Collect() {
-	tiolw.tiol.Collect()
+func (tiolw *Wrapper) Collect() error {
+	return tiolw.tiol.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (tiolw *Wrapper) SetLocker(locker sync.Locker) {
+	tiolw.tiol.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (tiolw *Wrapper) HideColumns(names []string) error {
+	return tiolw.hidden.Hide(tiolw.headings(), names)
+}
 
-	// sort the results by latency (might be needed in other places)
-	sort.Sort(byLatency(tiolw.tiol.Results))
+// sort the results by the current sort column
+func (tiolw Wrapper) sort() {
+	results := tiolw.tiol.Results
+	compare := less[tiolw.SortColumn()]
+
+	sort.Slice(results, tiolw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
+}
+
+// headings returns the individual column headings
+func (tiolw Wrapper) headings() []string {
+	latency := "Latency"
+	if tiolw.tiol.WantRates() {
+		latency = "Latency/s"
+	}
+
+	return []string{latency, "%", "Fetch", "Insert", "Update", "Delete", "Table Name"}
 }
 
 // Headings returns the latency headings as a string
 func (tiolw Wrapper) Headings() string {
-	return fmt.Sprintf("%10s %6s|%6s %6s %6s %6s|%s",
-		"Latency",
-		"%",
-		"Fetch",
-		"Insert",
-		"Update",
-		"Delete",
-		"Table Name")
+	return tiolw.hidden.Strings("%10s %6s|%6s %6s %6s %6s|%s", tiolw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (tiolw Wrapper) SortHeading() string {
+	return tiolw.headings()[tiolw.SortColumnHeading()]
 }
 
 // RowContent returns the rows we need for displaying
 func (tiolw Wrapper) RowContent() []string {
+	tiolw.sort()
 	rows := make([]string, 0, len(tiolw.tiol.Results))
 
 	for i := range tiolw.tiol.Results {
@@ -64,6 +130,23 @@ func (tiolw Wrapper) RowContent() []string {
 	return rows
 }
 
+// Records returns the rows as typed records in the current sort order
+func (tiolw Wrapper) Records() []record.Record {
+	tiolw.sort()
+	records := make([]record.Record, 0, len(tiolw.tiol.Results))
+
+	for i := range tiolw.tiol.Results {
+		records = append(records, newRecord(tiolw.tiol.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (tiolw Wrapper) TotalRecord() record.Record {
+	return newRecord(tiolw.tiol.Totals)
+}
+
 // Len return the length of the result set
 func (tiolw Wrapper) Len() int {
 	return len(tiolw.tiol.Results)
@@ -108,6 +191,11 @@ func (tiolw Wrapper) LastCollectTime() time.Time {
 	return tiolw.tiol.LastCollectTime()
 }
 
+// LastTruncation returns when the table was last seen to be truncated
+func (tiolw Wrapper) LastTruncation() time.Time {
+	return tiolw.tiol.LastTruncation()
+}
+
 func (tiolw Wrapper) WantRelativeStats() bool {
 	return tiolw.tiol.WantRelativeStats()
 }
@@ -125,8 +213,8 @@ func (tiolw Wrapper) content(row, totals table_io.Row) string {
 		name = ""
 	}
 
-	return fmt.Sprintf("%10s %6s|%6s %6s %6s %6s|%s",
-		lib.FormatTime(row.SumTimerWait),
+	return tiolw.hidden.Sprintf("%10s %6s|%6s %6s %6s %6s|%s",
+		lib.FormatTime(tiolw.tiol.Rate(row.SumTimerWait)),
 		lib.FormatPct(lib.Divide(row.SumTimerWait, totals.SumTimerWait)),
 		lib.FormatPct(lib.Divide(row.SumTimerFetch, row.SumTimerWait)),
 		lib.FormatPct(lib.Divide(row.SumTimerInsert, row.SumTimerWait)),
@@ -135,16 +223,14 @@ func (tiolw Wrapper) content(row, totals table_io.Row) string {
 		name)
 }
 
-// for sorting
-type byLatency table_io.Rows
-
-// sort the table_io.Rows by latency
-func (rows byLatency) Len() int      { return len(rows) }
-func (rows byLatency) Swap(i, j int) { rows[i], rows[j] = rows[j], rows[i] }
-
-// sort by value (descending) but also by "name" (ascending) if the values are the same
-func (rows byLatency) Less(i, j int) bool {
-	return (rows[i].SumTimerWait > rows[j].SumTimerWait) ||
-		((rows[i].SumTimerWait == rows[j].SumTimerWait) &&
-			(rows[i].Name < rows[j].Name))
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row table_io.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "latency_ps", Value: row.SumTimerWait},
+		{Name: "fetch_latency_ps", Value: row.SumTimerFetch},
+		{Name: "insert_latency_ps", Value: row.SumTimerInsert},
+		{Name: "update_latency_ps", Value: row.SumTimerUpdate},
+		{Name: "delete_latency_ps", Value: row.SumTimerDelete},
+	}
 }
@@ -0,0 +1,79 @@
+package table_io_latency
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/datasource"
+	"github.com/sjmudd/ps-top/global"
+	"github.com/sjmudd/ps-top/model/filter"
+	"github.com/sjmudd/ps-top/model/table_io"
+)
+
+func TestRecords(t *testing.T) {
+	first := table_io.Rows{
+		{Name: "db.orders", SumTimerWait: 100, SumTimerFetch: 60, SumTimerInsert: 40},
+		{Name: "db.items", SumTimerWait: 50, SumTimerFetch: 50},
+	}
+	second := table_io.Rows{
+		{Name: "db.orders", SumTimerWait: 110, SumTimerFetch: 60, SumTimerInsert: 50},
+		{Name: "db.items", SumTimerWait: 80, SumTimerFetch: 70, SumTimerUpdate: 10},
+	}
+	var tests = []struct {
+		relative bool
+		sort     string
+		expected []string
+		latency  []uint64
+	}{
+		{false, "latency", []string{"db.orders", "db.items"}, []uint64{110, 80}},
+		{false, "update_latency", []string{"db.items", "db.orders"}, []uint64{80, 110}},
+		{true, "latency", []string{"db.items", "db.orders"}, []uint64{30, 10}},
+		{true, "insert_latency", []string{"db.orders", "db.items"}, []uint64{10, 30}},
+		{true, "name", []string{"db.items", "db.orders"}, []uint64{30, 10}},
+	}
+
+	for _, test := range tests {
+		ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+		ctx.SetWantRelativeStats(test.relative)
+		source := &table_io.MemorySource{Rows: first}
+		tiolw := NewTableIoLatencyWithSource(ctx, source)
+		if err := tiolw.SetSortColumn(test.sort); err != nil {
+			t.Fatalf("SetSortColumn(%q): unexpected error: %v", test.sort, err)
+		}
+		tiolw.Collect()
+		source.Rows = second
+		tiolw.Collect()
+
+		var names []string
+		var latency []uint64
+		for _, r := range tiolw.Records() {
+			names = append(names, r[0].Value.(string))
+			latency = append(latency, r[1].Value.(uint64))
+		}
+		if !reflect.DeepEqual(names, test.expected) || !reflect.DeepEqual(latency, test.latency) {
+			t.Errorf("Records() relative: %v, sorted by %q: expected %v %v, actual %v %v", test.relative, test.sort, test.expected, test.latency, names, latency)
+		}
+	}
+}
+
+func TestHideColumns(t *testing.T) {
+	ctx := context.NewContext(datasource.NewMySQL(), nil, new(global.Variables), filter.NewDatabaseFilter(""))
+	source := &table_io.MemorySource{Rows: table_io.Rows{{Name: "db.orders", CountStar: 1, SumTimerWait: 100, SumTimerFetch: 100}}}
+	tiolw := NewTableIoLatencyWithSource(ctx, source)
+	if err := tiolw.HideColumns([]string{"insert", "update", "delete"}); err != nil {
+		t.Fatalf("HideColumns(): unexpected error: %v", err)
+	}
+	tiolw.Collect()
+
+	if expected := "   Latency      %| Fetch|Table Name"; tiolw.Headings() != expected {
+		t.Errorf("Headings(): expected %q, got %q", expected, tiolw.Headings())
+	}
+	if rows := tiolw.RowContent(); len(rows) != 1 || !strings.HasSuffix(rows[0], " 100.0%|100.0%|db.orders") {
+		t.Errorf("RowContent(): expected the fetch column and name only after the latency, got %q", rows)
+	}
+	if err := tiolw.HideColumns([]string{"junk"}); err == nil {
+		t.Errorf("HideColumns(%q): expected an error", "junk")
+	}
+}
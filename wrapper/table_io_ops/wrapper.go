@@ -4,22 +4,49 @@ package table_io_ops
 import (
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
+	"github.com/sjmudd/ps-top/columns"
 	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/model/table_io"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
 	"github.com/sjmudd/ps-top/wrapper/table_io_latency"
 )
 
 // FileIoLatency represents a wrapper around table_io
 type Wrapper struct {
-	tiol *table_io.TableIo
+	*sorting.Sorter
+	tiol   *table_io.TableIo
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "ops", Heading: 0},
+	{Name: "fetch_ops", Heading: 2},
+	{Name: "insert_ops", Heading: 3},
+	{Name: "update_ops", Heading: 4},
+	{Name: "delete_ops", Heading: 5},
+	{Name: "name", Heading: 6},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b table_io.Row) bool{
+	func(a, b table_io.Row) bool { return sorting.Descending(a.CountStar, b.CountStar, a.Name, b.Name) },
+	func(a, b table_io.Row) bool { return sorting.Descending(a.CountFetch, b.CountFetch, a.Name, b.Name) },
+	func(a, b table_io.Row) bool { return sorting.Descending(a.CountInsert, b.CountInsert, a.Name, b.Name) },
+	func(a, b table_io.Row) bool { return sorting.Descending(a.CountUpdate, b.CountUpdate, a.Name, b.Name) },
+	func(a, b table_io.Row) bool { return sorting.Descending(a.CountDelete, b.CountDelete, a.Name, b.Name) },
+	func(a, b table_io.Row) bool { return sorting.Ascending(a.Name, b.Name) },
 }
 
 // NewTableIoOps creates a wrapper around TableIo, sharing the same connection with the table_io_latency wrapper
 func NewTableIoOps(latency *table_io_latency.Wrapper) *Wrapper {
 	return &Wrapper{
-		tiol: latency.Tiol(),
+		Sorter: sorting.NewSorter(sortColumns...),
+		tiol:   latency.Tiol(),
 	}
 }
 
@@ -29,27 +56,51 @@ func (tiolw *Wrapper) SetFirstFromLast() {
 }
 
 // Collect data from the db, then merge it in.
-func (tiolw *Wrapper) Collect() {
-	tiolw.tiol.Collect()
+func (tiolw *Wrapper) Collect() error {
+	return tiolw.tiol.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (tiolw *Wrapper) SetLocker(locker sync.Locker) {
+	tiolw.tiol.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (tiolw *Wrapper) HideColumns(names []string) error {
+	return tiolw.hidden.Hide(tiolw.headings(), names)
+}
+
+// sort the results by the current sort column
+func (tiolw Wrapper) sort() {
+	results := tiolw.tiol.Results
+	compare := less[tiolw.SortColumn()]
+
+	sort.Slice(results, tiolw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
+}
+
+// headings returns the individual column headings
+func (tiolw Wrapper) headings() []string {
+	ops := "Ops"
+	if tiolw.tiol.WantRates() {
+		ops = "Ops/s"
+	}
 
-	// sort the results by ops
-	sort.Sort(ByOps(tiolw.tiol.Results))
+	return []string{ops, "%", "Fetch", "Insert", "Update", "Delete", "Table Name"}
 }
 
 // Headings returns the headings by operations as a string
 func (tiolw Wrapper) Headings() string {
-	return fmt.Sprintf("%10s %6s|%6s %6s %6s %6s|%s",
-		"Ops",
-		"%",
-		"Fetch",
-		"Insert",
-		"Update",
-		"Delete",
-		"Table Name")
+	return tiolw.hidden.Strings("%10s %6s|%6s %6s %6s %6s|%s", tiolw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (tiolw Wrapper) SortHeading() string {
+	return tiolw.headings()[tiolw.SortColumnHeading()]
 }
 
 // RowContent returns the rows we need for displaying
 func (tiolw Wrapper) RowContent() []string {
+	tiolw.sort()
 	rows := make([]string, 0, len(tiolw.tiol.Results))
 
 	for i := range tiolw.tiol.Results {
@@ -59,6 +110,23 @@ func (tiolw Wrapper) RowContent() []string {
 	return rows
 }
 
+// Records returns the rows as typed records in the current sort order
+func (tiolw Wrapper) Records() []record.Record {
+	tiolw.sort()
+	records := make([]record.Record, 0, len(tiolw.tiol.Results))
+
+	for i := range tiolw.tiol.Results {
+		records = append(records, newRecord(tiolw.tiol.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (tiolw Wrapper) TotalRecord() record.Record {
+	return newRecord(tiolw.tiol.Totals)
+}
+
 // Len return the length of the result set
 func (tiolw Wrapper) Len() int {
 	return len(tiolw.tiol.Results)
@@ -103,6 +171,11 @@ func (tiolw Wrapper) LastCollectTime() time.Time {
 	return tiolw.tiol.LastCollectTime()
 }
 
+// LastTruncation returns when the table was last seen to be truncated
+func (tiolw Wrapper) LastTruncation() time.Time {
+	return tiolw.tiol.LastTruncation()
+}
+
 func (tiolw Wrapper) WantRelativeStats() bool {
 	return tiolw.tiol.WantRelativeStats()
 }
@@ -115,8 +188,8 @@ func (tiolw Wrapper) content(row, totals table_io.Row) string {
 		name = ""
 	}
 
-	return fmt.Sprintf("%10s %6s|%6s %6s %6s %6s|%s",
-		lib.FormatAmount(row.CountStar),
+	return tiolw.hidden.Sprintf("%10s %6s|%6s %6s %6s %6s|%s",
+		lib.FormatAmount(tiolw.tiol.Rate(row.CountStar)),
 		lib.FormatPct(lib.Divide(row.CountStar, totals.CountStar)),
 		lib.FormatPct(lib.Divide(row.CountFetch, row.CountStar)),
 		lib.FormatPct(lib.Divide(row.CountInsert, row.CountStar)),
@@ -125,13 +198,14 @@ func (tiolw Wrapper) content(row, totals table_io.Row) string {
 		name)
 }
 
-// ByOps is used for sorting by the number of operations
-type ByOps table_io.Rows
-
-func (rows ByOps) Len() int      { return len(rows) }
-func (rows ByOps) Swap(i, j int) { rows[i], rows[j] = rows[j], rows[i] }
-func (rows ByOps) Less(i, j int) bool {
-	return (rows[i].CountStar > rows[j].CountStar) ||
-		((rows[i].SumTimerWait == rows[j].SumTimerWait) &&
-			(rows[i].Name < rows[j].Name))
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row table_io.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "ops", Value: row.CountStar},
+		{Name: "fetch_ops", Value: row.CountFetch},
+		{Name: "insert_ops", Value: row.CountInsert},
+		{Name: "update_ops", Value: row.CountUpdate},
+		{Name: "delete_ops", Value: row.CountDelete},
+	}
 }
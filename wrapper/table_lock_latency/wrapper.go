@@ -3,50 +3,122 @@ package table_lock_latency
 
 import (
 	"database/sql"
-	"fmt"
 	"sort"
+	"sync"
 	"time"
 
+	"github.com/sjmudd/ps-top/columns"
 	"github.com/sjmudd/ps-top/context"
 	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/model/table_locks"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
 )
 
 // Wrapper wraps a TableLockLatency struct
 type Wrapper struct {
-	tl *table_locks.TableLocks
+	*sorting.Sorter
+	tl     *table_locks.TableLocks
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "latency", Heading: 0},
+	{Name: "read_latency", Heading: 2},
+	{Name: "write_latency", Heading: 3},
+	{Name: "name", Heading: 14},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b table_locks.Row) bool{
+	func(a, b table_locks.Row) bool {
+		return sorting.Descending(a.SumTimerWait, b.SumTimerWait, a.Name, b.Name)
+	},
+	func(a, b table_locks.Row) bool {
+		return sorting.Descending(a.SumTimerRead, b.SumTimerRead, a.Name, b.Name)
+	},
+	func(a, b table_locks.Row) bool {
+		return sorting.Descending(a.SumTimerWrite, b.SumTimerWrite, a.Name, b.Name)
+	},
+	func(a, b table_locks.Row) bool { return sorting.Ascending(a.Name, b.Name) },
 }
 
 // NewTableLocks creates a wrapper around TableLockLatency
 func NewTableLockLatency(ctx *context.Context, db *sql.DB) *Wrapper {
+	return NewTableLockLatencyWithSource(ctx, table_locks.NewMySQLSource(db))
+}
+
+// NewTableLockLatencyWithSource creates a wrapper collecting data from the given source
+func NewTableLockLatencyWithSource(ctx *context.Context, source table_locks.Source) *Wrapper {
 	return &Wrapper{
-		tl: table_locks.NewTableLocks(ctx, db),
+		Sorter: sorting.NewSorter(sortColumns...),
+		tl:     table_locks.NewTableLocksWithSource(ctx, source),
 	}
 }
 
+// TableLocks returns the wrapped TableLocks so other views can use its data
+func (tlw *Wrapper) TableLocks() *table_locks.TableLocks {
+	return tlw.tl
+}
+
 // SetFirstFromLast resets the statistics to last values
 func (tlw *Wrapper) SetFirstFromLast() {
 	tlw.tl.SetFirstFromLast()
 }
 
 // Collect data from the db, then merge it in.
-func (tlw *Wrapper) Collect() {
-	tlw.tl.Collect()
-	sort.Sort(ByLatency(tlw.tl.Results))
+func (tlw *Wrapper) Collect() error {
+	return tlw.tl.Collect()
 }
 
-// Headings returns the headings for a table
-func (tlw Wrapper) Headings() string {
-	return fmt.Sprintf("%10s %6s|%6s %6s|%6s %6s %6s %6s %6s|%6s %6s %6s %6s %6s|%-30s",
-		"Latency", "%",
+// SetLocker sets the lock held while the collected data is changed
+func (tlw *Wrapper) SetLocker(locker sync.Locker) {
+	tlw.tl.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (tlw *Wrapper) HideColumns(names []string) error {
+	return tlw.hidden.Hide(tlw.headings(), names)
+}
+
+// sort the results by the current sort column
+func (tlw Wrapper) sort() {
+	results := tlw.tl.Results
+	compare := less[tlw.SortColumn()]
+
+	sort.Slice(results, tlw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
+}
+
+// headings returns the individual column headings
+func (tlw Wrapper) headings() []string {
+	latency := "Latency"
+	if tlw.tl.WantRates() {
+		latency = "Latency/s"
+	}
+
+	return []string{
+		latency, "%",
 		"Read", "Write",
 		"S.Lock", "High", "NoIns", "Normal", "Extrnl",
 		"AlloWr", "CncIns", "Low", "Normal", "Extrnl",
-		"Table Name")
+		"Table Name",
+	}
+}
+
+// Headings returns the headings for a table
+func (tlw Wrapper) Headings() string {
+	return tlw.hidden.Strings("%10s %6s|%6s %6s|%6s %6s %6s %6s %6s|%6s %6s %6s %6s %6s|%-30s", tlw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (tlw Wrapper) SortHeading() string {
+	return tlw.headings()[tlw.SortColumnHeading()]
 }
 
 // RowContent returns the rows we need for displaying
 func (tlw Wrapper) RowContent() []string {
+	tlw.sort()
 	rows := make([]string, 0, len(tlw.tl.Results))
 
 	for i := range tlw.tl.Results {
@@ -61,6 +133,23 @@ func (tlw Wrapper) TotalRowContent() string {
 	return tlw.content(tlw.tl.Totals, tlw.tl.Totals)
 }
 
+// Records returns the rows as typed records in the current sort order
+func (tlw Wrapper) Records() []record.Record {
+	tlw.sort()
+	records := make([]record.Record, 0, len(tlw.tl.Results))
+
+	for i := range tlw.tl.Results {
+		records = append(records, newRecord(tlw.tl.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (tlw Wrapper) TotalRecord() record.Record {
+	return newRecord(tlw.tl.Totals)
+}
+
 // Len return the length of the result set
 func (tlw Wrapper) Len() int {
 	return len(tlw.tl.Results)
@@ -93,6 +182,11 @@ func (tlw Wrapper) LastCollectTime() time.Time {
 	return tlw.tl.LastCollectTime()
 }
 
+// LastTruncation returns when the table was last seen to be truncated
+func (tlw Wrapper) LastTruncation() time.Time {
+	return tlw.tl.LastTruncation()
+}
+
 // WantRelativeStats indiates if we want relative statistics
 func (tlw Wrapper) WantRelativeStats() bool {
 	return tlw.tl.WantRelativeStats()
@@ -106,8 +200,8 @@ func (tlw Wrapper) content(row, totals table_locks.Row) string {
 		name = ""
 	}
 
-	return fmt.Sprintf("%10s %6s|%6s %6s|%6s %6s %6s %6s %6s|%6s %6s %6s %6s %6s|%s",
-		lib.FormatTime(row.SumTimerWait),
+	return tlw.hidden.Sprintf("%10s %6s|%6s %6s|%6s %6s %6s %6s %6s|%6s %6s %6s %6s %6s|%s",
+		lib.FormatTime(tlw.tl.Rate(row.SumTimerWait)),
 		lib.FormatPct(lib.Divide(row.SumTimerWait, totals.SumTimerWait)),
 
 		lib.FormatPct(lib.Divide(row.SumTimerRead, row.SumTimerWait)),
@@ -127,13 +221,22 @@ func (tlw Wrapper) content(row, totals table_locks.Row) string {
 		name)
 }
 
-type ByLatency table_locks.Rows
-
-func (t ByLatency) Len() int      { return len(t) }
-func (t ByLatency) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
-func (t ByLatency) Less(i, j int) bool {
-	return (t[i].SumTimerWait > t[j].SumTimerWait) ||
-		((t[i].SumTimerWait == t[j].SumTimerWait) &&
-			(t[i].Name < t[j].Name))
-
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row table_locks.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "latency_ps", Value: row.SumTimerWait},
+		{Name: "read_latency_ps", Value: row.SumTimerRead},
+		{Name: "write_latency_ps", Value: row.SumTimerWrite},
+		{Name: "read_with_shared_locks_latency_ps", Value: row.SumTimerReadWithSharedLocks},
+		{Name: "read_high_priority_latency_ps", Value: row.SumTimerReadHighPriority},
+		{Name: "read_no_insert_latency_ps", Value: row.SumTimerReadNoInsert},
+		{Name: "read_normal_latency_ps", Value: row.SumTimerReadNormal},
+		{Name: "read_external_latency_ps", Value: row.SumTimerReadExternal},
+		{Name: "write_allow_write_latency_ps", Value: row.SumTimerWriteAllowWrite},
+		{Name: "write_concurrent_insert_latency_ps", Value: row.SumTimerWriteConcurrentInsert},
+		{Name: "write_low_priority_latency_ps", Value: row.SumTimerWriteLowPriority},
+		{Name: "write_normal_latency_ps", Value: row.SumTimerWriteNormal},
+		{Name: "write_external_latency_ps", Value: row.SumTimerWriteExternal},
+	}
 }
@@ -0,0 +1,246 @@
+// Package threads holds the routines which manage the connections
+package threads
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sjmudd/ps-top/columns"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/model/threads"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
+)
+
+// Wrapper wraps a Threads struct
+type Wrapper struct {
+	*sorting.Sorter
+	t      *threads.Threads
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "latency", Heading: 0},
+	{Name: "time", Heading: 1},
+	{Name: "id", Heading: 2},
+	{Name: "user", Heading: 4},
+	{Name: "host", Heading: 5},
+	{Name: "db", Heading: 6},
+	{Name: "command", Heading: 7},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b threads.Row) bool{
+	func(a, b threads.Row) bool {
+		return (a.Latency > b.Latency) ||
+			((a.Latency == b.Latency) && (a.Time > b.Time)) ||
+			((a.Latency == b.Latency) && (a.Time == b.Time) && (a.ID < b.ID))
+	},
+	func(a, b threads.Row) bool {
+		return (a.Time > b.Time) ||
+			((a.Time == b.Time) && (a.ID < b.ID))
+	},
+	func(a, b threads.Row) bool { return a.ID < b.ID },
+	func(a, b threads.Row) bool {
+		return (a.User < b.User) ||
+			((a.User == b.User) && (a.ID < b.ID))
+	},
+	func(a, b threads.Row) bool {
+		return (a.Host < b.Host) ||
+			((a.Host == b.Host) && (a.ID < b.ID))
+	},
+	func(a, b threads.Row) bool {
+		return (a.Db < b.Db) ||
+			((a.Db == b.Db) && (a.ID < b.ID))
+	},
+	func(a, b threads.Row) bool {
+		return (a.Command < b.Command) ||
+			((a.Command == b.Command) && (a.ID < b.ID))
+	},
+}
+
+// NewThreads creates a wrapper around threads.Threads
+func NewThreads(ctx *context.Context, db *sql.DB) *Wrapper {
+	return NewThreadsWithSource(ctx, threads.NewMySQLSource(db))
+}
+
+// NewThreadsWithSource creates a wrapper collecting data from the given source
+func NewThreadsWithSource(ctx *context.Context, source threads.Source) *Wrapper {
+	return &Wrapper{
+		Sorter: sorting.NewSorter(sortColumns...),
+		t:      threads.NewThreadsWithSource(ctx, source),
+	}
+}
+
+// SetFirstFromLast resets the statistics to last values
+func (tw *Wrapper) SetFirstFromLast() {
+	tw.t.SetFirstFromLast()
+}
+
+// Collect data from the db
+func (tw *Wrapper) Collect() error {
+	return tw.t.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (tw *Wrapper) SetLocker(locker sync.Locker) {
+	tw.t.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (tw *Wrapper) HideColumns(names []string) error {
+	return tw.hidden.Hide(tw.headings(), names)
+}
+
+// ToggleIdle changes between showing and hiding the idle connections
+func (tw *Wrapper) ToggleIdle() {
+	tw.t.ToggleIdle()
+}
+
+// sort the results by the current sort column
+func (tw Wrapper) sort() {
+	results := tw.t.Results
+	compare := less[tw.SortColumn()]
+
+	sort.Slice(results, tw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
+}
+
+// headings returns the individual column headings
+func (tw Wrapper) headings() []string {
+	return []string{"Latency", "Time", "Id", "Tag", "User", "Host", "Db", "Command", "Stage", "Statement"}
+}
+
+// Headings returns the headings for a table
+func (tw Wrapper) Headings() string {
+	return tw.hidden.Strings("%10s %8s|%8s %-6s|%-12s %-20s %-12s|%-12s %-24s|%s", tw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (tw Wrapper) SortHeading() string {
+	return tw.headings()[tw.SortColumnHeading()]
+}
+
+// RowContent returns the rows we need for displaying
+func (tw Wrapper) RowContent() []string {
+	tw.sort()
+	rows := make([]string, 0, len(tw.t.Results))
+
+	for i := range tw.t.Results {
+		rows = append(rows, tw.content(tw.t.Results[i]))
+	}
+
+	return rows
+}
+
+// TotalRowContent returns all the totals
+func (tw Wrapper) TotalRowContent() string {
+	return tw.content(tw.t.Totals)
+}
+
+// Records returns the rows as typed records in the current sort order
+func (tw Wrapper) Records() []record.Record {
+	tw.sort()
+	records := make([]record.Record, 0, len(tw.t.Results))
+
+	for i := range tw.t.Results {
+		records = append(records, newRecord(tw.t.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (tw Wrapper) TotalRecord() record.Record {
+	return newRecord(tw.t.Totals)
+}
+
+// EmptyRowContent returns an empty string of data (for filling in)
+func (tw Wrapper) EmptyRowContent() string {
+	var empty threads.Row
+
+	return tw.content(empty)
+}
+
+// Description returns a description of the table
+func (tw Wrapper) Description() string {
+	idle := "including"
+	if !tw.t.ShowIdle() {
+		idle = "excluding"
+	}
+	return fmt.Sprintf("Connections (threads) %d rows %s idle connections (press i to toggle)", len(tw.t.Results), idle)
+}
+
+// HaveRelativeStats is false for this object
+func (tw Wrapper) HaveRelativeStats() bool {
+	return tw.t.HaveRelativeStats()
+}
+
+// FirstCollectTime returns the time the first value was collected
+func (tw Wrapper) FirstCollectTime() time.Time {
+	return tw.t.FirstCollectTime()
+}
+
+// LastCollectTime returns the time the last value was collected
+func (tw Wrapper) LastCollectTime() time.Time {
+	return tw.t.LastCollectTime()
+}
+
+// LastTruncation returns when the table was last seen to be truncated
+func (tw Wrapper) LastTruncation() time.Time {
+	return tw.t.LastTruncation()
+}
+
+// WantRelativeStats indiates if we want relative statistics
+func (tw Wrapper) WantRelativeStats() bool {
+	return tw.t.WantRelativeStats()
+}
+
+// Len return the length of the result set
+func (tw Wrapper) Len() int {
+	return len(tw.t.Results)
+}
+
+// truncate returns s cut down to width characters
+func truncate(s string, width int) string {
+	if len(s) > width {
+		return s[:width]
+	}
+	return s
+}
+
+// content generate a printable result for a row
+func (tw Wrapper) content(row threads.Row) string {
+	return tw.hidden.Sprintf("%10s %8s|%8s %-6s|%-12s %-20s %-12s|%-12s %-24s|%s",
+		lib.FormatTime(row.Latency),
+		lib.FormatSeconds(row.Time),
+		lib.FormatCounter(int(row.ID), 8),
+		row.Tag,
+		truncate(row.User, 12),
+		truncate(row.Host, 20),
+		truncate(row.Db, 12),
+		truncate(row.Command, 12),
+		truncate(row.Stage, 24),
+		row.Statement)
+}
+
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row threads.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.User},
+		{Name: "id", Value: row.ID},
+		{Name: "thread_id", Value: row.ThreadID},
+		{Name: "tag", Value: row.Tag},
+		{Name: "host", Value: row.Host},
+		{Name: "db", Value: row.Db},
+		{Name: "command", Value: row.Command},
+		{Name: "time_seconds", Value: row.Time},
+		{Name: "stage", Value: row.Stage},
+		{Name: "latency_ps", Value: row.Latency},
+		{Name: "statement", Value: row.Statement},
+	}
+}
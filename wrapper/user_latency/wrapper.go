@@ -5,22 +5,72 @@ import (
 	"database/sql"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
+	"github.com/sjmudd/ps-top/columns"
 	"github.com/sjmudd/ps-top/context"
 	"github.com/sjmudd/ps-top/lib"
 	"github.com/sjmudd/ps-top/model/user_latency"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
 )
 
 // Wrapper wraps a UserLatency struct
 type Wrapper struct {
-	ul *user_latency.UserLatency
+	*sorting.Sorter
+	ul     *user_latency.UserLatency
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "latency", Heading: 0},
+	{Name: "time", Heading: 2},
+	{Name: "sleep_time", Heading: 3},
+	{Name: "connections", Heading: 4},
+	{Name: "active", Heading: 5},
+	{Name: "hosts", Heading: 6},
+	{Name: "dbs", Heading: 7},
+	{Name: "statements", Heading: 8},
+	{Name: "name", Heading: 13},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b user_latency.Row) bool{
+	func(a, b user_latency.Row) bool { return sorting.Descending(a.Latency, b.Latency, a.Name, b.Name) },
+	func(a, b user_latency.Row) bool {
+		return (a.TotalTime() > b.TotalTime()) ||
+			((a.TotalTime() == b.TotalTime()) && (a.Connections > b.Connections)) ||
+			((a.TotalTime() == b.TotalTime()) && (a.Connections == b.Connections) && (a.Name < b.Name))
+	},
+	func(a, b user_latency.Row) bool {
+		return sorting.Descending(a.Sleeptime, b.Sleeptime, a.Name, b.Name)
+	},
+	func(a, b user_latency.Row) bool {
+		return sorting.Descending(a.Connections, b.Connections, a.Name, b.Name)
+	},
+	func(a, b user_latency.Row) bool {
+		return sorting.Descending(a.Active, b.Active, a.Name, b.Name)
+	},
+	func(a, b user_latency.Row) bool { return sorting.Descending(a.Hosts, b.Hosts, a.Name, b.Name) },
+	func(a, b user_latency.Row) bool { return sorting.Descending(a.Dbs, b.Dbs, a.Name, b.Name) },
+	func(a, b user_latency.Row) bool {
+		return sorting.Descending(a.Statements(), b.Statements(), a.Name, b.Name)
+	},
+	func(a, b user_latency.Row) bool { return sorting.Ascending(a.Name, b.Name) },
 }
 
 // NewUserLatency creates a wrapper around UserLatency
 func NewUserLatency(ctx *context.Context, db *sql.DB) *Wrapper {
+	return NewUserLatencyWithSource(ctx, user_latency.NewMySQLSource(db, ctx.Capabilities()))
+}
+
+// NewUserLatencyWithSource creates a wrapper collecting data from the given source
+func NewUserLatencyWithSource(ctx *context.Context, source user_latency.Source) *Wrapper {
 	return &Wrapper{
-		ul: user_latency.NewUserLatency(ctx, db),
+		Sorter: sorting.NewSorter(sortColumns...),
+		ul:     user_latency.NewUserLatencyWithSource(ctx, source),
 	}
 }
 
@@ -30,13 +80,36 @@ func (ulw *Wrapper) SetFirstFromLast() {
 }
 
 // Collect data from the db, then sort the results.
-func (ulw *Wrapper) Collect() {
-	ulw.ul.Collect()
-	sort.Sort(ByRunTime(ulw.ul.Results))
+func (ulw *Wrapper) Collect() error {
+	return ulw.ul.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (ulw *Wrapper) SetLocker(locker sync.Locker) {
+	ulw.ul.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (ulw *Wrapper) HideColumns(names []string) error {
+	return ulw.hidden.Hide(ulw.headings(), names)
+}
+
+// NextGrouping groups the results by the next of user, host or account
+func (ulw *Wrapper) NextGrouping() {
+	ulw.ul.NextGrouping()
+}
+
+// sort the results by the current sort column
+func (ulw Wrapper) sort() {
+	results := ulw.ul.Results
+	compare := less[ulw.SortColumn()]
+
+	sort.Slice(results, ulw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
 }
 
 // RowContent returns the rows we need for displaying
 func (ulw Wrapper) RowContent() []string {
+	ulw.sort()
 	rows := make([]string, 0, len(ulw.ul.Results))
 
 	for i := range ulw.ul.Results {
@@ -51,6 +124,23 @@ func (ulw Wrapper) TotalRowContent() string {
 	return ulw.content(ulw.ul.Totals, ulw.ul.Totals)
 }
 
+// Records returns the rows as typed records in the current sort order
+func (ulw Wrapper) Records() []record.Record {
+	ulw.sort()
+	records := make([]record.Record, 0, len(ulw.ul.Results))
+
+	for i := range ulw.ul.Results {
+		records = append(records, newRecord(ulw.ul.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (ulw Wrapper) TotalRecord() record.Record {
+	return newRecord(ulw.ul.Totals)
+}
+
 // Len return the length of the result set
 func (ulw Wrapper) Len() int {
 	return len(ulw.ul.Results)
@@ -78,6 +168,11 @@ func (ulw Wrapper) LastCollectTime() time.Time {
 	return ulw.ul.LastCollectTime()
 }
 
+// LastTruncation returns when the table was last seen to be truncated
+func (ulw Wrapper) LastTruncation() time.Time {
+	return ulw.ul.LastTruncation()
+}
+
 // WantRelativeStats indiates if we want relative statistics
 func (ulw Wrapper) WantRelativeStats() bool {
 	return ulw.ul.WantRelativeStats()
@@ -87,45 +182,72 @@ func (ulw Wrapper) WantRelativeStats() bool {
 func (ulw Wrapper) Description() string {
 	var count int
 	for row := range ulw.ul.Results {
-		if ulw.ul.Results[row].Username != "" {
+		if ulw.ul.Results[row].Name != "" {
 			count++
 		}
 	}
-	return fmt.Sprintf("Activity by Username (processlist) %d rows", count)
+	return fmt.Sprintf("Activity by %s (processlist, events_statements_summary_by_%s_by_event_name) %d rows", ulw.ul.Grouping(), ulw.ul.Grouping(), count)
+}
+
+// headings returns the individual column headings
+func (ulw Wrapper) headings() []string {
+	latency := "Latency"
+	if ulw.ul.WantRates() {
+		latency = "Latency/s"
+	}
+	name := map[user_latency.Grouping]string{
+		user_latency.ByUser:    "User",
+		user_latency.ByHost:    "Host",
+		user_latency.ByAccount: "Account",
+	}[ulw.ul.Grouping()]
+
+	return []string{latency, "%", "Run Time", "Sleeping", "Conn", "Actv", "Hosts", "DBs", "Sel", "Ins", "Upd", "Del", "Oth", name}
 }
 
 // Headings returns the headings for a table
 func (ulw Wrapper) Headings() string {
-	return fmt.Sprintf("%-9s %6s|%-8s %6s|%4s %4s|%5s %3s|%3s %3s %3s %3s %3s|%s",
-		"Run Time", "%", "Sleeping", "%", "Conn", "Actv", "Hosts", "DBs", "Sel", "Ins", "Upd", "Del", "Oth", "User")
+	return ulw.hidden.Strings("%10s %6s|%-8s %-8s|%4s %4s|%5s %3s|%8s %8s %8s %8s %8s|%s", ulw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (ulw Wrapper) SortHeading() string {
+	return ulw.headings()[ulw.SortColumnHeading()]
 }
 
 // content generate a printable result for a row, given the totals
 func (ulw Wrapper) content(row, totals user_latency.Row) string {
-	return fmt.Sprintf("%9s %6s|%8s %6s|%4s %4s|%5s %3s|%3s %3s %3s %3s %3s|%s",
+	return ulw.hidden.Sprintf("%10s %6s|%8s %8s|%4s %4s|%5s %3s|%8s %8s %8s %8s %8s|%s",
+		lib.FormatTime(ulw.ul.Rate(row.Latency)),
+		lib.FormatPct(lib.Divide(row.Latency, totals.Latency)),
 		lib.FormatSeconds(row.Runtime),
-		lib.FormatPct(lib.Divide(row.Runtime, totals.Runtime)),
 		lib.FormatSeconds(row.Sleeptime),
-		lib.FormatPct(lib.Divide(row.Sleeptime, totals.Sleeptime)),
 		lib.FormatCounter(int(row.Connections), 4),
 		lib.FormatCounter(int(row.Active), 4),
 		lib.FormatCounter(int(row.Hosts), 5),
 		lib.FormatCounter(int(row.Dbs), 3),
-		lib.FormatCounter(int(row.Selects), 3),
-		lib.FormatCounter(int(row.Inserts), 3),
-		lib.FormatCounter(int(row.Updates), 3),
-		lib.FormatCounter(int(row.Deletes), 3),
-		lib.FormatCounter(int(row.Other), 3),
-		row.Username)
-}
-
-// ByRunTime is for sorting rows by Runtime
-type ByRunTime user_latency.Rows
-
-func (t ByRunTime) Len() int      { return len(t) }
-func (t ByRunTime) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
-func (t ByRunTime) Less(i, j int) bool {
-	return (t[i].TotalTime() > t[j].TotalTime()) ||
-		((t[i].TotalTime() == t[j].TotalTime()) && (t[i].Connections > t[j].Connections)) ||
-		((t[i].TotalTime() == t[j].TotalTime()) && (t[i].Connections == t[j].Connections) && (t[i].Username < t[j].Username))
+		lib.FormatAmount(ulw.ul.Rate(row.Selects)),
+		lib.FormatAmount(ulw.ul.Rate(row.Inserts)),
+		lib.FormatAmount(ulw.ul.Rate(row.Updates)),
+		lib.FormatAmount(ulw.ul.Rate(row.Deletes)),
+		lib.FormatAmount(ulw.ul.Rate(row.Other)),
+		row.Name)
+}
+
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row user_latency.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "latency_ps", Value: row.Latency},
+		{Name: "runtime_seconds", Value: row.Runtime},
+		{Name: "sleeptime_seconds", Value: row.Sleeptime},
+		{Name: "connections", Value: row.Connections},
+		{Name: "active", Value: row.Active},
+		{Name: "hosts", Value: row.Hosts},
+		{Name: "dbs", Value: row.Dbs},
+		{Name: "selects", Value: row.Selects},
+		{Name: "inserts", Value: row.Inserts},
+		{Name: "updates", Value: row.Updates},
+		{Name: "deletes", Value: row.Deletes},
+		{Name: "other", Value: row.Other},
+	}
 }
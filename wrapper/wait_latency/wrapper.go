@@ -0,0 +1,233 @@
+// Package wait_latency holds the routines which manage the wait events
+package wait_latency
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sjmudd/ps-top/columns"
+	"github.com/sjmudd/ps-top/context"
+	"github.com/sjmudd/ps-top/lib"
+	"github.com/sjmudd/ps-top/model/wait_latency"
+	"github.com/sjmudd/ps-top/record"
+	"github.com/sjmudd/ps-top/sorting"
+)
+
+// Wrapper wraps a WaitLatency struct
+type Wrapper struct {
+	*sorting.Sorter
+	wl     *wait_latency.WaitLatency
+	hidden columns.Hidden // columns which are not shown
+}
+
+// sortColumns are the columns the results can be sorted by, the order matching less below
+var sortColumns = []sorting.Column{
+	{Name: "latency", Heading: 0},
+	{Name: "count", Heading: 1},
+	{Name: "avg_latency", Heading: 3},
+	{Name: "max_latency", Heading: 4},
+	{Name: "name", Heading: 5},
+}
+
+// less holds the comparison functions for each of the sortColumns
+var less = []func(a, b wait_latency.Row) bool{
+	func(a, b wait_latency.Row) bool {
+		return sorting.Descending(a.SumTimerWait, b.SumTimerWait, a.Name, b.Name)
+	},
+	func(a, b wait_latency.Row) bool { return sorting.Descending(a.CountStar, b.CountStar, a.Name, b.Name) },
+	func(a, b wait_latency.Row) bool {
+		return sorting.Descending(a.AvgTimerWait(), b.AvgTimerWait(), a.Name, b.Name)
+	},
+	func(a, b wait_latency.Row) bool {
+		return sorting.Descending(a.MaxTimerWait, b.MaxTimerWait, a.Name, b.Name)
+	},
+	func(a, b wait_latency.Row) bool { return sorting.Ascending(a.Name, b.Name) },
+}
+
+// NewWaitLatency creates a wrapper around wait_latency.WaitLatency collecting the given wait classes
+func NewWaitLatency(ctx *context.Context, db *sql.DB, classes []string) *Wrapper {
+	return NewWaitLatencyWithSource(ctx, wait_latency.NewMySQLSource(db, classes))
+}
+
+// NewWaitLatencyWithSource creates a wrapper collecting data from the given source
+func NewWaitLatencyWithSource(ctx *context.Context, source wait_latency.Source) *Wrapper {
+	return &Wrapper{
+		Sorter: sorting.NewSorter(sortColumns...),
+		wl:     wait_latency.NewWaitLatencyWithSource(ctx, source),
+	}
+}
+
+// Instruments returns the setup_instruments names needed to collect the given wait classes
+func Instruments(classes []string) []string {
+	return wait_latency.Instruments(classes)
+}
+
+// SetFirstFromLast resets the statistics to last values
+func (wlw *Wrapper) SetFirstFromLast() {
+	wlw.wl.SetFirstFromLast()
+}
+
+// Collect data from the db, then merge it in.
+func (wlw *Wrapper) Collect() error {
+	return wlw.wl.Collect()
+}
+
+// SetLocker sets the lock held while the collected data is changed
+func (wlw *Wrapper) SetLocker(locker sync.Locker) {
+	wlw.wl.SetLocker(locker)
+}
+
+// HideColumns hides the named columns
+func (wlw *Wrapper) HideColumns(names []string) error {
+	return wlw.hidden.Hide(wlw.headings(), names)
+}
+
+// Expand shows one more level of the event hierarchy
+func (wlw *Wrapper) Expand() {
+	wlw.wl.Expand()
+}
+
+// Collapse shows one less level of the event hierarchy
+func (wlw *Wrapper) Collapse() {
+	wlw.wl.Collapse()
+}
+
+// sort the results by the current sort column
+func (wlw Wrapper) sort() {
+	results := wlw.wl.Results
+	compare := less[wlw.SortColumn()]
+
+	sort.Slice(results, wlw.Less(func(i, j int) bool { return compare(results[i], results[j]) }))
+}
+
+// RowContent returns the rows we need for displaying
+func (wlw Wrapper) RowContent() []string {
+	wlw.sort()
+	rows := make([]string, 0, len(wlw.wl.Results))
+
+	for i := range wlw.wl.Results {
+		rows = append(rows, wlw.content(wlw.wl.Results[i], wlw.wl.Totals))
+	}
+
+	return rows
+}
+
+// TotalRowContent returns all the totals
+func (wlw Wrapper) TotalRowContent() string {
+	return wlw.content(wlw.wl.Totals, wlw.wl.Totals)
+}
+
+// Records returns the rows as typed records in the current sort order
+func (wlw Wrapper) Records() []record.Record {
+	wlw.sort()
+	records := make([]record.Record, 0, len(wlw.wl.Results))
+
+	for i := range wlw.wl.Results {
+		records = append(records, newRecord(wlw.wl.Results[i]))
+	}
+
+	return records
+}
+
+// TotalRecord returns the totals as a typed record
+func (wlw Wrapper) TotalRecord() record.Record {
+	return newRecord(wlw.wl.Totals)
+}
+
+// Len return the length of the result set
+func (wlw Wrapper) Len() int {
+	return len(wlw.wl.Results)
+}
+
+// EmptyRowContent returns an empty string of data (for filling in)
+func (wlw Wrapper) EmptyRowContent() string {
+	var empty wait_latency.Row
+
+	return wlw.content(empty, empty)
+}
+
+// HaveRelativeStats is true for this object
+func (wlw Wrapper) HaveRelativeStats() bool {
+	return wlw.wl.HaveRelativeStats()
+}
+
+// FirstCollectTime returns the time the first value was collected
+func (wlw Wrapper) FirstCollectTime() time.Time {
+	return wlw.wl.FirstCollectTime()
+}
+
+// LastCollectTime returns the time the last value was collected
+func (wlw Wrapper) LastCollectTime() time.Time {
+	return wlw.wl.LastCollectTime()
+}
+
+// LastTruncation returns when the table was last seen to be truncated
+func (wlw Wrapper) LastTruncation() time.Time {
+	return wlw.wl.LastTruncation()
+}
+
+// WantRelativeStats indiates if we want relative statistics
+func (wlw Wrapper) WantRelativeStats() bool {
+	return wlw.wl.WantRelativeStats()
+}
+
+// Description returns a description of the table
+func (wlw Wrapper) Description() string {
+	var count int
+	for row := range wlw.wl.Results {
+		if wlw.wl.Results[row].SumTimerWait > 0 {
+			count++
+		}
+	}
+	return fmt.Sprintf("Wait Latency (events_waits_summary_global_by_event_name) level %d (e/c to expand/collapse) %d rows", wlw.wl.Depth(), count)
+}
+
+// headings returns the individual column headings
+func (wlw Wrapper) headings() []string {
+	latency, count := "Latency", "Count"
+	if wlw.wl.WantRates() {
+		latency, count = "Latency/s", "Count/s"
+	}
+
+	return []string{latency, count, "%", "Avg", "Max", "Wait Event"}
+}
+
+// Headings returns the headings for a table
+func (wlw Wrapper) Headings() string {
+	return wlw.hidden.Strings("%10s %8s %6s %10s %10s|%s", wlw.headings())
+}
+
+// SortHeading returns the heading of the column the results are sorted by
+func (wlw Wrapper) SortHeading() string {
+	return wlw.headings()[wlw.SortColumnHeading()]
+}
+
+// content generate a printable result for a row, given the totals
+func (wlw Wrapper) content(row, totals wait_latency.Row) string {
+	name := row.Name
+	if row.CountStar == 0 && name != "Totals" {
+		name = ""
+	}
+
+	return wlw.hidden.Sprintf("%10s %8s %6s %10s %10s|%s",
+		lib.FormatTime(wlw.wl.Rate(row.SumTimerWait)),
+		lib.FormatAmount(wlw.wl.Rate(row.CountStar)),
+		lib.FormatPct(lib.Divide(row.SumTimerWait, totals.SumTimerWait)),
+		lib.FormatTime(row.AvgTimerWait()),
+		lib.FormatTime(row.MaxTimerWait),
+		name)
+}
+
+// newRecord returns the row as a record with values in their raw units
+func newRecord(row wait_latency.Row) record.Record {
+	return record.Record{
+		{Name: "name", Value: row.Name},
+		{Name: "latency_ps", Value: row.SumTimerWait},
+		{Name: "count", Value: row.CountStar},
+		{Name: "avg_latency_ps", Value: row.AvgTimerWait()},
+		{Name: "max_latency_ps", Value: row.MaxTimerWait},
+	}
+}